@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/config"
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+	"github.com/cassiuspaim/portimporter/infrastructure/redact"
+	"github.com/cassiuspaim/portimporter/infrastructure/tracing"
+)
+
+// configFlags are the flags of a command that override the configuration.
+type configFlags struct {
+	// flags of the command, whose given flags override the configuration even when they are zero.
+	flags     *flag.FlagSet
+	path      *string
+	overrides config.Config
+	// runID identifies the run in the logs and in the provenance of the imported values, set when
+	// the configuration is loaded. The daemon and watch commands give each of their imports its own.
+	runID string
+	// Flushes the spans, set when the configuration is loaded.
+	shutdownTracing func(context.Context) error
+}
+
+// Registers the flags of the configuration file and of the logs, shared by every command.
+func registerConfigFlags(flags *flag.FlagSet) *configFlags {
+	configFlags := &configFlags{
+		flags: flags,
+		path:  flags.String("config", "", "YAML or TOML configuration file. Overrides "+config.FileEnv+"."),
+	}
+
+	flags.StringVar(&configFlags.overrides.Log.Level, "log-level", "",
+		"Minimum level of the logs: debug, info, warn or error. Overrides LOG_LEVEL.")
+	flags.StringVar(&configFlags.overrides.Log.Format, "log-format", "", "Format of the logs: text or json. "+
+		"Overrides LOG_FORMAT.")
+	flags.Func("log-component-levels", "Levels by component, e.g. jsonstream=debug,mongodb=warn. "+
+		"Overrides LOG_COMPONENT_LEVELS.", func(value string) error {
+		configFlags.overrides.Log.ComponentLevels = strings.Split(value, ",")
+
+		return nil
+	})
+	flags.StringVar(&configFlags.overrides.Tracing.Exporter, "trace-exporter", "",
+		"Exporter of the traces: none, stdout, file or otlp. Overrides TRACE_EXPORTER.")
+	flags.StringVar(&configFlags.overrides.Tracing.File, "trace-file", "",
+		"File where the file exporter writes the traces. Overrides TRACE_FILE.")
+
+	return configFlags
+}
+
+// Registers the flags of the store.
+func (c *configFlags) registerDatabase(flags *flag.FlagSet) {
+	flags.StringVar(&c.overrides.Database.Driver, "store", "", "Where the ports are stored: mongodb, postgres, "+
+		"sqlite or memory. Overrides DB_DRIVER. The memory store validates the file end-to-end without a database.")
+	flags.StringVar(&c.overrides.Database.URI, "db-uri", "",
+		"Connection URI, or file for sqlite. Overrides DB_CONNECTION_URI.")
+	flags.StringVar(&c.overrides.Database.Name, "db-name", "", "Database used by the application. Overrides DB_NAME.")
+	flags.StringVar(&c.overrides.Database.AuthName, "db-auth-name", "",
+		"Database used to authenticate. Overrides DB_AUTHENTICATION_NAME.")
+	flags.StringVar(&c.overrides.Database.UserName, "db-user", "",
+		"User to connect with database. Overrides DB_USER_NAME.")
+	flags.StringVar((*string)(&c.overrides.Database.Password), "db-password", "",
+		"Password to connect to database. Overrides DB_USER_PASSWORD and DB_USER_PASSWORD_FILE. "+
+			"Prefer DB_USER_PASSWORD_FILE, flags are visible to other processes.")
+	flags.IntVar(&c.overrides.Database.Retry.Attempts, "db-retry-attempts", 0,
+		"Maximum attempts of each operation of the store failing with transient errors. Overrides DB_RETRY_ATTEMPTS, "+
+			"default 5.")
+	flags.IntVar(&c.overrides.Database.Retry.ConnectAttempts, "db-connect-attempts", 0,
+		"Maximum attempts to connect to the store. Overrides DB_CONNECT_ATTEMPTS, default 10.")
+}
+
+// Registers the flags of the file to be imported.
+func (c *configFlags) registerImport(flags *flag.FlagSet) {
+	flags.StringVar(&c.overrides.Import.File, "file", "", "JSON file with the ports. Overrides PORT_JSON_PATH.")
+	flags.StringVar(&c.overrides.Import.Duplicates, "duplicates", "", "When a key is repeated at a file: last-wins, "+
+		"first-wins or fail the repeated entry. Overrides IMPORT_DUPLICATES, default last-wins.")
+	flags.StringVar(&c.overrides.Import.Normalize, "normalize", "", "How the imported texts are normalized: off, on "+
+		"or fold, which also stores the name and the city folded to ASCII. Overrides IMPORT_NORMALIZE, default on.")
+	flags.StringVar(&c.overrides.Import.Countries, "countries", "", "Whether the ISO 3166 codes of the country and "+
+		"the province are set and the countries not matching the keys flagged: off or on. Overrides IMPORT_COUNTRIES, "+
+		"default on.")
+	flags.StringVar(&c.overrides.Import.Coordinates, "coordinates", "", "How the coordinates are checked against the "+
+		"bounds of the country: off, check, which flags the ones outside it and the swapped ones or with a wrong sign, "+
+		"or fix, which also corrects the swapped ones and the ones with a wrong sign. Overrides IMPORT_COORDINATES, "+
+		"default check.")
+	flags.StringVar(&c.overrides.Import.Timezones, "timezones", "", "How the timezones are inferred from the "+
+		"coordinates: off, check, which flags the missing ones and the ones disagreeing with the coordinates, or fill, "+
+		"which also sets the missing ones. Overrides IMPORT_TIMEZONES, default fill.")
+}
+
+// Registers the flags of the sources imported together.
+func (c *configFlags) registerSources(flags *flag.FlagSet) {
+	flags.Func("sources", "Files imported together instead of --file, as path=priority separated by commas, e.g. "+
+		"unlocode.json=10,partner.json=5. Each field takes the value of the highest priority. Overrides IMPORT_SOURCES.",
+		func(value string) error {
+			c.overrides.Import.Sources = strings.Split(value, ",")
+
+			return nil
+		})
+}
+
+// Registers the flags of the upserts of the imported ports.
+func (c *configFlags) registerUpsert(flags *flag.FlagSet) {
+	flags.Func("merge", "Merge policies of the fields of the stored ports, e.g. alias=union,timezone=keep-existing. "+
+		"Policies: overwrite, keep-existing, union or only-if-empty. Overrides IMPORT_MERGE.", func(value string) error {
+		c.overrides.Import.Merge = strings.Split(value, ",")
+
+		return nil
+	})
+}
+
+// Registers the flags of the recurring imports.
+func (c *configFlags) registerDaemon(flags *flag.FlagSet) {
+	flags.StringVar(&c.overrides.Daemon.Schedule, "schedule", "",
+		"Cron expression of the imports, e.g. \"*/15 * * * *\" or @hourly. Overrides DAEMON_SCHEDULE.")
+	flags.DurationVar(&c.overrides.Daemon.Interval, "interval", 0,
+		"Time between the end of an import and the start of the next one, e.g. 15m. Overrides DAEMON_INTERVAL.")
+}
+
+// Registers the flags of the imports run when the file changes.
+func (c *configFlags) registerWatch(flags *flag.FlagSet) {
+	flags.DurationVar(&c.overrides.Watch.Debounce, "debounce", 0,
+		"How long the file must have no changes before it is imported. Overrides WATCH_DEBOUNCE, default 500ms.")
+}
+
+// Registers the flags of the lock of the imports.
+func (c *configFlags) registerLock(flags *flag.FlagSet) {
+	flags.StringVar(&c.overrides.Lock.Mode, "lock", "",
+		"When another instance is importing: fail, wait or off to import anyway. Overrides LOCK_MODE, default fail.")
+}
+
+// Registers the flags of the HTTP server.
+func (c *configFlags) registerServer(flags *flag.FlagSet) {
+	flags.StringVar(&c.overrides.Server.Address, "addr", "",
+		"Address the server listens to. Overrides SERVE_ADDRESS, default :8080.")
+}
+
+// Registers the flags of the Prometheus metrics.
+func (c *configFlags) registerMetrics(flags *flag.FlagSet) {
+	flags.StringVar(&c.overrides.Metrics.Address, "metrics-addr", "",
+		"Address where GET /metrics is served while importing. Overrides METRICS_ADDRESS, disabled by default.")
+}
+
+// Loads the configuration, sets up the logs and validates the configuration with every
+// validation. From then on the secrets of the configuration are redacted from the logs.
+// When the configuration is valid the traces are set up, close must be called to flush them.
+func (c *configFlags) load(validations ...func(config.Config) error) (config.Config, error) {
+	var given []string
+
+	c.flags.Visit(func(flag *flag.Flag) { given = append(given, flag.Name) })
+
+	cfg, err := config.Load(*c.path, c.overrides, given...)
+	if err != nil {
+		return cfg, err
+	}
+
+	c.runID = logging.NewRunID()
+
+	err = logging.Setup(logging.Config{
+		Format:          cfg.Log.Format,
+		Level:           cfg.Log.Level,
+		ComponentLevels: cfg.Log.ComponentLevels,
+		SampleEvery:     cfg.Log.SampleEvery,
+	}, redact.NewWriter(os.Stderr, cfg.Secrets()...), c.runID)
+	if err != nil {
+		return cfg, fmt.Errorf("Invalid log configuration. Error: %w", err)
+	}
+
+	errs := make([]error, 0, len(validations))
+	for _, validation := range validations {
+		errs = append(errs, validation(cfg))
+	}
+
+	if err = errors.Join(errs...); err != nil {
+		return cfg, err
+	}
+
+	c.shutdownTracing, err = tracing.Setup(tracing.Config{
+		Exporter: cfg.Tracing.Exporter,
+		File:     cfg.Tracing.File,
+		Endpoint: cfg.Tracing.Endpoint,
+	})
+	if err != nil {
+		return cfg, fmt.Errorf("Invalid tracing configuration. Error: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Flushes the spans of the command.
+func (c *configFlags) close() {
+	if c.shutdownTracing == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := c.shutdownTracing(ctx); err != nil {
+		logger.Error("Error flushing traces", "error", err)
+	}
+}
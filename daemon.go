@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/cassiuspaim/portimporter/domain/services"
+	"github.com/cassiuspaim/portimporter/infrastructure/config"
+	"github.com/cassiuspaim/portimporter/infrastructure/health"
+	"github.com/cassiuspaim/portimporter/infrastructure/schedule"
+)
+
+// Runs the daemon command, which imports the JSON file at each time of the schedule until a
+// signal is received.
+func runDaemon(args []string) int {
+	flags := newFlagSet("daemon")
+	configFlags := registerConfigFlags(flags)
+	configFlags.registerDatabase(flags)
+	configFlags.registerImport(flags)
+	configFlags.registerUpsert(flags)
+	configFlags.registerLock(flags)
+	configFlags.registerDaemon(flags)
+	configFlags.registerServer(flags)
+
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	cfg, err := configFlags.load(config.Config.ValidateDatabase, config.Config.ValidateImport,
+		config.Config.ValidateDaemon, config.Config.ValidateLock)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+	defer configFlags.close()
+
+	mergePolicies, err := services.ParseMergePolicies(cfg.Import.Merge)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+
+	runSchedule := schedule.Every(cfg.Daemon.Interval)
+	if cfg.Daemon.Schedule != "" {
+		if runSchedule, err = schedule.Cron(cfg.Daemon.Schedule); err != nil {
+			logger.Error("Invalid configuration", "error", err)
+
+			return exitUsage
+		}
+	}
+
+	// Handle the signals to handle graceful shutdown.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := connectToDatabase(cfg.Database)
+	if err != nil {
+		logger.Error("Error connecting to database", "error", err)
+
+		return exitConnectionFailure
+	}
+	defer db.close()
+
+	if err = db.migrate(); err != nil {
+		logger.Error("Error migrating database", "error", err)
+
+		return exitFailure
+	}
+
+	checks := health.New()
+	db.addHealthChecks(checks)
+	serveOperations(ctx, cfg.Server.Address, checks)
+
+	portService := newPortService(db.portRepository, cfg.Import, mergePolicies)
+	recurring := newRecurringImport(portService, newImportLock(db, cfg.Lock), cfg.Import.File,
+		newImportOptions(cfg.Import, configFlags.runID), nil)
+	schedule.Run(ctx, runSchedule, recurring.run)
+
+	checks.ShutDown()
+	logger.Info("Daemon stopped")
+
+	return exitSuccess
+}
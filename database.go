@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/infrastructure/config"
+	"github.com/cassiuspaim/portimporter/infrastructure/health"
+	"github.com/cassiuspaim/portimporter/infrastructure/lease"
+	"github.com/cassiuspaim/portimporter/infrastructure/metrics"
+	"github.com/cassiuspaim/portimporter/infrastructure/redact"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/memory"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/mongodb"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/postgres"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/sqlite"
+	"github.com/cassiuspaim/portimporter/infrastructure/retry"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Time given to each attempt to connect to the store.
+const connectTimeout = 5 * time.Second
+
+// database is a connected store.
+type database struct {
+	portRepository domain.PortRepository
+	// migrate applies the migrations, or creates the indexes, of the store.
+	migrate func() error
+	// ping retrieves an error when the store is not reachable.
+	ping func(ctx context.Context) error
+	// checkMigrations retrieves an error when migrate was not applied to the store.
+	checkMigrations func(ctx context.Context) error
+	// leases keeps the lease of the imports, nil when the store does not support leases.
+	leases lease.Store
+	close  func()
+}
+
+// Adds the checks of the store to the readiness.
+func (d database) addHealthChecks(checks *health.Health) {
+	checks.AddCheck("database", d.ping)
+	checks.AddCheck("migrations", d.checkMigrations)
+}
+
+// Connects to the store defined by the configuration. The calls of its PortRepository are measured by
+// the metrics whatever the store, each attempt of the retried ones apart.
+func connectToDatabase(settings config.Database) (database, error) {
+	logger.Info("Connecting DB", "driver", settings.Driver)
+
+	switch settings.Driver {
+	case config.DriverMongoDB:
+		clientDB, err := connectToMongo(settings)
+		if err != nil {
+			return database{}, err
+		}
+
+		portRepository := mongodb.NewPortRepository(clientDB, settings.Name)
+
+		return database{
+			portRepository: retry.NewPortRepository(metrics.NewPortRepository(portRepository), retryPolicy(settings.Retry),
+				mongodb.IsRetryable),
+			migrate:         portRepository.CreateIndexes,
+			ping:            func(ctx context.Context) error { return clientDB.Ping(ctx, nil) },
+			checkMigrations: portRepository.CheckIndexes,
+			leases:          mongodb.NewLeaseStore(clientDB, settings.Name),
+			close:           func() { closeMongoConnection(clientDB) },
+		}, nil
+	case config.DriverPostgres:
+		db, err := connectToPostgres(settings)
+		if err != nil {
+			return database{}, err
+		}
+
+		portRepository := retry.NewPortRepository(metrics.NewPortRepository(postgres.NewPortRepository(db)),
+			retryPolicy(settings.Retry), postgres.IsRetryable)
+
+		return database{
+			portRepository:  portRepository,
+			migrate:         func() error { return postgres.Migrate(db) },
+			ping:            db.PingContext,
+			checkMigrations: func(ctx context.Context) error { return noPendingMigrations(postgres.Pending(ctx, db)) },
+			close:           func() { closeSQLConnection(db) },
+		}, nil
+	case config.DriverSQLite:
+		db, err := connectToSQLite(settings)
+		if err != nil {
+			return database{}, err
+		}
+
+		portRepository := retry.NewPortRepository(metrics.NewPortRepository(sqlite.NewPortRepository(db)),
+			retryPolicy(settings.Retry), sqlite.IsRetryable)
+
+		return database{
+			portRepository:  portRepository,
+			migrate:         func() error { return sqlite.Migrate(db) },
+			ping:            db.PingContext,
+			checkMigrations: func(ctx context.Context) error { return noPendingMigrations(sqlite.Pending(ctx, db)) },
+			close:           func() { closeSQLConnection(db) },
+		}, nil
+	case config.DriverMemory:
+		portRepository := memory.NewPortRepository()
+
+		return database{
+			portRepository:  metrics.NewPortRepository(portRepository),
+			migrate:         func() error { return nil },
+			ping:            func(context.Context) error { return nil },
+			checkMigrations: func(context.Context) error { return nil },
+			close:           func() { logger.Info("Ports kept in memory", "ports", portRepository.Count()) },
+		}, nil
+	default:
+		return database{}, fmt.Errorf("Unknown DB_DRIVER %s", settings.Driver)
+	}
+}
+
+// Retrieves the policy of the retries of the operations of the store.
+func retryPolicy(settings config.Retry) retry.Policy {
+	return retry.Policy{
+		Attempts:       settings.Attempts,
+		InitialBackoff: settings.InitialBackoff,
+		MaxBackoff:     settings.MaxBackoff,
+		Jitter:         settings.Jitter,
+	}
+}
+
+// Retrieves the policy of the retries of the connection to the store.
+func connectPolicy(settings config.Retry) retry.Policy {
+	policy := retryPolicy(settings)
+	policy.Attempts = settings.ConnectAttempts
+
+	return policy
+}
+
+// Pings the store until it answers, so the application waits for a store that is starting.
+// Each ping is limited to connectTimeout.
+func pingWithRetries(settings config.Retry, retryable func(error) bool, ping func(context.Context) error) error {
+	return connectPolicy(settings).Do(context.Background(), "connect", retryable, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+		defer cancel()
+
+		return ping(ctx)
+	})
+}
+
+// Retrieves an error when any migration is pending.
+func noPendingMigrations(pending []string, err error) error {
+	if err != nil {
+		return err
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("Pending migrations %s", strings.Join(pending, ", "))
+	}
+
+	return nil
+}
+
+func connectToMongo(settings config.Database) (*mongo.Client, error) {
+	logger.Info("Connecting DB", "auth_name", settings.AuthName, "user_name", settings.UserName,
+		"uri", redact.URI(settings.URI))
+
+	// Set credential
+	credential := options.Credential{
+		AuthSource: settings.AuthName,
+		Username:   settings.UserName,
+		Password:   settings.Password.Value(),
+	}
+
+	// Set client options
+	clientOptions := options.Client().ApplyURI(settings.URI).SetAuth(credential)
+
+	// Connect to MongoDB
+	client, err := mongo.Connect(context.TODO(), clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the connection
+	err = pingWithRetries(settings.Retry, mongodb.IsRetryable, func(ctx context.Context) error {
+		return client.Ping(ctx, nil)
+	})
+	if err != nil {
+		_ = client.Disconnect(context.TODO())
+
+		return nil, err
+	}
+
+	logger.Info("Connected to database")
+
+	return client, nil
+}
+
+// Connects to Postgres through the URI. The user name, password and database name of the configuration
+// are used when they are not present at the URI.
+func connectToPostgres(settings config.Database) (*sql.DB, error) {
+	dbURI, err := url.Parse(settings.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	if dbURI.User == nil && settings.UserName != "" {
+		dbURI.User = url.UserPassword(settings.UserName, settings.Password.Value())
+	}
+
+	if dbURI.Path == "" || dbURI.Path == "/" {
+		dbURI.Path = "/" + settings.Name
+	}
+
+	logger.Info("Connecting DB", "uri", redact.URI(dbURI.String()))
+
+	db, err := sql.Open("postgres", dbURI.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the connection
+	err = pingWithRetries(settings.Retry, postgres.IsRetryable, db.PingContext)
+	if err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	logger.Info("Connected to database")
+
+	return db, nil
+}
+
+// Opens the SQLite database file of the URI, creating it when it does not exist.
+func connectToSQLite(settings config.Database) (*sql.DB, error) {
+	logger.Info("Connecting DB", "file", settings.URI)
+
+	db, err := sqlite.Open(settings.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Connected to database")
+
+	return db, nil
+}
+
+func closeMongoConnection(clientDB *mongo.Client) {
+	logger.Info("Start closing database connection")
+
+	err := clientDB.Disconnect(context.TODO())
+	if err != nil {
+		logger.Error("Error closing database connection", "error", err)
+
+		return
+	}
+
+	logger.Info("Database connection closed")
+}
+
+func closeSQLConnection(db *sql.DB) {
+	logger.Info("Start closing database connection")
+
+	err := db.Close()
+	if err != nil {
+		logger.Error("Error closing database connection", "error", err)
+
+		return
+	}
+
+	logger.Info("Database connection closed")
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/domain/services"
+	"github.com/cassiuspaim/portimporter/infrastructure/config"
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+)
+
+// Runs the diff command, which compares the ports of the JSON file with the stored ones as the
+// import would merge them. Each different port is written to the standard output prefixed by +
+// when it is not stored, ~ when the import would change it and - when it is stored but not present
+// at the file. The locked fields the import would attempt to change are written under their port.
+func runDiff(args []string) int {
+	flags := newFlagSet("diff")
+	configFlags := registerConfigFlags(flags)
+	configFlags.registerDatabase(flags)
+	configFlags.registerImport(flags)
+
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	cfg, err := configFlags.load(config.Config.ValidateDatabase, config.Config.ValidateImport)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+	defer configFlags.close()
+
+	mergePolicies, err := services.ParseMergePolicies(cfg.Import.Merge)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+
+	file, err := os.Open(cfg.Import.File)
+	if err != nil {
+		logger.Error("Error opening file", "file", cfg.Import.File, "error", err)
+
+		return exitFailure
+	}
+	defer file.Close()
+
+	db, err := connectToDatabase(cfg.Database)
+	if err != nil {
+		logger.Error("Error connecting to database", "error", err)
+
+		return exitConnectionFailure
+	}
+	defer db.close()
+
+	ctx := context.Background()
+	options := newImportOptions(cfg.Import, configFlags.runID)
+	fileKeys := map[string]bool{}
+	added, changed, removed, unchanged, invalid, lockedChanges := 0, 0, 0, 0, 0, 0
+
+	for entry := range readPorts(file, options.duplicates) {
+		if entry.Ignored {
+			continue
+		}
+
+		if entry.Error != nil {
+			invalid++
+
+			logger.Warn("Invalid port", logging.KeyPortKey, entry.Port.ID, logging.KeyOffset, entry.Offset, "error", entry.Error)
+
+			continue
+		}
+
+		// The ports are compared as they would be stored.
+		entry.Port = preparePort(entry.Port, options)
+
+		fileKeys[entry.Port.ID] = true
+
+		stored, err := db.portRepository.GetByID(ctx, entry.Port.ID)
+		if err != nil {
+			logger.Error("Error querying port", logging.KeyPortKey, entry.Port.ID, "error", err)
+
+			return exitFailure
+		}
+
+		if stored == nil {
+			added++
+
+			fmt.Printf("+ %s\n", entry.Port.ID)
+
+			continue
+		}
+
+		// The ports are merged as the upsert would, keeping the locked fields.
+		merged, locked := mergePolicies.Merge(*stored, entry.Port)
+
+		if stored.Equal(merged) {
+			unchanged++
+		} else {
+			changed++
+
+			fmt.Printf("~ %s\n", entry.Port.ID)
+		}
+
+		if len(locked) > 0 {
+			lockedChanges++
+		}
+
+		for _, field := range locked {
+			fmt.Printf("  locked %s %s %q\n", entry.Port.ID, field, entry.Port.Field(field))
+		}
+	}
+
+	err = db.portRepository.ForEach(ctx, func(port entities.Port) error {
+		if !fileKeys[port.ID] {
+			removed++
+
+			fmt.Printf("- %s\n", port.ID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Error("Error listing ports", "error", err)
+
+		return exitFailure
+	}
+
+	logger.Info("Diff finished", "added", added, "changed", changed, "removed", removed, "unchanged", unchanged,
+		"locked_changes", lockedChanges, "invalid", invalid)
+
+	if invalid > 0 {
+		return exitValidationFailure
+	}
+
+	return exitSuccess
+}
@@ -1,15 +1,49 @@
 package domain
 
-import "github.com/cassiuspaim/portimporter/domain/entities"
+import (
+	"context"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+)
+
+// UpsertResult tells what PortService.Upsert did with a Port.
+type UpsertResult string
+
+// Results of PortService.Upsert.
+const (
+	UpsertCreated   UpsertResult = "created"
+	UpsertUpdated   UpsertResult = "updated"
+	UpsertUnchanged UpsertResult = "unchanged"
+)
 
 // Interface to define the operations for the PortService.
+// The context carries the cancellation and the trace of the operation.
 type PortService interface {
-	Upsert(entities.Port) error
+	Upsert(context.Context, entities.Port) (UpsertResult, error)
+}
+
+// Interface to define the enrichment of the imported Ports, e.g. with codes of a dataset. Enrich
+// retrieves the Port with the values added or corrected, and the problems found at it.
+type Enricher interface {
+	Enrich(entities.Port) (entities.Port, []entities.Flag)
+}
+
+// Interface to define the normalization of the texts of the imported Ports. Normalize retrieves
+// the Port with its texts normalized, and the changes made.
+type Normalizer interface {
+	Normalize(entities.Port) (entities.Port, []entities.Change)
 }
 
 // Interface to define the operations for the PortRepository.
+// The context carries the cancellation and the trace of the operation.
 type PortRepository interface {
-	GetByID(id string) (*entities.Port, error)
-	Create(entities.Port) error
-	Update(entities.Port, string) error
+	GetByID(ctx context.Context, id string) (*entities.Port, error)
+	// Create stores the Port with version 0.
+	Create(context.Context, entities.Port) error
+	// Update replaces the Port identified by the ID when its stored version is the version of
+	// the given Port, and increments the version. Otherwise it retrieves ErrVersionConflict.
+	Update(context.Context, entities.Port, string) error
+	// ForEach calls the function for every Port ordered by ID. It stops at the first error
+	// retrieved by the function and retrieves it.
+	ForEach(context.Context, func(entities.Port) error) error
 }
@@ -0,0 +1,13 @@
+package entities
+
+// Change is a field of a Port changed by a normalization, e.g. a text with a broken encoding.
+type Change struct {
+	// Field is the name of the changed field, one of FieldNames. Lists are separated by commas.
+	Field string
+	// Before and After are the values of the field, as retrieved by Port.Field, before and after the
+	// change.
+	Before string
+	After  string
+	// Kinds tell the changes made, as named by the normalization.
+	Kinds []string
+}
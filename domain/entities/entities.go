@@ -1,5 +1,7 @@
 package entities
 
+import "fmt"
+
 // Port is the entity used by domain.
 type Port struct {
 	ID          string
@@ -13,6 +15,23 @@ type Port struct {
 	Timezone    string
 	Unlocs      []string
 	Code        string
+	// NameASCII and CityASCII are the Name and the City folded to ASCII for search, e.g.
+	// "Sao Paulo", when the import folds them. Otherwise they are empty.
+	NameASCII string
+	CityASCII string
+	// CountryCode is the ISO 3166-1 alpha-2 code of the Country and SubdivisionCode the ISO 3166-2
+	// code of the Province, when the import enriches them. Otherwise they are empty.
+	CountryCode     string
+	SubdivisionCode string
+	// Version is the revision of the stored Port, incremented by each update. It is used to detect
+	// changes made since the Port was read, and it is not compared by Equal.
+	Version int64
+	// Provenance tells where the value of each field came from, by field name. It is nil when
+	// empty and it is not compared by Equal.
+	Provenance map[string]Provenance
+	// Locked has the names of the fields that imports never change. It is nil when empty and it
+	// is not compared by Equal.
+	Locked []string
 }
 
 // Retrieves a new Port entity.
@@ -41,3 +60,71 @@ func NewPort(
 		Unlocs:      unlocs,
 		Code:        code}
 }
+
+// Equal retrieves whether both Ports have the same values, whatever their versions, provenance
+// and locked fields. Nil and empty slices are equal.
+func (p Port) Equal(other Port) bool {
+	return p.ID == other.ID &&
+		p.Name == other.Name &&
+		p.City == other.City &&
+		p.Country == other.Country &&
+		equalStrings(p.Alias, other.Alias) &&
+		equalStrings(p.Regions, other.Regions) &&
+		equalFloats(p.Coordinates, other.Coordinates) &&
+		p.Province == other.Province &&
+		p.Timezone == other.Timezone &&
+		equalStrings(p.Unlocs, other.Unlocs) &&
+		p.Code == other.Code &&
+		p.NameASCII == other.NameASCII &&
+		p.CityASCII == other.CityASCII &&
+		p.CountryCode == other.CountryCode &&
+		p.SubdivisionCode == other.SubdivisionCode
+}
+
+// Validate retrieves the problems found at the Port, or nil when the Port is valid.
+func (p Port) Validate() []string {
+	var problems []string
+
+	if p.ID == "" {
+		problems = append(problems, "ID is empty")
+	}
+
+	if p.Name == "" {
+		problems = append(problems, "name is empty")
+	}
+
+	if len(p.Coordinates) != 0 && len(p.Coordinates) != 2 {
+		problems = append(problems, fmt.Sprintf("coordinates must have longitude and latitude, found %d values",
+			len(p.Coordinates)))
+	}
+
+	return problems
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalFloats(a []float64, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -47,3 +47,71 @@ func TestPort(t *testing.T) {
 		assert.Equal(t, expectedCode, port.Code, "Codes must be equal")
 	})
 }
+
+func TestPortEqual(t *testing.T) {
+	t.Parallel()
+
+	port := NewPort("id", "name", "city", "country", []string{"alias1"}, nil,
+		[]float64{43.434343434, 35.2423434}, "province", "timezone", []string{"unloc1"}, "code")
+
+	t.Run("Given a Port with empty slices instead of nil ones When comparing Then the Ports must be equal", func(t *testing.T) {
+		t.Parallel()
+
+		other := port
+		other.Regions = []string{}
+		assert.True(t, port.Equal(other))
+	})
+
+	t.Run("Given a Port with a different alias When comparing Then the Ports must not be equal", func(t *testing.T) {
+		t.Parallel()
+
+		other := port
+		other.Alias = []string{"alias2"}
+		assert.False(t, port.Equal(other))
+	})
+
+	t.Run("Given a Port with different coordinates When comparing Then the Ports must not be equal", func(t *testing.T) {
+		t.Parallel()
+
+		other := port
+		other.Coordinates = []float64{35.2423434, 43.434343434}
+		assert.False(t, port.Equal(other))
+	})
+}
+
+func TestPortValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		port     Port
+		problems int
+	}{
+		{
+			name: "Given a complete Port When validating Then no problem is expected",
+			port: Port{ID: "id", Name: "name", Coordinates: []float64{1, 2}},
+		},
+		{
+			name: "Given a Port without coordinates When validating Then no problem is expected",
+			port: Port{ID: "id", Name: "name"},
+		},
+		{
+			name:     "Given a Port without ID and name When validating Then two problems are expected",
+			port:     Port{},
+			problems: 2,
+		},
+		{
+			name:     "Given a Port with three coordinates When validating Then one problem is expected",
+			port:     Port{ID: "id", Name: "name", Coordinates: []float64{1, 2, 3}},
+			problems: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Len(t, tt.port.Validate(), tt.problems)
+		})
+	}
+}
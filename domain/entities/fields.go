@@ -0,0 +1,173 @@
+package entities
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sources of the values that are not imported.
+const (
+	// SourceManual is the source of the values edited by hand.
+	SourceManual = "manual"
+	// SourceInferred is the source of the values inferred from other fields by the import.
+	SourceInferred = "inferred"
+)
+
+// FieldNames are the names of the fields of a Port that have a value of their own, in the order
+// of the Port. The ID is not one of them.
+var FieldNames = []string{
+	"name", "city", "country", "alias", "regions", "coordinates", "province", "timezone", "unlocs", "code",
+}
+
+// Provenance tells where the value of a field of a Port came from.
+type Provenance struct {
+	// Source is the imported file, SourceManual for the values edited by hand or SourceInferred
+	// for the values inferred from other fields.
+	Source string
+	// RunID identifies the run that wrote the value, as the run_id of its logs.
+	RunID     string
+	UpdatedAt time.Time
+}
+
+// IsField retrieves whether name is one of FieldNames.
+func IsField(name string) bool {
+	for _, fieldName := range FieldNames {
+		if fieldName == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithProvenance retrieves a copy of the Port whose fields came from the provenance.
+func (p Port) WithProvenance(provenance Provenance) Port {
+	p.Provenance = make(map[string]Provenance, len(FieldNames))
+	for _, name := range FieldNames {
+		p.Provenance[name] = provenance
+	}
+
+	return p
+}
+
+// WithInferred retrieves a copy of the Port whose field was inferred from other fields, keeping the
+// run and the time of its provenance. Ports without provenance are retrieved as they are.
+func (p Port) WithInferred(field string) Port {
+	if p.Provenance == nil {
+		return p
+	}
+
+	provenance := make(map[string]Provenance, len(p.Provenance))
+	for name, value := range p.Provenance {
+		provenance[name] = value
+	}
+
+	inferred := provenance[field]
+	inferred.Source = SourceInferred
+	provenance[field] = inferred
+	p.Provenance = provenance
+
+	return p
+}
+
+// IsLocked retrieves whether the field is locked.
+func (p Port) IsLocked(field string) bool {
+	for _, locked := range p.Locked {
+		if locked == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Field retrieves the value of the field as text, or an empty text for unknown fields. Lists are
+// separated by commas.
+func (p Port) Field(name string) string {
+	switch name {
+	case "name":
+		return p.Name
+	case "city":
+		return p.City
+	case "country":
+		return p.Country
+	case "alias":
+		return strings.Join(p.Alias, ",")
+	case "regions":
+		return strings.Join(p.Regions, ",")
+	case "coordinates":
+		values := make([]string, 0, len(p.Coordinates))
+		for _, coordinate := range p.Coordinates {
+			values = append(values, strconv.FormatFloat(coordinate, 'f', -1, 64))
+		}
+
+		return strings.Join(values, ",")
+	case "province":
+		return p.Province
+	case "timezone":
+		return p.Timezone
+	case "unlocs":
+		return strings.Join(p.Unlocs, ",")
+	case "code":
+		return p.Code
+	default:
+		return ""
+	}
+}
+
+// SetField sets the field from its value as text. Lists are separated by commas, and the
+// coordinates are the longitude and the latitude separated by a comma.
+func (p *Port) SetField(name string, value string) error {
+	switch name {
+	case "name":
+		p.Name = value
+	case "city":
+		p.City = value
+	case "country":
+		p.Country = value
+	case "alias":
+		p.Alias = splitList(value)
+	case "regions":
+		p.Regions = splitList(value)
+	case "coordinates":
+		coordinates := []float64{}
+
+		for _, text := range splitList(value) {
+			coordinate, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return fmt.Errorf("Invalid coordinate %q. Error: %w", text, err)
+			}
+
+			coordinates = append(coordinates, coordinate)
+		}
+
+		p.Coordinates = coordinates
+	case "province":
+		p.Province = value
+	case "timezone":
+		p.Timezone = value
+	case "unlocs":
+		p.Unlocs = splitList(value)
+	case "code":
+		p.Code = value
+	default:
+		return fmt.Errorf("Unknown field %q, expected one of %s", name, strings.Join(FieldNames, ", "))
+	}
+
+	return nil
+}
+
+// Retrieves the values separated by commas, without spaces around them.
+func splitList(value string) []string {
+	values := []string{}
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortFields(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given every field When setting it from its text Then the same text must be retrieved", func(t *testing.T) {
+		t.Parallel()
+
+		values := map[string]string{
+			"name":        "Abu Dhabi",
+			"city":        "Abu Dhabi",
+			"country":     "United Arab Emirates",
+			"alias":       "Abū Ẓaby,Abu Zabi",
+			"regions":     "Gulf",
+			"coordinates": "54.37,24.47",
+			"province":    "Abu Dhabi",
+			"timezone":    "Asia/Dubai",
+			"unlocs":      "AEAUH",
+			"code":        "52001",
+		}
+
+		var port Port
+
+		for _, name := range FieldNames {
+			require.NoError(t, port.SetField(name, values[name]), "Error must not be found setting %s", name)
+			assert.Equal(t, values[name], port.Field(name), "Field %s must be retrieved as set", name)
+		}
+
+		assert.Equal(t, []float64{54.37, 24.47}, port.Coordinates)
+		assert.Equal(t, []string{"Abū Ẓaby", "Abu Zabi"}, port.Alias)
+	})
+
+	t.Run("Given invalid values When setting fields Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		var port Port
+
+		assert.ErrorContains(t, port.SetField("coordinates", "54.37,north"), "Invalid coordinate")
+		assert.ErrorContains(t, port.SetField("id", "AEAUH"), "Unknown field")
+		assert.False(t, IsField("id"), "The ID must not be a field")
+	})
+
+	t.Run("Given a provenance When set to a Port Then every field must have it and Equal must ignore it", func(t *testing.T) {
+		t.Parallel()
+
+		port := Port{ID: "AEAUH", Name: "Abu Dhabi", Locked: []string{"timezone"}}
+		provenance := Provenance{Source: "ports.json", RunID: "run"}
+
+		withProvenance := port.WithProvenance(provenance)
+
+		assert.Len(t, withProvenance.Provenance, len(FieldNames))
+		assert.Equal(t, provenance, withProvenance.Provenance["timezone"])
+		assert.Nil(t, port.Provenance, "The Port must not be changed")
+		assert.True(t, withProvenance.Equal(Port{ID: "AEAUH", Name: "Abu Dhabi"}),
+			"Provenance and locked fields must not be compared")
+		assert.True(t, port.IsLocked("timezone"))
+		assert.False(t, port.IsLocked("name"))
+	})
+
+	t.Run("Given a Port with provenance When a field is inferred Then only its source must change", func(t *testing.T) {
+		t.Parallel()
+
+		provenance := Provenance{Source: "ports.json", RunID: "run"}
+		port := Port{ID: "AEAUH"}.WithProvenance(provenance)
+
+		inferred := port.WithInferred("timezone")
+
+		assert.Equal(t, Provenance{Source: SourceInferred, RunID: "run"}, inferred.Provenance["timezone"])
+		assert.Equal(t, provenance, inferred.Provenance["name"])
+		assert.Equal(t, provenance, port.Provenance["timezone"], "The Port must not be changed")
+		assert.Nil(t, Port{ID: "AEAUH"}.WithInferred("timezone").Provenance, "Ports without provenance must not get it")
+	})
+}
@@ -0,0 +1,12 @@
+package entities
+
+// Flag is a problem found at a Port by an enrichment, e.g. a country that does not match the
+// key. The Port is imported anyway.
+type Flag struct {
+	// Field is the name of the field with the problem, one of FieldNames.
+	Field string
+	// Kind tells the problem, as named by the enrichment.
+	Kind string
+	// Detail describes the problem with the values found.
+	Detail string
+}
@@ -0,0 +1,10 @@
+package domain
+
+import "errors"
+
+// ErrPortAlreadyExists is retrieved by PortRepository.Create when a Port with the same ID is already stored.
+var ErrPortAlreadyExists = errors.New("Port already exists")
+
+// ErrVersionConflict is retrieved by PortRepository.Update when the stored Port changed, or was
+// removed, since it was read.
+var ErrVersionConflict = errors.New("Port changed since it was read")
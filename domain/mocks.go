@@ -2,6 +2,7 @@ package domain
 
 // TODO move this to package only for tests.
 import (
+	"context"
 	"errors"
 
 	"github.com/cassiuspaim/portimporter/domain/entities"
@@ -12,11 +13,12 @@ type MockPortRepository struct {
 	GetByIDfn func(id string) (*entities.Port, error)
 	Createfn  func(entities.Port) error
 	Updatefn  func(entities.Port, string) error
+	ForEachfn func(func(entities.Port) error) error
 }
 
 // Does what is defined at MockPortRepository.GetByIDfn.
 // If MockPortRepository.GetByIDfn is not defined it retrieves an Error.
-func (r MockPortRepository) GetByID(id string) (*entities.Port, error) {
+func (r MockPortRepository) GetByID(_ context.Context, id string) (*entities.Port, error) {
 	if r.GetByIDfn != nil {
 		return r.GetByIDfn(id)
 	}
@@ -26,7 +28,7 @@ func (r MockPortRepository) GetByID(id string) (*entities.Port, error) {
 
 // Does what is defined at MockPortRepository.Createfn.
 // If MockPortRepository.Createfn is not defined it retrieves an Error.
-func (r MockPortRepository) Create(port entities.Port) error {
+func (r MockPortRepository) Create(_ context.Context, port entities.Port) error {
 	if r.Createfn != nil {
 		return r.Createfn(port)
 	}
@@ -36,10 +38,50 @@ func (r MockPortRepository) Create(port entities.Port) error {
 
 // Does what is defined at MockPortRepository.Updatefn.
 // If MockPortRepository.Updatefn is not defined it retrieves an Error.
-func (r MockPortRepository) Update(port entities.Port, filter string) error {
+func (r MockPortRepository) Update(_ context.Context, port entities.Port, filter string) error {
 	if r.Updatefn != nil {
 		return r.Updatefn(port, filter)
 	}
 
 	return errors.New("No behaviour defined")
 }
+
+// Does what is defined at MockPortRepository.ForEachfn.
+// If MockPortRepository.ForEachfn is not defined it retrieves an Error.
+func (r MockPortRepository) ForEach(_ context.Context, fn func(entities.Port) error) error {
+	if r.ForEachfn != nil {
+		return r.ForEachfn(fn)
+	}
+
+	return errors.New("No behaviour defined")
+}
+
+// MockEnricher used for tests.
+type MockEnricher struct {
+	Enrichfn func(entities.Port) (entities.Port, []entities.Flag)
+}
+
+// Does what is defined at MockEnricher.Enrichfn.
+// If MockEnricher.Enrichfn is not defined it retrieves the Port unchanged.
+func (e MockEnricher) Enrich(port entities.Port) (entities.Port, []entities.Flag) {
+	if e.Enrichfn != nil {
+		return e.Enrichfn(port)
+	}
+
+	return port, nil
+}
+
+// MockNormalizer used for tests.
+type MockNormalizer struct {
+	Normalizefn func(entities.Port) (entities.Port, []entities.Change)
+}
+
+// Does what is defined at MockNormalizer.Normalizefn.
+// If MockNormalizer.Normalizefn is not defined it retrieves the Port unchanged.
+func (n MockNormalizer) Normalize(port entities.Port) (entities.Port, []entities.Change) {
+	if n.Normalizefn != nil {
+		return n.Normalizefn(port)
+	}
+
+	return port, nil
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+)
+
+// ImportEntry is a Port read from a source. If the Port could not be read, Error is present
+// and Port may only have its ID. Offset is the position of the Port at the source.
+type ImportEntry struct {
+	Port   entities.Port
+	Error  error
+	Offset int64
+	// DuplicateOf is the offset of the first entry with the same key at the source, 0 when the
+	// key was not read before.
+	DuplicateOf int64
+	// Ignored entries are counted but not upserted, e.g. repeated keys when the first entry wins.
+	Ignored bool
+}
+
+// Report summarizes an import run.
+type Report struct {
+	Decoded      int
+	DecodeFailed int
+	Created      int
+	Updated      int
+	Unchanged    int
+	UpsertFailed int
+	// Conflicts counts the upserts, also counted by UpsertFailed, that failed because another
+	// writer kept changing the Port.
+	Conflicts int
+	Skipped   int
+	// Duplicates counts the entries whose key was read before at the same source. They are also
+	// counted as failed, when the policy fails them, or upserted, when the last entry wins.
+	Duplicates int
+	// Normalizations are the changes made to the texts of the imported Ports by the normalization.
+	Normalizations []Normalization
+	// Flags are the problems found at the imported Ports by the enrichers. The Ports are imported anyway.
+	Flags []Flag
+	// LockedChanges counts the Ports, also counted as updated or unchanged, whose import
+	// attempted to change locked fields. Those fields kept their stored values.
+	LockedChanges int
+	// LockedFields are the locked fields the imported Ports attempted to change, with the rejected values.
+	LockedFields []LockedField
+	// Retries counts the operations of the store retried because of transient errors, when the
+	// ImportService is decorated to count them.
+	Retries     int
+	Interrupted bool
+}
+
+// Failed retrieves how many entries were not imported because of errors.
+func (r Report) Failed() int {
+	return r.DecodeFailed + r.UpsertFailed
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("decoded=%d decode_failed=%d created=%d updated=%d unchanged=%d upsert_failed=%d "+
+		"conflicts=%d skipped=%d duplicates=%d normalized=%d flagged=%d locked_changes=%d retries=%d interrupted=%t",
+		r.Decoded, r.DecodeFailed, r.Created, r.Updated, r.Unchanged, r.UpsertFailed, r.Conflicts, r.Skipped,
+		r.Duplicates, len(r.Normalizations), len(r.Flags), r.LockedChanges, r.Retries, r.Interrupted)
+}
+
+// Details retrieves a line for each change made by the normalization, e.g.
+// `normalized BRSSZ name "SÃ£o Paulo " -> "São Paulo" (mojibake, whitespace)`, followed by a line
+// for each flag, e.g. `flagged ANGSB coordinates coordinates-swapped: Coordinates ... are outside AN`,
+// and a line for each locked field the import attempted to change, e.g. `locked AEAUH timezone "Asia/Muscat"`.
+func (r Report) Details() []string {
+	details := make([]string, 0, len(r.Normalizations)+len(r.Flags)+len(r.LockedFields))
+
+	for _, normalization := range r.Normalizations {
+		details = append(details, "normalized "+normalization.String())
+	}
+
+	for _, flag := range r.Flags {
+		details = append(details, "flagged "+flag.String())
+	}
+
+	for _, field := range r.LockedFields {
+		details = append(details, "locked "+field.String())
+	}
+
+	return details
+}
+
+// LogValue logs the Report as a group of fields, with the changes made by the normalization, the
+// flags and the locked fields the import attempted to change, if any.
+func (r Report) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.Int("decoded", r.Decoded),
+		slog.Int("decode_failed", r.DecodeFailed),
+		slog.Int("created", r.Created),
+		slog.Int("updated", r.Updated),
+		slog.Int("unchanged", r.Unchanged),
+		slog.Int("upsert_failed", r.UpsertFailed),
+		slog.Int("conflicts", r.Conflicts),
+		slog.Int("skipped", r.Skipped),
+		slog.Int("duplicates", r.Duplicates),
+		slog.Int("normalized", len(r.Normalizations)),
+		slog.Int("flagged", len(r.Flags)),
+		slog.Int("locked_changes", r.LockedChanges),
+		slog.Int("retries", r.Retries),
+		slog.Bool("interrupted", r.Interrupted),
+	}
+
+	if len(r.Normalizations) > 0 {
+		attrs = append(attrs, slog.Any("normalizations", r.Normalizations))
+	}
+
+	if len(r.Flags) > 0 {
+		attrs = append(attrs, slog.Any("flags", r.Flags))
+	}
+
+	if len(r.LockedFields) > 0 {
+		attrs = append(attrs, slog.Any("locked_fields", r.LockedFields))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// Normalization is a change made to a field of an imported Port by the normalization.
+type Normalization struct {
+	Key string
+	entities.Change
+}
+
+// String retrieves the key, the field, the text before and after the change and its kinds.
+func (n Normalization) String() string {
+	return fmt.Sprintf("%s %s %q -> %q (%s)", n.Key, n.Field, n.Before, n.After, strings.Join(n.Kinds, ", "))
+}
+
+// Flag is a problem found at an imported Port by an enricher.
+type Flag struct {
+	Key string
+	entities.Flag
+}
+
+// String retrieves the key, the field, the kind and the detail of the problem.
+func (f Flag) String() string {
+	return fmt.Sprintf("%s %s %s: %s", f.Key, f.Field, f.Kind, f.Detail)
+}
+
+// LockedField is a locked field of a stored Port that an import attempted to change.
+type LockedField struct {
+	Key   string
+	Field string
+	// Value is the imported value, which was rejected.
+	Value string
+}
+
+// String retrieves the key, the field and the rejected value.
+func (f LockedField) String() string {
+	return fmt.Sprintf("%s %s %q", f.Key, f.Field, f.Value)
+}
+
+// Importer imports the Ports received by entries, retrieving the Report of the run. It is
+// implemented by ImportService and by the decorators instrumenting it.
+type Importer interface {
+	Import(ctx context.Context, entries <-chan ImportEntry) Report
+}
+
+// ImportService upserts every Port read from a source.
+type ImportService struct {
+	portService domain.PortService
+	// normalizer is nil when the texts are not normalized.
+	normalizer domain.Normalizer
+	enrichers  []domain.Enricher
+	logger     *slog.Logger
+}
+
+// Retrieves a new ImportService.
+func NewImportService(portService domain.PortService) ImportService {
+	return ImportService{
+		portService: portService,
+		logger:      slog.Default(),
+	}
+}
+
+// Retrieves a copy of the ImportService that normalizes the texts of the Ports with the normalizer
+// before upserting them, recording the changes at the Report.
+func (s ImportService) WithNormalizer(normalizer domain.Normalizer) ImportService {
+	s.normalizer = normalizer
+
+	return s
+}
+
+// Retrieves a copy of the ImportService that enriches the Ports with every enricher, in order, after
+// normalizing them and before upserting them. The problems found are recorded at the Report.
+func (s ImportService) WithEnrichers(enrichers ...domain.Enricher) ImportService {
+	s.enrichers = append(slices.Clip(s.enrichers), enrichers...)
+
+	return s
+}
+
+// Retrieves a copy of the ImportService that writes its logs with the logger.
+func (s ImportService) WithLogger(logger *slog.Logger) ImportService {
+	s.logger = logger
+
+	return s
+}
+
+// Import upserts the Ports received by entries until the channel is closed. Ignored entries are
+// only counted. When ctx is done the remaining entries are read but skipped, so the source is
+// not blocked.
+func (s ImportService) Import(ctx context.Context, entries <-chan ImportEntry) Report {
+	var report Report
+
+	ctx, lockedChanges := withLockedChanges(ctx)
+
+	for entry := range entries {
+		if ctx.Err() != nil {
+			if !report.Interrupted {
+				s.logger.InfoContext(ctx, "Stopping Port import", "reason", ctx.Err())
+			}
+
+			report.Interrupted = true
+			report.Skipped++
+
+			continue
+		}
+
+		if entry.DuplicateOf != 0 {
+			report.Duplicates++
+		}
+
+		if entry.Ignored {
+			continue
+		}
+
+		if entry.Error != nil {
+			s.logger.WarnContext(ctx, "Error reading port", keyPortKey, entry.Port.ID, keyOffset, entry.Offset,
+				"error", entry.Error)
+
+			report.DecodeFailed++
+
+			continue
+		}
+
+		report.Decoded++
+
+		if s.normalizer != nil {
+			entry.Port, report.Normalizations = s.normalize(ctx, entry, report.Normalizations)
+		}
+
+		entry.Port, report.Flags = s.enrich(ctx, entry, report.Flags)
+
+		// The upsert in progress is not canceled by ctx, so a Port is never left half written.
+		result, err := s.portService.Upsert(context.WithoutCancel(ctx), entry.Port)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Error upserting the Port", keyPortKey, entry.Port.ID, keyOffset, entry.Offset,
+				"error", err)
+
+			report.UpsertFailed++
+
+			if errors.Is(err, domain.ErrVersionConflict) {
+				report.Conflicts++
+			}
+
+			continue
+		}
+
+		switch result {
+		case domain.UpsertCreated:
+			report.Created++
+		case domain.UpsertUpdated:
+			report.Updated++
+		case domain.UpsertUnchanged:
+			report.Unchanged++
+		}
+	}
+
+	report.LockedChanges, report.LockedFields = lockedChanges.collected()
+
+	return report
+}
+
+// Retrieves the Port of the entry normalized, appending the changes made to normalizations.
+func (s ImportService) normalize(ctx context.Context, entry ImportEntry,
+	normalizations []Normalization,
+) (entities.Port, []Normalization) {
+	port, changes := s.normalizer.Normalize(entry.Port)
+
+	for _, change := range changes {
+		s.logger.InfoContext(ctx, "Port text normalized", keyPortKey, port.ID, keyOffset, entry.Offset,
+			"field", change.Field, "before", change.Before, "after", change.After, "kinds", change.Kinds)
+
+		normalizations = append(normalizations, Normalization{Key: port.ID, Change: change})
+	}
+
+	return port, normalizations
+}
+
+// Retrieves the Port of the entry enriched by every enricher, appending the problems found to flags.
+func (s ImportService) enrich(ctx context.Context, entry ImportEntry, flags []Flag) (entities.Port, []Flag) {
+	port := entry.Port
+
+	for _, enricher := range s.enrichers {
+		var found []entities.Flag
+
+		port, found = enricher.Enrich(port)
+
+		for _, flag := range found {
+			s.logger.WarnContext(ctx, "Port flagged", keyPortKey, port.ID, keyOffset, entry.Offset,
+				"field", flag.Field, "kind", flag.Kind, "detail", flag.Detail)
+
+			flags = append(flags, Flag{Key: port.ID, Flag: flag})
+		}
+	}
+
+	return port, flags
+}
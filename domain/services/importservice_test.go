@@ -0,0 +1,241 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/normalize"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sendEntries(entries ...ImportEntry) <-chan ImportEntry {
+	channel := make(chan ImportEntry, len(entries))
+
+	for _, entry := range entries {
+		channel <- entry
+	}
+
+	close(channel)
+
+	return channel
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given valid and invalid entries When importing Then the report must count each of them", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := memory.NewPortRepository()
+		importService := NewImportService(NewPortService(portRepository))
+
+		report := importService.Import(context.Background(), sendEntries(
+			ImportEntry{Port: entities.Port{ID: "AAA"}},
+			ImportEntry{Port: entities.Port{ID: "BBB"}},
+			ImportEntry{Port: entities.Port{ID: "CCC"}, Error: errors.New("Error decoding port")},
+		))
+
+		assert.Equal(t, Report{Decoded: 2, DecodeFailed: 1, Created: 2}, report)
+		assert.Equal(t, 1, report.Failed())
+		assert.Equal(t, 2, portRepository.Count(), "Valid Ports must be stored")
+	})
+
+	t.Run("Given entries imported before When importing Then the report must count updated and unchanged Ports", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := memory.NewPortRepository()
+		importService := NewImportService(NewPortService(portRepository))
+		importService.Import(context.Background(), sendEntries(
+			ImportEntry{Port: entities.Port{ID: "AAA", Name: "Name"}},
+			ImportEntry{Port: entities.Port{ID: "BBB", Name: "Name"}},
+		))
+
+		report := importService.Import(context.Background(), sendEntries(
+			ImportEntry{Port: entities.Port{ID: "AAA", Name: "Name"}},
+			ImportEntry{Port: entities.Port{ID: "BBB", Name: "Other name"}},
+			ImportEntry{Port: entities.Port{ID: "CCC", Name: "Name"}},
+		))
+
+		assert.Equal(t, Report{Decoded: 3, Created: 1, Updated: 1, Unchanged: 1}, report)
+	})
+
+	t.Run("Given the repository fails When importing Then the report must count the failures", func(t *testing.T) {
+		t.Parallel()
+
+		mockPortRepository := domain.MockPortRepository{
+			GetByIDfn: func(id string) (*entities.Port, error) {
+				return nil, errors.New("Error querying Port")
+			},
+		}
+		importService := NewImportService(NewPortService(mockPortRepository))
+
+		report := importService.Import(context.Background(), sendEntries(ImportEntry{Port: entities.Port{ID: "AAA"}}))
+
+		assert.Equal(t, Report{Decoded: 1, UpsertFailed: 1}, report)
+	})
+
+	t.Run("Given Ports changed by another writer When importing Then the report must count the conflicts", func(t *testing.T) {
+		t.Parallel()
+
+		mockPortRepository := domain.MockPortRepository{
+			GetByIDfn: func(id string) (*entities.Port, error) {
+				return &entities.Port{ID: id}, nil
+			},
+			Updatefn: func(entities.Port, string) error {
+				return domain.ErrVersionConflict
+			},
+		}
+		importService := NewImportService(NewPortService(mockPortRepository).WithConflictRetries(0))
+
+		report := importService.Import(context.Background(), sendEntries(
+			ImportEntry{Port: entities.Port{ID: "AAA", Name: "Name"}},
+		))
+
+		assert.Equal(t, Report{Decoded: 1, UpsertFailed: 1, Conflicts: 1}, report)
+	})
+
+	t.Run("Given Ports with locked fields When importing Then the report must count the attempted changes", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := memory.NewPortRepository()
+		require.NoError(t, portRepository.Create(context.Background(),
+			entities.Port{ID: "AAA", Name: "Name", Timezone: "Asia/Dubai", Locked: []string{"timezone"}}))
+		require.NoError(t, portRepository.Create(context.Background(),
+			entities.Port{ID: "BBB", Name: "Name", Timezone: "Asia/Dubai", Locked: []string{"timezone"}}))
+		importService := NewImportService(NewPortService(portRepository))
+
+		report := importService.Import(context.Background(), sendEntries(
+			ImportEntry{Port: entities.Port{ID: "AAA", Name: "Other name", Timezone: "Europe/Lisbon"}},
+			ImportEntry{Port: entities.Port{ID: "BBB", Name: "Name", Timezone: "Asia/Dubai"}},
+		))
+
+		assert.Equal(t, Report{Decoded: 2, Updated: 1, Unchanged: 1, LockedChanges: 1,
+			LockedFields: []LockedField{{Key: "AAA", Field: "timezone", Value: "Europe/Lisbon"}}}, report)
+		assert.Equal(t, []string{`locked AAA timezone "Europe/Lisbon"`}, report.Details())
+
+		var logged bytes.Buffer
+		slog.New(slog.NewJSONHandler(&logged, nil)).Info("Import finished", "report", report)
+		assert.Contains(t, logged.String(), `"locked_fields":[{"Key":"AAA","Field":"timezone","Value":"Europe/Lisbon"}]`)
+
+		stored, err := portRepository.GetByID(context.Background(), "AAA")
+		require.NoError(t, err)
+		assert.Equal(t, "Other name", stored.Name)
+		assert.Equal(t, "Asia/Dubai", stored.Timezone, "Locked fields must not be changed")
+	})
+
+	t.Run("Given repeated keys When importing Then the report must count them and skip the ignored ones", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := memory.NewPortRepository()
+		importService := NewImportService(NewPortService(portRepository))
+
+		report := importService.Import(context.Background(), sendEntries(
+			ImportEntry{Port: entities.Port{ID: "AAA", Name: "First"}, Offset: 10},
+			ImportEntry{Port: entities.Port{ID: "AAA", Name: "Ignored"}, Offset: 20, DuplicateOf: 10, Ignored: true},
+			ImportEntry{Port: entities.Port{ID: "AAA"}, Offset: 30, DuplicateOf: 10, Error: errors.New("duplicate key")},
+		))
+
+		assert.Equal(t, Report{Decoded: 1, DecodeFailed: 1, Created: 1, Duplicates: 2}, report)
+
+		stored, err := portRepository.GetByID(context.Background(), "AAA")
+		require.NoError(t, err)
+		assert.Equal(t, "First", stored.Name, "Ignored entries must not be upserted")
+	})
+
+	t.Run("Given texts to normalize When importing Then the normalized Ports must be stored and the changes reported", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := memory.NewPortRepository()
+		importService := NewImportService(NewPortService(portRepository)).
+			WithNormalizer(normalize.NewNormalizer(normalize.Options{FoldASCII: true}))
+
+		report := importService.Import(context.Background(), sendEntries(
+			ImportEntry{Port: entities.Port{ID: "BRSSZ", Name: "SÃ£o Paulo ", City: "Santos"}},
+			ImportEntry{Port: entities.Port{ID: "AEAUH", Name: "Abu Dhabi", City: "Abu Dhabi"}},
+		))
+
+		assert.Equal(t, []Normalization{{Key: "BRSSZ", Change: entities.Change{
+			Field: "name", Before: "SÃ£o Paulo ", After: "São Paulo",
+			Kinds: []string{normalize.KindMojibake, normalize.KindWhitespace},
+		}}}, report.Normalizations)
+		assert.Contains(t, report.String(), "normalized=1")
+		assert.Equal(t, []string{`normalized BRSSZ name "SÃ£o Paulo " -> "São Paulo" (mojibake, whitespace)`}, report.Details())
+
+		var logged bytes.Buffer
+		slog.New(slog.NewJSONHandler(&logged, nil)).Info("Import finished", "report", report)
+		assert.Contains(t, logged.String(), `"normalizations":[{"Key":"BRSSZ","Field":"name","Before":"SÃ£o Paulo ",`+
+			`"After":"São Paulo","Kinds":["mojibake","whitespace"]}]`)
+
+		stored, err := portRepository.GetByID(context.Background(), "BRSSZ")
+		require.NoError(t, err)
+		assert.Equal(t, "São Paulo", stored.Name)
+		assert.Equal(t, "Sao Paulo", stored.NameASCII)
+		assert.Equal(t, "Santos", stored.CityASCII)
+	})
+
+	t.Run("Given enrichers When importing Then the enriched Ports must be stored and the flags reported", func(t *testing.T) {
+		t.Parallel()
+
+		flag := entities.Flag{Field: "country", Kind: "country-mismatch", Detail: "Country does not match the key"}
+		countries := domain.MockEnricher{Enrichfn: func(port entities.Port) (entities.Port, []entities.Flag) {
+			port.CountryCode = port.ID[:2]
+			if port.Country != "Brazil" {
+				return port, []entities.Flag{flag}
+			}
+
+			return port, nil
+		}}
+		subdivisions := domain.MockEnricher{Enrichfn: func(port entities.Port) (entities.Port, []entities.Flag) {
+			port.SubdivisionCode = port.CountryCode + "-SP"
+
+			return port, nil
+		}}
+
+		portRepository := memory.NewPortRepository()
+		importService := NewImportService(NewPortService(portRepository)).WithEnrichers(countries, subdivisions)
+
+		report := importService.Import(context.Background(), sendEntries(
+			ImportEntry{Port: entities.Port{ID: "BRSSZ", Country: "Brazil"}},
+			ImportEntry{Port: entities.Port{ID: "BRRIG", Country: "Argentina"}},
+		))
+
+		assert.Equal(t, []Flag{{Key: "BRRIG", Flag: flag}}, report.Flags)
+		assert.Contains(t, report.String(), "flagged=1")
+		assert.Equal(t, []string{"flagged BRRIG country country-mismatch: Country does not match the key"}, report.Details())
+
+		var logged bytes.Buffer
+		slog.New(slog.NewJSONHandler(&logged, nil)).Info("Import finished", "report", report)
+		assert.Contains(t, logged.String(), `"flags":[{"Key":"BRRIG","Field":"country","Kind":"country-mismatch",`+
+			`"Detail":"Country does not match the key"}]`)
+		assert.Equal(t, 2, report.Created, "Flagged Ports must be imported")
+
+		stored, err := portRepository.GetByID(context.Background(), "BRSSZ")
+		require.NoError(t, err)
+		assert.Equal(t, "BR", stored.CountryCode)
+		assert.Equal(t, "BR-SP", stored.SubdivisionCode, "Enrichers must run in order")
+	})
+
+	t.Run("Given a done context When importing Then every entry must be skipped", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := memory.NewPortRepository()
+		importService := NewImportService(NewPortService(portRepository))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		report := importService.Import(ctx, sendEntries(
+			ImportEntry{Port: entities.Port{ID: "AAA"}},
+			ImportEntry{Port: entities.Port{ID: "BBB"}},
+		))
+
+		assert.Equal(t, Report{Skipped: 2, Interrupted: true}, report)
+		assert.Equal(t, 0, portRepository.Count(), "No Port must be stored")
+	})
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+)
+
+// MergePolicy tells how the upsert of a stored Port combines a field of the stored Port with
+// the one of the imported Port.
+type MergePolicy string
+
+// Policies of the fields.
+const (
+	// MergeOverwrite replaces the stored value by the imported one. It is the policy of the fields
+	// without policy.
+	MergeOverwrite MergePolicy = "overwrite"
+	// MergeKeepExisting keeps the stored value, the imported one is only used to create the Port.
+	MergeKeepExisting MergePolicy = "keep-existing"
+	// MergeUnion keeps the stored values and adds the imported values not stored yet. It is only
+	// allowed for lists.
+	MergeUnion MergePolicy = "union"
+	// MergeOnlyIfEmpty replaces the stored value by the imported one only when the stored one is empty.
+	MergeOnlyIfEmpty MergePolicy = "only-if-empty"
+)
+
+// MergePolicies are the policies by field name, e.g. "alias". The fields without policy are
+// overwritten.
+type MergePolicies map[string]MergePolicy
+
+// mergeField merges a field of the imported Port into the stored one following a policy.
+type mergeField struct {
+	merge func(policy MergePolicy, merged *entities.Port, imported entities.Port)
+	// list is true for the fields allowing MergeUnion.
+	list bool
+}
+
+// Fields of a Port, by name, that have a policy. The ID is never merged.
+var mergeFields = map[string]mergeField{
+	"name":        textField(func(p *entities.Port) *string { return &p.Name }),
+	"city":        textField(func(p *entities.Port) *string { return &p.City }),
+	"country":     textField(func(p *entities.Port) *string { return &p.Country }),
+	"alias":       listField(func(p *entities.Port) *[]string { return &p.Alias }),
+	"regions":     listField(func(p *entities.Port) *[]string { return &p.Regions }),
+	"coordinates": coordinatesField(),
+	"province":    textField(func(p *entities.Port) *string { return &p.Province }),
+	"timezone":    textField(func(p *entities.Port) *string { return &p.Timezone }),
+	"unlocs":      listField(func(p *entities.Port) *[]string { return &p.Unlocs }),
+	"code":        textField(func(p *entities.Port) *string { return &p.Code }),
+}
+
+func textField(field func(*entities.Port) *string) mergeField {
+	return mergeField{
+		merge: func(policy MergePolicy, merged *entities.Port, imported entities.Port) {
+			if keepStored(policy, *field(merged) == "") {
+				return
+			}
+
+			*field(merged) = *field(&imported)
+		},
+	}
+}
+
+func listField(field func(*entities.Port) *[]string) mergeField {
+	return mergeField{
+		merge: func(policy MergePolicy, merged *entities.Port, imported entities.Port) {
+			if policy == MergeUnion {
+				*field(merged) = union(*field(merged), *field(&imported))
+
+				return
+			}
+
+			if keepStored(policy, len(*field(merged)) == 0) {
+				return
+			}
+
+			*field(merged) = *field(&imported)
+		},
+		list: true,
+	}
+}
+
+// Coordinates are a pair of values, not a list, so they are not allowed to be united.
+func coordinatesField() mergeField {
+	return mergeField{
+		merge: func(policy MergePolicy, merged *entities.Port, imported entities.Port) {
+			if keepStored(policy, len(merged.Coordinates) == 0) {
+				return
+			}
+
+			merged.Coordinates = imported.Coordinates
+		},
+	}
+}
+
+// Retrieves whether the policy keeps the stored value of a field.
+func keepStored(policy MergePolicy, storedEmpty bool) bool {
+	switch policy {
+	case MergeKeepExisting:
+		return true
+	case MergeOnlyIfEmpty:
+		return !storedEmpty
+	default:
+		return false
+	}
+}
+
+// Retrieves the stored values followed by the imported values not stored.
+func union(stored []string, imported []string) []string {
+	values := append([]string{}, stored...)
+
+	for _, value := range imported {
+		if !contains(values, value) {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func contains(values []string, value string) bool {
+	for _, existing := range values {
+		if existing == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseMergePolicies retrieves the MergePolicies of settings like "alias=union", retrieving an
+// error for unknown fields and policies and for unions of fields that are not lists.
+func ParseMergePolicies(settings []string) (MergePolicies, error) {
+	policies := MergePolicies{}
+
+	for _, setting := range settings {
+		name, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			return nil, fmt.Errorf("Invalid merge policy %q, expected field=policy", setting)
+		}
+
+		name = strings.ToLower(strings.TrimSpace(name))
+		policy := MergePolicy(strings.TrimSpace(value))
+
+		field, ok := mergeFields[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown field %q at merge policy %q, expected one of %s", name, setting,
+				strings.Join(entities.FieldNames, ", "))
+		}
+
+		switch policy {
+		case MergeOverwrite, MergeKeepExisting, MergeOnlyIfEmpty:
+		case MergeUnion:
+			if !field.list {
+				return nil, fmt.Errorf("Invalid merge policy %q, %s is only allowed for alias, regions and unlocs",
+					setting, MergeUnion)
+			}
+		default:
+			return nil, fmt.Errorf("Unknown policy %q at merge policy %q, expected %s, %s, %s or %s", policy, setting,
+				MergeOverwrite, MergeKeepExisting, MergeUnion, MergeOnlyIfEmpty)
+		}
+
+		policies[name] = policy
+	}
+
+	return policies, nil
+}
+
+// Merge retrieves the Port to store when the imported Port is upserted over the stored one,
+// applying the policy of each field. The version and the locked fields are the stored ones.
+// The changed fields take the provenance of the imported Port. The locked fields keep their
+// stored values, and the ones the import would change are retrieved in locked.
+func (m MergePolicies) Merge(stored entities.Port, imported entities.Port) (merged entities.Port, locked []string) {
+	merged = stored
+	copied := false
+
+	for _, name := range entities.FieldNames {
+		policy, ok := m[name]
+		if !ok {
+			policy = MergeOverwrite
+		}
+
+		next := merged
+		mergeFields[name].merge(policy, &next, imported)
+
+		if next.Field(name) == merged.Field(name) {
+			continue
+		}
+
+		if stored.IsLocked(name) {
+			locked = append(locked, name)
+
+			continue
+		}
+
+		merged = next
+
+		// The map of the stored Port is shared, so it is copied before its first change.
+		if !copied {
+			merged.Provenance = copyProvenance(stored.Provenance)
+			copied = true
+		}
+
+		if provenance, ok := imported.Provenance[name]; ok {
+			merged.Provenance[name] = provenance
+		} else {
+			delete(merged.Provenance, name)
+		}
+	}
+
+	if copied && len(merged.Provenance) == 0 {
+		merged.Provenance = nil
+	}
+
+	// The folded variants and the codes follow their fields, they are the imported ones when the
+	// field has the imported value.
+	if merged.Name == imported.Name {
+		merged.NameASCII = imported.NameASCII
+	}
+
+	if merged.City == imported.City {
+		merged.CityASCII = imported.CityASCII
+	}
+
+	if merged.Country == imported.Country {
+		merged.CountryCode = imported.CountryCode
+	}
+
+	if merged.Province == imported.Province {
+		merged.SubdivisionCode = imported.SubdivisionCode
+	}
+
+	return merged, locked
+}
+
+func copyProvenance(provenance map[string]entities.Provenance) map[string]entities.Provenance {
+	copied := make(map[string]entities.Provenance, len(entities.FieldNames))
+	for name, value := range provenance {
+		copied[name] = value
+	}
+
+	return copied
+}
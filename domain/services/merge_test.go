@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	stored := entities.Port{
+		ID:          "AEAJM",
+		Name:        "Ajman",
+		City:        "Ajman",
+		Alias:       []string{"curated"},
+		Coordinates: []float64{55.5136433, 25.4052165},
+		Timezone:    "",
+		Version:     7,
+	}
+	imported := entities.Port{
+		ID:          "AEAJM",
+		Name:        "Ajman Port",
+		City:        "",
+		Alias:       []string{"imported", "curated"},
+		Coordinates: []float64{55.5, 25.4},
+		Timezone:    "Asia/Dubai",
+	}
+
+	t.Run("Given no policies When merging Then every field must be overwritten", func(t *testing.T) {
+		t.Parallel()
+
+		merged, _ := MergePolicies{}.Merge(stored, imported)
+
+		expected := imported
+		expected.Version = stored.Version
+		assert.Equal(t, expected, merged)
+	})
+
+	t.Run("Given keep-existing When merging Then the stored values must be kept", func(t *testing.T) {
+		t.Parallel()
+
+		merged, _ := MergePolicies{"name": MergeKeepExisting, "timezone": MergeKeepExisting}.Merge(stored, imported)
+
+		assert.Equal(t, "Ajman", merged.Name)
+		assert.Empty(t, merged.Timezone, "Empty stored values must be kept too")
+		assert.Equal(t, imported.Alias, merged.Alias, "Fields without policy must be overwritten")
+	})
+
+	t.Run("Given union When merging Then the imported values must be added to the stored ones", func(t *testing.T) {
+		t.Parallel()
+
+		merged, _ := MergePolicies{"alias": MergeUnion}.Merge(stored, imported)
+
+		assert.Equal(t, []string{"curated", "imported"}, merged.Alias)
+		assert.Equal(t, []string{"curated"}, stored.Alias, "The stored Port must not be changed")
+	})
+
+	t.Run("Given only-if-empty When merging Then only the empty stored values must be replaced", func(t *testing.T) {
+		t.Parallel()
+
+		merged, _ := MergePolicies{
+			"name":        MergeOnlyIfEmpty,
+			"timezone":    MergeOnlyIfEmpty,
+			"coordinates": MergeOnlyIfEmpty,
+		}.Merge(stored, imported)
+
+		assert.Equal(t, "Ajman", merged.Name)
+		assert.Equal(t, "Asia/Dubai", merged.Timezone)
+		assert.Equal(t, stored.Coordinates, merged.Coordinates)
+	})
+
+	t.Run("Given locked fields When merging Then they must keep the stored values and be retrieved", func(t *testing.T) {
+		t.Parallel()
+
+		locked := stored
+		locked.Locked = []string{"timezone", "name", "country"}
+
+		merged, changes := MergePolicies{}.Merge(locked, imported)
+
+		assert.Equal(t, "Ajman", merged.Name)
+		assert.Empty(t, merged.Timezone)
+		assert.Equal(t, imported.Alias, merged.Alias, "Fields not locked must be merged")
+		assert.Equal(t, []string{"name", "timezone"}, changes, "Locked fields left as stored must not be retrieved")
+		assert.Equal(t, locked.Locked, merged.Locked)
+	})
+
+	t.Run("Given provenance When merging Then the changed fields must take the imported provenance", func(t *testing.T) {
+		t.Parallel()
+
+		manual := entities.Provenance{Source: entities.SourceManual}
+		file := entities.Provenance{Source: "ports.json", RunID: "run"}
+
+		withProvenance := stored.WithProvenance(manual)
+
+		merged, _ := MergePolicies{"name": MergeKeepExisting}.Merge(withProvenance, imported.WithProvenance(file))
+
+		assert.Equal(t, manual, merged.Provenance["name"], "Kept fields must keep their provenance")
+		assert.Equal(t, manual, merged.Provenance["country"], "Unchanged fields must keep their provenance")
+		assert.Equal(t, file, merged.Provenance["timezone"])
+		assert.Equal(t, manual, withProvenance.Provenance["timezone"], "The stored Port must not be changed")
+
+		merged, _ = MergePolicies{}.Merge(withProvenance, imported)
+
+		assert.NotContains(t, merged.Provenance, "timezone", "Values without provenance must not keep the stored one")
+	})
+
+	t.Run("Given folded names When merging Then they must follow the merged name and city", func(t *testing.T) {
+		t.Parallel()
+
+		folded := imported
+		folded.NameASCII = "Ajman Port"
+
+		storedFolded := stored
+		storedFolded.CityASCII = "Ajman"
+
+		merged, _ := MergePolicies{"city": MergeOnlyIfEmpty}.Merge(storedFolded, folded)
+
+		assert.Equal(t, "Ajman Port", merged.NameASCII)
+		assert.Equal(t, "Ajman", merged.CityASCII, "Kept cities must keep their folded variant")
+
+		merged, _ = MergePolicies{"name": MergeKeepExisting}.Merge(stored, folded)
+
+		assert.Empty(t, merged.NameASCII, "Kept names must keep their folded variant")
+	})
+
+	t.Run("Given ISO 3166 codes When merging Then they must follow the merged country and province", func(t *testing.T) {
+		t.Parallel()
+
+		withCodes := imported
+		withCodes.Country = "United Arab Emirates"
+		withCodes.CountryCode = "AE"
+		withCodes.Province = "Ajman"
+		withCodes.SubdivisionCode = "AE-AJ"
+
+		merged, _ := MergePolicies{"province": MergeKeepExisting}.Merge(stored, withCodes)
+
+		assert.Equal(t, "AE", merged.CountryCode)
+		assert.Empty(t, merged.SubdivisionCode, "Kept provinces must keep their code")
+	})
+}
+
+func TestParseMergePolicies(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given valid settings When parsing Then the policies must be retrieved", func(t *testing.T) {
+		t.Parallel()
+
+		policies, err := ParseMergePolicies([]string{"alias=union", " Timezone = keep-existing", "city=only-if-empty"})
+
+		require.NoError(t, err)
+		assert.Equal(t, MergePolicies{"alias": MergeUnion, "timezone": MergeKeepExisting, "city": MergeOnlyIfEmpty},
+			policies)
+	})
+
+	tests := []struct {
+		name     string
+		setting  string
+		expected string
+	}{
+		{"Given a setting without policy", "alias", "expected field=policy"},
+		{"Given an unknown field", "id=keep-existing", "Unknown field \"id\""},
+		{"Given an unknown policy", "alias=append", "Unknown policy \"append\""},
+		{"Given the union of a field that is not a list", "name=union", "only allowed for alias, regions and unlocs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" When parsing Then an error is expected", func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseMergePolicies([]string{tt.setting})
+
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestUpsertPortWithMergePolicies(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given curated aliases When upserting the Port Then they must be kept", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := memory.NewPortRepository()
+		require.NoError(t, portRepository.Create(context.Background(),
+			entities.Port{ID: "AEAJM", Name: "Ajman", Alias: []string{"curated"}}))
+
+		portService := NewPortService(portRepository).WithMergePolicies(MergePolicies{"alias": MergeUnion})
+
+		result, err := portService.Upsert(context.Background(), entities.Port{ID: "AEAJM", Name: "Ajman", Alias: []string{"imported"}})
+		require.NoError(t, err)
+		assert.Equal(t, domain.UpsertUpdated, result)
+
+		stored, err := portRepository.GetByID(context.Background(), "AEAJM")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"curated", "imported"}, stored.Alias)
+
+		result, err = portService.Upsert(context.Background(), entities.Port{ID: "AEAJM", Name: "Ajman", Alias: []string{"imported"}})
+		require.NoError(t, err)
+		assert.Equal(t, domain.UpsertUnchanged, result, "A Port that the merge does not change must not be updated")
+	})
+}
@@ -1,46 +1,176 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync"
 
 	"github.com/cassiuspaim/portimporter/domain"
 	"github.com/cassiuspaim/portimporter/domain/entities"
 )
 
+// Keys of the logs about a Port, the same of the logs of the other components.
+const (
+	keyPortKey = "port_key"
+	keyOffset  = "offset"
+)
+
+// Times a Port is upserted again, by default, when another writer changed it during the upsert.
+const defaultConflictRetries = 3
+
 // PortService is a service that handle the business rules with Port entity.
 type PortService struct {
-	portRepository domain.PortRepository
+	portRepository  domain.PortRepository
+	conflictRetries int
+	mergePolicies   MergePolicies
+	logger          *slog.Logger
 }
 
 // Retrieves a new PortService
 func NewPortService(portRepository domain.PortRepository) PortService {
 	return PortService{
-		portRepository: portRepository,
+		portRepository:  portRepository,
+		conflictRetries: defaultConflictRetries,
+		logger:          slog.Default(),
 	}
 }
 
-// Upsert a Port based on its ID
-func (s PortService) Upsert(portEntity entities.Port) error {
-	portDB, err := s.portRepository.GetByID(portEntity.ID)
+// Retrieves a copy of the PortService that upserts a Port again up to retries times when another
+// writer changes it during the upsert. With 0 retries the conflicts are retrieved as errors.
+func (s PortService) WithConflictRetries(retries int) PortService {
+	s.conflictRetries = retries
+
+	return s
+}
+
+// Retrieves a copy of the PortService that merges the fields of the stored Ports with the
+// policies. The fields without policy are overwritten.
+func (s PortService) WithMergePolicies(policies MergePolicies) PortService {
+	s.mergePolicies = policies
+
+	return s
+}
+
+// Retrieves a copy of the PortService that writes its logs with the logger.
+func (s PortService) WithLogger(logger *slog.Logger) PortService {
+	s.logger = logger
+
+	return s
+}
+
+// Upsert a Port based on its ID. The stored Port is merged with the given one following the
+// merge policies, and it is not updated when the merge does not change it.
+// When another writer creates or changes the Port between reading and writing it, the Port is
+// read and upserted again, up to the conflict retries, so changes are never silently overwritten.
+func (s PortService) Upsert(ctx context.Context, portEntity entities.Port) (domain.UpsertResult, error) {
+	result, locked, err := s.upsertWithConflictRetries(ctx, portEntity)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	if portDB != nil {
-		err = s.portRepository.Update(portEntity, portEntity.ID)
-		if err != nil {
-			return fmt.Errorf("Error updating port %s. Error: %v", portEntity.ID, err)
+	if len(locked) > 0 {
+		s.logger.WarnContext(ctx, "Import attempted to change locked fields", keyPortKey, portEntity.ID, "fields", locked)
+
+		if changes, ok := ctx.Value(lockedChangesKey{}).(*lockedChanges); ok {
+			changes.add(portEntity, locked)
 		}
+	}
+
+	return result, nil
+}
 
-		log.Printf("Port updated %s.", portEntity.ID)
-	} else {
-		err = s.portRepository.Create(portEntity)
+func (s PortService) upsertWithConflictRetries(ctx context.Context,
+	portEntity entities.Port) (domain.UpsertResult, []string, error) {
+	for retry := 0; ; retry++ {
+		result, locked, err := s.upsert(ctx, portEntity)
+		if retry == s.conflictRetries || !isConflict(err) {
+			return result, locked, err
+		}
+
+		s.logger.WarnContext(ctx, "Port changed by another writer, upserting it again", keyPortKey, portEntity.ID,
+			"error", err)
+	}
+}
+
+// Retrieves whether err is raised because another writer created or changed the Port.
+func isConflict(err error) bool {
+	return errors.Is(err, domain.ErrVersionConflict) || errors.Is(err, domain.ErrPortAlreadyExists)
+}
+
+// Retrieves the result of the upsert and the locked fields the Port attempted to change.
+func (s PortService) upsert(ctx context.Context, portEntity entities.Port) (domain.UpsertResult, []string, error) {
+	portDB, err := s.portRepository.GetByID(ctx, portEntity.ID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if portDB == nil {
+		err = s.portRepository.Create(ctx, portEntity)
 		if err != nil {
-			return fmt.Errorf("Error creating port %s. Error: %v", portEntity.ID, err)
+			return "", nil, fmt.Errorf("Error creating port %s. Error: %w", portEntity.ID, err)
 		}
-		log.Printf("Port created %s.", portEntity.ID)
+
+		s.logger.DebugContext(ctx, "Port created", keyPortKey, portEntity.ID)
+
+		return domain.UpsertCreated, nil, nil
+	}
+
+	// The merged Port has the version read, the one expected by the update, which fails when the
+	// Port changed since.
+	merged, locked := s.mergePolicies.Merge(*portDB, portEntity)
+
+	if portDB.Equal(merged) {
+		s.logger.DebugContext(ctx, "Port unchanged", keyPortKey, portEntity.ID)
+
+		return domain.UpsertUnchanged, locked, nil
 	}
 
-	return nil
+	err = s.portRepository.Update(ctx, merged, portEntity.ID)
+	if err != nil {
+		return "", nil, fmt.Errorf("Error updating port %s. Error: %w", portEntity.ID, err)
+	}
+
+	s.logger.DebugContext(ctx, "Port updated", keyPortKey, portEntity.ID)
+
+	return domain.UpsertUpdated, locked, nil
+}
+
+type lockedChangesKey struct{}
+
+// lockedChanges collects the Ports upserted with a context whose import attempted to change
+// locked fields, and the fields.
+type lockedChanges struct {
+	mutex  sync.Mutex
+	ports  int
+	fields []LockedField
+}
+
+// Adds the locked fields the Port attempted to change, with the values it had for them.
+func (c *lockedChanges) add(port entities.Port, fields []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.ports++
+
+	for _, field := range fields {
+		c.fields = append(c.fields, LockedField{Key: port.ID, Field: field, Value: port.Field(field)})
+	}
+}
+
+// Retrieves how many Ports attempted to change locked fields, and the fields.
+func (c *lockedChanges) collected() (int, []LockedField) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.ports, c.fields
+}
+
+// Retrieves a context whose upserts attempting to change locked fields are counted by the
+// returned lockedChanges.
+func withLockedChanges(ctx context.Context) (context.Context, *lockedChanges) {
+	changes := &lockedChanges{}
+
+	return context.WithValue(ctx, lockedChangesKey{}, changes), changes
 }
@@ -1,11 +1,13 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/cassiuspaim/portimporter/domain"
 	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/memory"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,7 +22,7 @@ func TestUpsertPort(t *testing.T) {
 			GetByIDfn: func(id string) (*entities.Port, error) {
 				port := entities.NewPort(
 					"id",
-					"name",
+					"old name",
 					"city",
 					"country",
 					[]string{"alias1", "alias2"},
@@ -42,7 +44,7 @@ func TestUpsertPort(t *testing.T) {
 		}
 
 		portService := NewPortService(mockPortRepository)
-		err := portService.Upsert(entities.NewPort(
+		result, err := portService.Upsert(context.Background(), entities.NewPort(
 			"id",
 			"name",
 			"city",
@@ -56,9 +58,47 @@ func TestUpsertPort(t *testing.T) {
 			"code"))
 
 		assert.NoError(t, err, "Error must not be found when upserting an existing port")
+		assert.Equal(t, domain.UpsertUpdated, result)
 		assert.True(t, updateWasCalled, "Repository's Update method must be called")
 	})
 
+	t.Run("Given an equal Port at Repository When upserting the Port Then the Port must not be updated", func(t *testing.T) {
+		t.Parallel()
+
+		port := entities.NewPort(
+			"id",
+			"name",
+			"city",
+			"country",
+			[]string{"alias1", "alias2"},
+			[]string{"region1", "region2"},
+			[]float64{43.434343434, 35.2423434},
+			"province",
+			"timezone",
+			[]string{"unloc1", "unloc2"},
+			"code")
+		updateWasCalled := false
+		mockPortRepository := domain.MockPortRepository{
+			GetByIDfn: func(id string) (*entities.Port, error) {
+				stored := port
+
+				return &stored, nil
+			},
+			Updatefn: func(p entities.Port, filter string) error {
+				updateWasCalled = true
+
+				return nil
+			},
+		}
+
+		portService := NewPortService(mockPortRepository)
+		result, err := portService.Upsert(context.Background(), port)
+
+		assert.NoError(t, err, "Error must not be found when upserting an unchanged port")
+		assert.Equal(t, domain.UpsertUnchanged, result)
+		assert.False(t, updateWasCalled, "Repository's Update method must not be called")
+	})
+
 	t.Run("Given a new Port at Repository When upserting the Port Then the Port must be created", func(t *testing.T) {
 		t.Parallel()
 
@@ -81,7 +121,7 @@ func TestUpsertPort(t *testing.T) {
 		}
 
 		portService := NewPortService(mockPortRepository)
-		err := portService.Upsert(entities.NewPort(
+		result, err := portService.Upsert(context.Background(), entities.NewPort(
 			"id",
 			"name",
 			"city",
@@ -95,6 +135,7 @@ func TestUpsertPort(t *testing.T) {
 			"code"))
 
 		assert.NoError(t, err, "Error must not be found when upserting an new port")
+		assert.Equal(t, domain.UpsertCreated, result)
 		assert.True(t, createWasCalled, "Repository's Create method must be called")
 		assert.False(t, updateWasCalled, "Repository's Update method must not be called")
 	})
@@ -121,7 +162,7 @@ func TestUpsertPort(t *testing.T) {
 		}
 
 		portService := NewPortService(mockPortRepository)
-		err := portService.Upsert(entities.NewPort(
+		result, err := portService.Upsert(context.Background(), entities.NewPort(
 			"id",
 			"name",
 			"city",
@@ -135,6 +176,7 @@ func TestUpsertPort(t *testing.T) {
 			"code"))
 
 		assert.Error(t, err, "Error must be found when upserting an new port")
+		assert.Empty(t, result)
 		assert.False(t, createWasCalled, "Repository's Create method must not be called")
 		assert.False(t, updateWasCalled, "Repository's Update method must not be called")
 	})
@@ -148,7 +190,7 @@ func TestUpsertPort(t *testing.T) {
 			GetByIDfn: func(id string) (*entities.Port, error) {
 				port := entities.NewPort(
 					"id",
-					"name",
+					"old name",
 					"city",
 					"country",
 					[]string{"alias1", "alias2"},
@@ -174,7 +216,7 @@ func TestUpsertPort(t *testing.T) {
 		}
 
 		portService := NewPortService(mockPortRepository)
-		err := portService.Upsert(entities.NewPort(
+		result, err := portService.Upsert(context.Background(), entities.NewPort(
 			"id",
 			"name",
 			"city",
@@ -188,6 +230,7 @@ func TestUpsertPort(t *testing.T) {
 			"code"))
 
 		assert.Error(t, err, "Error must be found when upserting an new port")
+		assert.Empty(t, result)
 		assert.False(t, createWasCalled, "Repository's Create method must not be called")
 		assert.True(t, updateWasCalled, "Repository's Update method must be called")
 	})
@@ -214,7 +257,7 @@ func TestUpsertPort(t *testing.T) {
 		}
 
 		portService := NewPortService(mockPortRepository)
-		err := portService.Upsert(entities.NewPort(
+		result, err := portService.Upsert(context.Background(), entities.NewPort(
 			"id",
 			"name",
 			"city",
@@ -228,7 +271,145 @@ func TestUpsertPort(t *testing.T) {
 			"code"))
 
 		assert.Error(t, err, "Error must be found when upserting an new port")
+		assert.Empty(t, result)
 		assert.True(t, createWasCalled, "Repository's Create method must be called")
 		assert.False(t, updateWasCalled, "Repository's Update method must not be called")
 	})
 }
+
+func TestUpsertPortWithConflicts(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a Port changed by another writer When upserting the Port Then it must be read and updated again", func(t *testing.T) {
+		t.Parallel()
+
+		reads := 0
+		var versions []int64
+		mockPortRepository := domain.MockPortRepository{
+			GetByIDfn: func(id string) (*entities.Port, error) {
+				reads++
+
+				return &entities.Port{ID: id, Name: "old name", Version: int64(reads)}, nil
+			},
+			Updatefn: func(p entities.Port, filter string) error {
+				versions = append(versions, p.Version)
+				if len(versions) == 1 {
+					return domain.ErrVersionConflict
+				}
+
+				return nil
+			},
+		}
+
+		result, err := NewPortService(mockPortRepository).Upsert(context.Background(), entities.Port{ID: "id", Name: "name"})
+
+		assert.NoError(t, err, "Error must not be found when the conflict is retried")
+		assert.Equal(t, domain.UpsertUpdated, result)
+		assert.Equal(t, []int64{1, 2}, versions, "Each update must expect the version read before it")
+	})
+
+	t.Run("Given a Port created by another writer When creating the Port Then it must be read and updated", func(t *testing.T) {
+		t.Parallel()
+
+		var stored *entities.Port
+		mockPortRepository := domain.MockPortRepository{
+			GetByIDfn: func(id string) (*entities.Port, error) {
+				return stored, nil
+			},
+			Createfn: func(p entities.Port) error {
+				stored = &entities.Port{ID: p.ID, Name: "created by another writer"}
+
+				return domain.ErrPortAlreadyExists
+			},
+			Updatefn: func(p entities.Port, filter string) error {
+				return nil
+			},
+		}
+
+		result, err := NewPortService(mockPortRepository).Upsert(context.Background(), entities.Port{ID: "id", Name: "name"})
+
+		assert.NoError(t, err, "Error must not be found when the conflict is retried")
+		assert.Equal(t, domain.UpsertUpdated, result)
+	})
+
+	t.Run("Given a Port changed at every attempt When upserting the Port Then ErrVersionConflict is expected", func(t *testing.T) {
+		t.Parallel()
+
+		updates := 0
+		mockPortRepository := domain.MockPortRepository{
+			GetByIDfn: func(id string) (*entities.Port, error) {
+				return &entities.Port{ID: id, Name: "old name"}, nil
+			},
+			Updatefn: func(p entities.Port, filter string) error {
+				updates++
+
+				return domain.ErrVersionConflict
+			},
+		}
+
+		portService := NewPortService(mockPortRepository).WithConflictRetries(2)
+		_, err := portService.Upsert(context.Background(), entities.Port{ID: "id", Name: "name"})
+
+		assert.ErrorIs(t, err, domain.ErrVersionConflict, "The conflict must be retrieved when the retries are exhausted")
+		assert.Equal(t, 3, updates, "The update must be attempted once plus the retries")
+	})
+
+	t.Run("Given no conflict retries and a Port changed by another writer When upserting the Port Then ErrVersionConflict is expected", func(t *testing.T) {
+		t.Parallel()
+
+		updates := 0
+		mockPortRepository := domain.MockPortRepository{
+			GetByIDfn: func(id string) (*entities.Port, error) {
+				return &entities.Port{ID: id, Name: "old name"}, nil
+			},
+			Updatefn: func(p entities.Port, filter string) error {
+				updates++
+
+				return domain.ErrVersionConflict
+			},
+		}
+
+		portService := NewPortService(mockPortRepository).WithConflictRetries(0)
+		_, err := portService.Upsert(context.Background(), entities.Port{ID: "id", Name: "name"})
+
+		assert.ErrorIs(t, err, domain.ErrVersionConflict)
+		assert.Equal(t, 1, updates, "The conflict must not be retried")
+	})
+}
+
+func TestUpsertPortWithMemoryRepository(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a Port upserted twice When querying the Port Then the last version must be stored", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := memory.NewPortRepository()
+		portService := NewPortService(portRepository)
+		port := entities.NewPort(
+			"id",
+			"name",
+			"city",
+			"country",
+			[]string{"alias1", "alias2"},
+			[]string{"region1", "region2"},
+			[]float64{43.434343434, 35.2423434},
+			"province",
+			"timezone",
+			[]string{"unloc1", "unloc2"},
+			"code")
+
+		_, err := portService.Upsert(context.Background(), port)
+		assert.NoError(t, err, "Error must not be found when upserting a new port")
+
+		port.Name = "other name"
+		_, err = portService.Upsert(context.Background(), port)
+		assert.NoError(t, err, "Error must not be found when upserting an existing port")
+
+		stored, err := portRepository.GetByID(context.Background(), "id")
+		assert.NoError(t, err, "Error must not be found when querying the port")
+
+		port.Version = 1
+		assert.Equal(t, &port, stored, "The last version of the port must be stored")
+		assert.Equal(t, 1, portRepository.Count(), "Only one port must be stored")
+	})
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+)
+
+// SourceEntries are the entries read from one of the sources of an import.
+type SourceEntries struct {
+	// Name identifies the source at the errors, e.g. its file.
+	Name string
+	// Priority tells which source wins a field, the highest one.
+	Priority int
+	Entries  <-chan ImportEntry
+}
+
+// Ports read from the sources held in memory, in order of precedence, by key.
+type resolution struct {
+	ports []map[string]ImportEntry
+	// keys in the order they were first read.
+	keys []string
+}
+
+// ResolveSources reads every source and retrieves one entry per key, whose Port takes each field
+// from the source with the highest priority whose value is not empty. Sources with the same
+// priority take precedence in the order they are given. The provenance of each field is the one
+// of the winning source, so it tells which source won the field. The entries that could not be
+// read and the ignored ones are retrieved as they are.
+//
+// Only the source with the highest priority is streamed: the ports of the other sources are held
+// in memory, read before it, so the memory grows with the ports of every source but that one. Each
+// entry of the streamed source is resolved as soon as it is read, repeated keys included, as a
+// single file would be imported. The keys found only at the held sources follow, in the order they
+// were first read, and a key repeated at one of them takes the last entry not ignored, the entry it
+// replaces is retrieved as ignored so the duplicate is counted.
+func ResolveSources(sources []SourceEntries) <-chan ImportEntry {
+	entries := make(chan ImportEntry)
+
+	go func() {
+		defer close(entries)
+
+		if len(sources) == 0 {
+			return
+		}
+
+		sources = append([]SourceEntries{}, sources...)
+		sort.SliceStable(sources, func(i, j int) bool {
+			return sources[i].Priority > sources[j].Priority
+		})
+
+		var resolution resolution
+
+		seen := map[string]bool{}
+
+		for _, source := range sources[1:] {
+			ports := map[string]ImportEntry{}
+
+			for entry := range source.Entries {
+				if !passed(source, entry, entries) {
+					continue
+				}
+
+				if entry.DuplicateOf != 0 {
+					entries <- ImportEntry{Port: entities.Port{ID: entry.Port.ID}, Offset: entry.Offset,
+						DuplicateOf: entry.DuplicateOf, Ignored: true}
+					entry.DuplicateOf = 0
+				}
+
+				ports[entry.Port.ID] = entry
+
+				if !seen[entry.Port.ID] {
+					seen[entry.Port.ID] = true
+					resolution.keys = append(resolution.keys, entry.Port.ID)
+				}
+			}
+
+			resolution.ports = append(resolution.ports, ports)
+		}
+
+		streamed := map[string]bool{}
+
+		for entry := range sources[0].Entries {
+			if !passed(sources[0], entry, entries) {
+				continue
+			}
+
+			streamed[entry.Port.ID] = true
+
+			resolved := resolve(append([]ImportEntry{entry}, resolution.held(entry.Port.ID)...))
+			resolved.DuplicateOf = entry.DuplicateOf
+			entries <- resolved
+		}
+
+		for _, key := range resolution.keys {
+			if !streamed[key] {
+				entries <- resolve(resolution.held(key))
+			}
+		}
+	}()
+
+	return entries
+}
+
+// Retrieves whether the entry read from the source is to be resolved. The entries that could not
+// be read and the ignored ones are sent to entries instead.
+func passed(source SourceEntries, entry ImportEntry, entries chan<- ImportEntry) bool {
+	if entry.Error != nil {
+		entry.Error = fmt.Errorf("Error reading source %s. Error: %w", source.Name, entry.Error)
+		entries <- entry
+
+		return false
+	}
+
+	if entry.Ignored {
+		entries <- entry
+
+		return false
+	}
+
+	return true
+}
+
+// Retrieves the entries of the key held in memory, in order of precedence.
+func (r resolution) held(key string) []ImportEntry {
+	var held []ImportEntry
+
+	for _, ports := range r.ports {
+		if entry, ok := ports[key]; ok {
+			held = append(held, entry)
+		}
+	}
+
+	return held
+}
+
+// Retrieves the entry resolved from the entries of a key, in order of precedence. The offset is
+// the one of the first entry.
+func resolve(candidates []ImportEntry) ImportEntry {
+	// The first entry gives the fields that are empty at every source.
+	resolved := ImportEntry{Port: candidates[0].Port, Offset: candidates[0].Offset}
+	resolved.Port.Provenance = copyProvenance(candidates[0].Port.Provenance)
+
+	for _, name := range entities.FieldNames {
+		for _, entry := range candidates {
+			if entry.Port.Field(name) == "" {
+				continue
+			}
+
+			mergeFields[name].merge(MergeOverwrite, &resolved.Port, entry.Port)
+
+			if provenance, ok := entry.Port.Provenance[name]; ok {
+				resolved.Port.Provenance[name] = provenance
+			} else {
+				delete(resolved.Port.Provenance, name)
+			}
+
+			break
+		}
+	}
+
+	if len(resolved.Port.Provenance) == 0 {
+		resolved.Port.Provenance = nil
+	}
+
+	return resolved
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveEntries(entries <-chan ImportEntry) []ImportEntry {
+	var received []ImportEntry
+
+	for entry := range entries {
+		received = append(received, entry)
+	}
+
+	return received
+}
+
+func TestResolveSources(t *testing.T) {
+	t.Parallel()
+
+	unlocode := entities.Provenance{Source: "unlocode.json"}
+	internal := entities.Provenance{Source: "internal.json"}
+	partner := entities.Provenance{Source: "partner.json"}
+
+	t.Run("Given sources with priorities When resolving Then each field must take the value of the highest priority", func(t *testing.T) {
+		t.Parallel()
+
+		entries := receiveEntries(ResolveSources([]SourceEntries{
+			{Name: "unlocode.json", Priority: 10, Entries: sendEntries(
+				ImportEntry{Port: entities.Port{ID: "AEAUH", Name: "Abu Dhabi", Country: "United Arab Emirates",
+					Unlocs: []string{"AEAUH"}}.WithProvenance(unlocode), Offset: 3},
+			)},
+			{Name: "partner.json", Priority: 5, Entries: sendEntries(
+				ImportEntry{Port: entities.Port{ID: "AEAUH", Name: "Abu Dhabi Port", City: "Abu Dhabi",
+					Timezone: "Asia/Dubai"}.WithProvenance(partner), Offset: 7},
+				ImportEntry{Port: entities.Port{ID: "AEAJM", Name: "Ajman"}.WithProvenance(partner), Offset: 9},
+			)},
+			{Name: "internal.json", Priority: 20, Entries: sendEntries(
+				ImportEntry{Port: entities.Port{ID: "AEAUH", Timezone: "Asia/Muscat"}.WithProvenance(internal), Offset: 1},
+			)},
+		}))
+
+		require.Len(t, entries, 2)
+
+		resolved := entries[0]
+		assert.Equal(t, int64(1), resolved.Offset, "The offset must be the one of the highest priority")
+		assert.True(t, resolved.Port.Equal(entities.Port{ID: "AEAUH", Name: "Abu Dhabi", City: "Abu Dhabi",
+			Country: "United Arab Emirates", Timezone: "Asia/Muscat", Unlocs: []string{"AEAUH"}}), "Resolved %+v", resolved.Port)
+		assert.Equal(t, internal, resolved.Port.Provenance["timezone"])
+		assert.Equal(t, unlocode, resolved.Port.Provenance["name"])
+		assert.Equal(t, partner, resolved.Port.Provenance["city"], "Empty values must not win a field")
+		assert.Equal(t, internal, resolved.Port.Provenance["province"],
+			"Fields empty at every source must come from the highest priority")
+
+		assert.Equal(t, "Ajman", entries[1].Port.Name, "Keys of a single source must be kept")
+		assert.Equal(t, partner, entries[1].Port.Provenance["name"])
+	})
+
+	t.Run("Given sources with the same priority When resolving Then the first one must win", func(t *testing.T) {
+		t.Parallel()
+
+		entries := receiveEntries(ResolveSources([]SourceEntries{
+			{Name: "unlocode.json", Entries: sendEntries(ImportEntry{Port: entities.Port{ID: "AEAUH", Name: "Abu Dhabi"}})},
+			{Name: "partner.json", Entries: sendEntries(ImportEntry{Port: entities.Port{ID: "AEAUH", Name: "Abu Dhabi Port"}})},
+		}))
+
+		require.Len(t, entries, 1)
+		assert.Equal(t, "Abu Dhabi", entries[0].Port.Name)
+		assert.Nil(t, entries[0].Port.Provenance)
+	})
+
+	t.Run("Given entries that could not be read When resolving Then they must be retrieved with the source", func(t *testing.T) {
+		t.Parallel()
+
+		entries := receiveEntries(ResolveSources([]SourceEntries{
+			{Name: "partner.json", Entries: sendEntries(
+				ImportEntry{Port: entities.Port{ID: "AEAUH"}, Error: errors.New("Error decoding port"), Offset: 4},
+			)},
+		}))
+
+		require.Len(t, entries, 1)
+		assert.ErrorContains(t, entries[0].Error, "Error reading source partner.json")
+		assert.Equal(t, int64(4), entries[0].Offset)
+	})
+
+	t.Run("Given a key repeated at a held source When resolving Then the last entry must win and the duplicate be retrieved", func(t *testing.T) {
+		t.Parallel()
+
+		entries := receiveEntries(ResolveSources([]SourceEntries{
+			{Name: "internal.json", Priority: 20, Entries: sendEntries(ImportEntry{Port: entities.Port{ID: "AEAJM"}})},
+			{Name: "partner.json", Priority: 5, Entries: sendEntries(
+				ImportEntry{Port: entities.Port{ID: "AEAUH", Name: "First"}, Offset: 10},
+				ImportEntry{Port: entities.Port{ID: "AEAUH", Name: "Ignored"}, Offset: 20, DuplicateOf: 10, Ignored: true},
+				ImportEntry{Port: entities.Port{ID: "AEAUH", Name: "Last"}, Offset: 30, DuplicateOf: 10},
+			)},
+		}))
+
+		require.Len(t, entries, 4)
+		assert.True(t, entries[0].Ignored, "Ignored entries must be retrieved as they are")
+		assert.Equal(t, ImportEntry{Port: entities.Port{ID: "AEAUH"}, Offset: 30, DuplicateOf: 10, Ignored: true}, entries[1])
+		assert.Equal(t, "AEAJM", entries[2].Port.ID, "The keys of the streamed source must come first")
+		assert.Equal(t, "Last", entries[3].Port.Name)
+		assert.Zero(t, entries[3].DuplicateOf, "The resolved entry must not be counted again")
+	})
+
+	t.Run("Given the source with the highest priority When resolving Then each entry must be retrieved as it is read", func(t *testing.T) {
+		t.Parallel()
+
+		streamed := make(chan ImportEntry)
+		entries := ResolveSources([]SourceEntries{
+			{Name: "internal.json", Priority: 20, Entries: streamed},
+			{Name: "partner.json", Priority: 5, Entries: sendEntries(
+				ImportEntry{Port: entities.Port{ID: "AEAUH", City: "Abu Dhabi"}, Offset: 7},
+			)},
+		})
+
+		streamed <- ImportEntry{Port: entities.Port{ID: "AEAUH", Name: "Abu Dhabi"}, Offset: 1}
+		resolved := <-entries
+		assert.Equal(t, ImportEntry{Port: entities.Port{ID: "AEAUH", Name: "Abu Dhabi", City: "Abu Dhabi"}, Offset: 1},
+			resolved)
+
+		streamed <- ImportEntry{Port: entities.Port{ID: "AEAUH", Name: "Abu Dhabi Port"}, Offset: 2, DuplicateOf: 1}
+		resolved = <-entries
+		assert.Equal(t, "Abu Dhabi Port", resolved.Port.Name, "A repeated key must be resolved again")
+		assert.Equal(t, int64(1), resolved.DuplicateOf, "A repeated key must be counted as a duplicate")
+
+		close(streamed)
+		assert.Empty(t, receiveEntries(entries), "The keys of the streamed source must not be retrieved again")
+	})
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/infrastructure/config"
+	"github.com/cassiuspaim/portimporter/infrastructure/export"
+)
+
+// Runs the export command, which writes every stored port to a file.
+func runExport(args []string) int {
+	flags := newFlagSet("export")
+	configFlags := registerConfigFlags(flags)
+	configFlags.registerDatabase(flags)
+	format := flags.String("format", export.FormatJSON, "Format of the export: json, ndjson, csv or geojson.")
+	outputPath := flags.String("output", "-", "File where the ports are written. Use - for the standard output.")
+
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	cfg, err := configFlags.load(config.Config.ValidateDatabase)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+	defer configFlags.close()
+
+	// The format is checked before connecting and creating the output, so a wrong one does not
+	// truncate an existing file.
+	if _, err = export.NewWriter(*format, io.Discard); err != nil {
+		logger.Error("Invalid format", "format", *format, "error", err)
+
+		return exitUsage
+	}
+
+	db, err := connectToDatabase(cfg.Database)
+	if err != nil {
+		logger.Error("Error connecting to database", "error", err)
+
+		return exitConnectionFailure
+	}
+	defer db.close()
+
+	output, closeOutput := io.Writer(os.Stdout), func() error { return nil }
+
+	if *outputPath != "-" {
+		file, err := os.Create(*outputPath)
+		if err != nil {
+			logger.Error("Error creating file", "file", *outputPath, "error", err)
+
+			return exitFailure
+		}
+
+		output, closeOutput = file, file.Close
+	}
+
+	total, err := exportPorts(db.portRepository, *format, output)
+
+	// Close may be the first to fail writing the ports, so it is checked even after an error.
+	if closeErr := closeOutput(); closeErr != nil && err == nil {
+		err = fmt.Errorf("Error closing file %s. Error: %w", *outputPath, closeErr)
+	}
+
+	if err != nil {
+		logger.Error("Error exporting ports", "error", err)
+
+		return exitFailure
+	}
+
+	logger.Info("Ports exported", "ports", total)
+
+	return exitSuccess
+}
+
+// Writes every stored port to the output in the format, retrieving how many were written.
+func exportPorts(portRepository domain.PortRepository, format string, output io.Writer) (int, error) {
+	buffered := bufio.NewWriter(output)
+
+	total, err := export.Export(context.Background(), portRepository, format, buffered)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = buffered.Flush(); err != nil {
+		return 0, fmt.Errorf("Error writing ports. Error: %w", err)
+	}
+
+	return total, nil
+}
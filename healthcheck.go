@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/config"
+	"github.com/cassiuspaim/portimporter/infrastructure/health"
+)
+
+// Time given to the server to answer the health check.
+const healthcheckTimeout = 5 * time.Second
+
+// Runs the healthcheck command, which fails when the readiness of a running server fails.
+// It is meant to be the HEALTHCHECK of the container.
+func runHealthcheck(args []string) int {
+	flags := newFlagSet("healthcheck")
+	configFlags := registerConfigFlags(flags)
+	configFlags.registerServer(flags)
+	url := flags.String("url", "", "URL checked. Defaults to "+health.ReadinessPath+" at the address of the server.")
+
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	cfg, err := configFlags.load(config.Config.ValidateServer)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+	defer configFlags.close()
+
+	if *url == "" {
+		*url = readinessURL(cfg.Server.Address)
+	}
+
+	client := http.Client{Timeout: healthcheckTimeout}
+
+	response, err := client.Get(*url)
+	if err != nil {
+		logger.Error("Error checking health", "url", *url, "error", err)
+
+		return exitConnectionFailure
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+
+	if response.StatusCode != http.StatusOK {
+		logger.Error("Not ready", "url", *url, "status", response.StatusCode, "body", string(body))
+
+		return exitFailure
+	}
+
+	logger.Info("Ready", "url", *url, "body", string(body))
+
+	return exitSuccess
+}
+
+// Retrieves the URL of the readiness of the server listening to the address. Addresses of
+// every interface, like :8080, are checked at localhost.
+func readinessURL(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, "80"
+	}
+
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+
+	return "http://" + net.JoinHostPort(host, port) + health.ReadinessPath
+}
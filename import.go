@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/domain/services"
+	"github.com/cassiuspaim/portimporter/infrastructure/config"
+	"github.com/cassiuspaim/portimporter/infrastructure/coordinates"
+	"github.com/cassiuspaim/portimporter/infrastructure/iso3166"
+	"github.com/cassiuspaim/portimporter/infrastructure/jsonstream"
+	"github.com/cassiuspaim/portimporter/infrastructure/lease"
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+	"github.com/cassiuspaim/portimporter/infrastructure/metrics"
+	"github.com/cassiuspaim/portimporter/infrastructure/normalize"
+	"github.com/cassiuspaim/portimporter/infrastructure/retry"
+	"github.com/cassiuspaim/portimporter/infrastructure/timezones"
+	"github.com/cassiuspaim/portimporter/infrastructure/tracing"
+)
+
+// Runs the import command, which upserts every port of the JSON file.
+func runImport(args []string) int {
+	flags := newFlagSet("import")
+	configFlags := registerConfigFlags(flags)
+	configFlags.registerDatabase(flags)
+	configFlags.registerImport(flags)
+	configFlags.registerSources(flags)
+	configFlags.registerUpsert(flags)
+	configFlags.registerLock(flags)
+	configFlags.registerMetrics(flags)
+
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	cfg, err := configFlags.load(config.Config.ValidateDatabase, config.Config.ValidateSources,
+		config.Config.ValidateLock)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+	defer configFlags.close()
+
+	mergePolicies, err := services.ParseMergePolicies(cfg.Import.Merge)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+
+	// The sources were validated by ValidateSources.
+	sources, _ := cfg.Import.ParseSources()
+
+	// Handle the signals to handle graceful shutdown.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveOperations(ctx, cfg.Metrics.Address, nil)
+
+	db, err := connectToDatabase(cfg.Database)
+	if err != nil {
+		logger.Error("Error connecting to database", "error", err)
+
+		return exitConnectionFailure
+	}
+	defer db.close()
+
+	if err = db.migrate(); err != nil {
+		logger.Error("Error migrating database", "error", err)
+
+		return exitFailure
+	}
+
+	ctx, release, err := newImportLock(db, cfg.Lock).acquire(ctx)
+	if err != nil {
+		var held *lease.HeldError
+		if errors.As(err, &held) {
+			logger.Error("Another instance is importing", "owner", held.Owner, "expires_at", held.ExpiresAt)
+
+			return exitLockHeld
+		}
+
+		logger.Error("Error acquiring lock", "error", err)
+
+		return exitFailure
+	}
+	defer release()
+
+	portService := newPortService(db.portRepository, cfg.Import, mergePolicies)
+
+	var report services.Report
+
+	if len(sources) > 0 {
+		report, err = importSources(ctx, portService, sources, newImportOptions(cfg.Import, configFlags.runID))
+	} else {
+		report, _, err = importFile(ctx, portService, cfg.Import.File, newImportOptions(cfg.Import, configFlags.runID))
+	}
+
+	if err != nil {
+		logger.Error("Error opening file", "error", err)
+
+		return exitFailure
+	}
+
+	logger.Info("Import finished", "report", report)
+
+	if report.Failed() > 0 || report.Interrupted {
+		return exitPartialFailure
+	}
+
+	return exitSuccess
+}
+
+// Retrieves the PortService upserting the imported ports with the settings of the import, traced
+// and measured by the metrics.
+func newPortService(portRepository domain.PortRepository, settings config.Import,
+	mergePolicies services.MergePolicies,
+) domain.PortService {
+	portService := services.NewPortService(portRepository).
+		WithConflictRetries(settings.ConflictRetries).
+		WithMergePolicies(mergePolicies).
+		WithLogger(logging.For("services"))
+
+	return tracing.NewPortService(metrics.NewPortService(portService), tracing.For("services"))
+}
+
+// importOptions are the options of the imports of a command.
+type importOptions struct {
+	// runID is recorded, with the file, as the provenance of the imported values.
+	runID      string
+	duplicates jsonstream.DuplicatePolicy
+	// normalizer is nil when the texts are not normalized.
+	normalizer domain.Normalizer
+	enrichers  []domain.Enricher
+}
+
+func newImportOptions(settings config.Import, runID string) importOptions {
+	return importOptions{
+		runID:      runID,
+		duplicates: jsonstream.DuplicatePolicy(settings.Duplicates),
+		normalizer: normalizer(settings.Normalize),
+		enrichers:  enrichers(settings),
+	}
+}
+
+// Retrieves the enrichers of the imported ports enabled by the settings.
+func enrichers(settings config.Import) []domain.Enricher {
+	var enrichers []domain.Enricher
+
+	if settings.Countries == config.CountriesOn {
+		enrichers = append(enrichers, iso3166.NewEnricher())
+	}
+
+	// The coordinates are checked after the countries, as they use the codes of the countries.
+	switch settings.Coordinates {
+	case config.CoordinatesCheck:
+		enrichers = append(enrichers, coordinates.NewEnricher(coordinates.Options{}))
+	case config.CoordinatesFix:
+		enrichers = append(enrichers, coordinates.NewEnricher(coordinates.Options{Fix: true}))
+	}
+
+	// The timezones are checked after the countries and the coordinates, so they use the codes of
+	// the countries and the fixed coordinates. In check mode, the coordinates outside the country
+	// are left as they are and the timezones skip them.
+	switch settings.Timezones {
+	case config.TimezonesCheck:
+		enrichers = append(enrichers, timezones.NewEnricher(timezones.Options{}))
+	case config.TimezonesFill:
+		enrichers = append(enrichers, timezones.NewEnricher(timezones.Options{Fill: true}))
+	}
+
+	return enrichers
+}
+
+// Retrieves the normalizer of the mode, nil when the texts are not normalized.
+func normalizer(mode string) domain.Normalizer {
+	switch mode {
+	case config.NormalizeOn:
+		return normalize.NewNormalizer(normalize.Options{})
+	case config.NormalizeFold:
+		return normalize.NewNormalizer(normalize.Options{FoldASCII: true})
+	default:
+		return nil
+	}
+}
+
+// Retrieves the ImportService of the PortService, normalizing and enriching the ports as the options
+// tell, with the retries of the store counted at its reports and its imports traced and measured by
+// the metrics.
+func newImportService(portService domain.PortService, options importOptions) services.Importer {
+	importService := services.NewImportService(portService).
+		WithEnrichers(options.enrichers...).
+		WithLogger(logging.For("services"))
+	if options.normalizer != nil {
+		importService = importService.WithNormalizer(options.normalizer)
+	}
+
+	return tracing.NewImportService(metrics.NewImportService(retry.NewImportService(importService)),
+		tracing.For("services"))
+}
+
+// Retrieves the port normalized and enriched as the options tell, as it would be imported.
+func preparePort(port entities.Port, options importOptions) entities.Port {
+	if options.normalizer != nil {
+		port, _ = options.normalizer.Normalize(port)
+	}
+
+	for _, enricher := range options.enrichers {
+		port, _ = enricher.Enrich(port)
+	}
+
+	return port
+}
+
+// Imports the ports of the file through the PortService, recording the file and the run as the
+// provenance of their values. It retrieves the report of the import and the SHA-256 checksum of
+// the content read from the file.
+func importFile(ctx context.Context, portService domain.PortService, path string,
+	options importOptions,
+) (services.Report, string, error) {
+	logger.InfoContext(ctx, "Openning port file", "file", path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return services.Report{}, "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	importService := newImportService(portService, options)
+	provenance := entities.Provenance{Source: path, RunID: options.runID, UpdatedAt: time.Now().UTC()}
+	entries := readPorts(io.TeeReader(file, hash), options.duplicates)
+	report := importService.Import(ctx, withProvenance(entries, provenance))
+
+	// The decoder stops at the end of the JSON object, the rest of the file is part of the checksum.
+	if _, err = io.Copy(hash, file); err != nil {
+		return report, "", err
+	}
+
+	return report, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Imports the ports of every source through the PortService, each field taking the value of the
+// source with the highest priority, which is recorded as the provenance of the field.
+func importSources(ctx context.Context, portService domain.PortService, sources []config.Source,
+	options importOptions,
+) (services.Report, error) {
+	// Every file is opened before any is read, so no reading is left blocked when a file is missing.
+	files := make([]*os.File, 0, len(sources))
+
+	for _, source := range sources {
+		logger.Info("Openning port file", "file", source.Path, "priority", source.Priority)
+
+		file, err := os.Open(source.Path)
+		if err != nil {
+			return services.Report{}, fmt.Errorf("Error opening file %s. Error: %w", source.Path, err)
+		}
+		defer file.Close()
+
+		files = append(files, file)
+	}
+
+	start := time.Now().UTC()
+	sourceEntries := make([]services.SourceEntries, 0, len(sources))
+
+	for i, source := range sources {
+		provenance := entities.Provenance{Source: source.Path, RunID: options.runID, UpdatedAt: start}
+		sourceEntries = append(sourceEntries, services.SourceEntries{
+			Name:     source.Path,
+			Priority: source.Priority,
+			Entries:  withProvenance(readPorts(files[i], options.duplicates), provenance),
+		})
+	}
+
+	importService := newImportService(portService, options)
+
+	return importService.Import(ctx, services.ResolveSources(sourceEntries)), nil
+}
+
+// Retrieves the SHA-256 checksum of the content of the file.
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Streams the ports of the JSON content, whose repeated keys follow the policy.
+func readPorts(file io.Reader, duplicates jsonstream.DuplicatePolicy) <-chan services.ImportEntry {
+	stream := jsonstream.NewPortStream().WithDuplicates(duplicates)
+	entries := make(chan services.ImportEntry)
+
+	go stream.Start(file)
+
+	go func() {
+		defer close(entries)
+
+		for entry := range stream.Watch() {
+			entries <- services.ImportEntry{Port: toPort(entry), Error: entry.Error, Offset: entry.Offset,
+				DuplicateOf: entry.DuplicateOf, Ignored: entry.Ignored}
+		}
+	}()
+
+	return entries
+}
+
+// Retrieves the entries with the provenance set on every field of their ports.
+func withProvenance(entries <-chan services.ImportEntry, provenance entities.Provenance) <-chan services.ImportEntry {
+	withProvenance := make(chan services.ImportEntry)
+
+	go func() {
+		defer close(withProvenance)
+
+		for entry := range entries {
+			entry.Port = entry.Port.WithProvenance(provenance)
+			withProvenance <- entry
+		}
+	}()
+
+	return withProvenance
+}
+
+func toPort(entry jsonstream.Entry) entities.Port {
+	return entities.Port{
+		ID:          entry.Key,
+		Name:        entry.Data.Name,
+		Coordinates: entry.Data.Coordinates,
+		City:        entry.Data.City,
+		Province:    entry.Data.Province,
+		Country:     entry.Data.Country,
+		Alias:       entry.Data.Alias,
+		Regions:     entry.Data.Regions,
+		Unlocs:      entry.Data.Unlocs,
+		Timezone:    entry.Data.Timezone,
+		Code:        entry.Data.Code,
+	}
+}
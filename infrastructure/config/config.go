@@ -0,0 +1,511 @@
+// Package config loads the configuration of the application from the defaults, a YAML or TOML
+// file, the environment variables and the command line flags, in this order of precedence.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Drivers of the store.
+const (
+	DriverMongoDB  = "mongodb"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// Policies of the keys repeated at a file.
+const (
+	DuplicatesLastWins  = "last-wins"
+	DuplicatesFirstWins = "first-wins"
+	DuplicatesFail      = "fail"
+)
+
+// Modes of the normalization of the imported texts.
+const (
+	NormalizeOff = "off"
+	NormalizeOn  = "on"
+	// NormalizeFold also stores the name and the city folded to ASCII.
+	NormalizeFold = "fold"
+)
+
+// Modes of the enrichment of the imported Ports with the ISO 3166 codes of their country and province.
+const (
+	CountriesOff = "off"
+	CountriesOn  = "on"
+)
+
+// Modes of the check of the coordinates of the imported Ports against the bounds of their country.
+const (
+	CoordinatesOff = "off"
+	// CoordinatesCheck flags the coordinates outside the country, and the ones that were swapped or
+	// had their sign changed.
+	CoordinatesCheck = "check"
+	// CoordinatesFix also corrects the coordinates that were swapped or had their sign changed.
+	CoordinatesFix = "fix"
+)
+
+// Modes of the inference of the timezones of the imported Ports from their coordinates.
+const (
+	TimezonesOff = "off"
+	// TimezonesCheck flags the missing timezones and the ones that disagree with the coordinates.
+	TimezonesCheck = "check"
+	// TimezonesFill also sets the missing timezones to the inferred ones.
+	TimezonesFill = "fill"
+)
+
+// Modes of the lock of the imports.
+const (
+	LockFail = "fail"
+	LockWait = "wait"
+	LockOff  = "off"
+)
+
+// Environment variable with the path of the configuration file, used when no path is given.
+const FileEnv = "PORTIMPORTER_CONFIG"
+
+// Suffix of the environment variables with the path of a file that has the value of a setting,
+// e.g. DB_USER_PASSWORD_FILE for secrets mounted by Docker or Kubernetes.
+const fileEnvSuffix = "_FILE"
+
+// Config is the configuration of the application. Each setting has the key used at the
+// configuration files, the environment variable that overrides it and the flag that
+// overrides both.
+type Config struct {
+	Database Database `yaml:"database" toml:"database"`
+	Import   Import   `yaml:"import" toml:"import"`
+	Daemon   Daemon   `yaml:"daemon" toml:"daemon"`
+	Watch    Watch    `yaml:"watch" toml:"watch"`
+	Lock     Lock     `yaml:"lock" toml:"lock"`
+	Server   Server   `yaml:"server" toml:"server"`
+	Metrics  Metrics  `yaml:"metrics" toml:"metrics"`
+	Tracing  Tracing  `yaml:"tracing" toml:"tracing"`
+	Log      Log      `yaml:"log" toml:"log"`
+}
+
+// Database has the settings used to connect to the store.
+type Database struct {
+	Driver   string `yaml:"driver" toml:"driver" env:"DB_DRIVER" flag:"store"`
+	URI      string `yaml:"uri" toml:"uri" env:"DB_CONNECTION_URI" flag:"db-uri"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME" flag:"db-name"`
+	AuthName string `yaml:"auth_name" toml:"auth_name" env:"DB_AUTHENTICATION_NAME" flag:"db-auth-name"`
+	UserName string `yaml:"user_name" toml:"user_name" env:"DB_USER_NAME" flag:"db-user"`
+	Password Secret `yaml:"password" toml:"password" env:"DB_USER_PASSWORD" flag:"db-password"`
+	Retry    Retry  `yaml:"retry" toml:"retry"`
+}
+
+// Retry has the settings of the retries of the operations that fail with transient errors.
+type Retry struct {
+	// Attempts is the maximum number of attempts of each operation of the store.
+	Attempts int `yaml:"attempts" toml:"attempts" env:"DB_RETRY_ATTEMPTS" flag:"db-retry-attempts"`
+	// ConnectAttempts is the maximum number of attempts to connect, higher than Attempts so the
+	// application waits for a store that is starting.
+	ConnectAttempts int `yaml:"connect_attempts" toml:"connect_attempts" env:"DB_CONNECT_ATTEMPTS" flag:"db-connect-attempts"`
+	// InitialBackoff is the wait before the first retry, doubled at each retry.
+	InitialBackoff time.Duration `yaml:"initial_backoff" toml:"initial_backoff" env:"DB_RETRY_INITIAL_BACKOFF"`
+	// MaxBackoff caps the wait between retries.
+	MaxBackoff time.Duration `yaml:"max_backoff" toml:"max_backoff" env:"DB_RETRY_MAX_BACKOFF"`
+	// Jitter is the fraction, between 0 and 1, of each wait that is random.
+	Jitter float64 `yaml:"jitter" toml:"jitter" env:"DB_RETRY_JITTER"`
+}
+
+// Import has the settings of the import of the ports.
+type Import struct {
+	File string `yaml:"file" toml:"file" env:"PORT_JSON_PATH" flag:"file"`
+	// ConflictRetries is how many times a Port changed by another writer during its upsert is
+	// upserted again before the conflict is reported.
+	ConflictRetries int `yaml:"conflict_retries" toml:"conflict_retries" env:"IMPORT_CONFLICT_RETRIES"`
+	// Merge has the policies of the fields of the stored Ports, e.g. alias=union,timezone=keep-existing.
+	Merge []string `yaml:"merge" toml:"merge" env:"IMPORT_MERGE" flag:"merge"`
+	// Sources are files imported together in one run, replacing File, as path=priority, e.g.
+	// unlocode.json=10. The fields of each Port take the values of the sources with the highest priority.
+	Sources []string `yaml:"sources" toml:"sources" env:"IMPORT_SOURCES" flag:"sources"`
+	// Duplicates tells what is done with the keys repeated at a file: last-wins, first-wins or fail.
+	Duplicates string `yaml:"duplicates" toml:"duplicates" env:"IMPORT_DUPLICATES" flag:"duplicates"`
+	// Normalize tells how the imported texts are normalized: off, on or fold.
+	Normalize string `yaml:"normalize" toml:"normalize" env:"IMPORT_NORMALIZE" flag:"normalize"`
+	// Countries tells whether the ISO 3166 codes of the country and the province are set: off or on.
+	Countries string `yaml:"countries" toml:"countries" env:"IMPORT_COUNTRIES" flag:"countries"`
+	// Coordinates tells how the coordinates are checked against the country: off, check or fix.
+	Coordinates string `yaml:"coordinates" toml:"coordinates" env:"IMPORT_COORDINATES" flag:"coordinates"`
+	// Timezones tells how the timezones are inferred from the coordinates: off, check or fill.
+	Timezones string `yaml:"timezones" toml:"timezones" env:"IMPORT_TIMEZONES" flag:"timezones"`
+}
+
+// Source is a file imported with a priority.
+type Source struct {
+	Path     string
+	Priority int
+}
+
+// ParseSources retrieves the Sources, in the order they are defined, retrieving an error for
+// sources without path or with an invalid priority. The priority is 0 when it is not defined.
+func (i Import) ParseSources() ([]Source, error) {
+	sources := make([]Source, 0, len(i.Sources))
+
+	for _, setting := range i.Sources {
+		source := Source{Path: strings.TrimSpace(setting)}
+
+		// The priority follows the last =, so paths are allowed to have one.
+		if index := strings.LastIndex(setting, "="); index >= 0 {
+			priority, err := strconv.Atoi(strings.TrimSpace(setting[index+1:]))
+			if err != nil {
+				return nil, fmt.Errorf("Invalid priority at source %q, expected path=priority. Error: %w", setting, err)
+			}
+
+			source = Source{Path: strings.TrimSpace(setting[:index]), Priority: priority}
+		}
+
+		if source.Path == "" {
+			return nil, fmt.Errorf("Invalid source %q, expected path=priority", setting)
+		}
+
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
+
+// Daemon has the settings of the recurring imports.
+type Daemon struct {
+	// Schedule is a cron expression, e.g. "*/15 * * * *", or a descriptor like "@hourly".
+	Schedule string `yaml:"schedule" toml:"schedule" env:"DAEMON_SCHEDULE" flag:"schedule"`
+	// Interval is the time between the end of a run and the start of the next one.
+	Interval time.Duration `yaml:"interval" toml:"interval" env:"DAEMON_INTERVAL" flag:"interval"`
+}
+
+// Watch has the settings of the imports run when the file changes.
+type Watch struct {
+	// Debounce is how long the file must have no changes before it is imported.
+	Debounce time.Duration `yaml:"debounce" toml:"debounce" env:"WATCH_DEBOUNCE" flag:"debounce"`
+}
+
+// Lock has the settings of the lease acquired before each import, so only one instance imports
+// at a time.
+type Lock struct {
+	// Mode is fail, to fail when another instance holds the lease, wait, to wait for it, or off.
+	Mode string `yaml:"mode" toml:"mode" env:"LOCK_MODE" flag:"lock"`
+	// Owner identifies the instance, the host name and the process ID when empty.
+	Owner string `yaml:"owner" toml:"owner" env:"LOCK_OWNER"`
+	// TTL is how long the lease lasts without being renewed. It is renewed every third of it.
+	TTL time.Duration `yaml:"ttl" toml:"ttl" env:"LOCK_TTL"`
+}
+
+// Server has the settings of the HTTP server.
+type Server struct {
+	Address string `yaml:"address" toml:"address" env:"SERVE_ADDRESS" flag:"addr"`
+}
+
+// Metrics has the settings of the Prometheus metrics.
+type Metrics struct {
+	// Address is where the import serves GET /metrics while it runs. The serve command always
+	// serves GET /metrics at Server.Address.
+	Address string `yaml:"address" toml:"address" env:"METRICS_ADDRESS" flag:"metrics-addr"`
+}
+
+// Tracing has the settings of the OpenTelemetry traces.
+type Tracing struct {
+	// Exporter is none, stdout, file or otlp.
+	Exporter string `yaml:"exporter" toml:"exporter" env:"TRACE_EXPORTER" flag:"trace-exporter"`
+	// File is where the file exporter writes the spans.
+	File string `yaml:"file" toml:"file" env:"TRACE_FILE" flag:"trace-file"`
+	// Endpoint is the host and port of the OTLP HTTP collector, e.g. localhost:4318.
+	Endpoint string `yaml:"endpoint" toml:"endpoint" env:"TRACE_OTLP_ENDPOINT"`
+}
+
+// Log has the settings of the logs.
+type Log struct {
+	// Format is text or json.
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT" flag:"log-format"`
+	// Level is debug, info, warn or error.
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL" flag:"log-level"`
+	// ComponentLevels overrides Level by component, e.g. jsonstream=debug,mongodb=warn.
+	ComponentLevels []string `yaml:"component_levels" toml:"component_levels" env:"LOG_COMPONENT_LEVELS" flag:"log-component-levels"`
+	// SampleEvery keeps one of every SampleEvery debug logs of each port, e.g. "Key decoded".
+	SampleEvery int `yaml:"sample_every" toml:"sample_every" env:"LOG_SAMPLE_EVERY"`
+}
+
+// Default retrieves the Config used when no other source defines a setting.
+func Default() Config {
+	return Config{
+		Database: Database{
+			Driver: DriverMongoDB,
+			Retry: Retry{
+				Attempts:        5,
+				ConnectAttempts: 10,
+				InitialBackoff:  100 * time.Millisecond,
+				MaxBackoff:      5 * time.Second,
+				Jitter:          0.2,
+			},
+		},
+		Import: Import{
+			ConflictRetries: 3,
+			Duplicates:      DuplicatesLastWins,
+			Normalize:       NormalizeOn,
+			Countries:       CountriesOn,
+			Coordinates:     CoordinatesCheck,
+			Timezones:       TimezonesFill,
+		},
+		Watch: Watch{
+			Debounce: 500 * time.Millisecond,
+		},
+		Lock: Lock{
+			Mode: LockFail,
+			TTL:  30 * time.Second,
+		},
+		Server: Server{
+			Address: ":8080",
+		},
+		Tracing: Tracing{
+			Exporter: "none",
+		},
+		Log: Log{
+			Format:      "text",
+			Level:       "info",
+			SampleEvery: 100,
+		},
+	}
+}
+
+// Load retrieves the default Config overridden by the file at path, by the environment
+// variables and by overrides, usually built from the flags. The settings at the file and the
+// settings of the given flags replace the previous ones even when they are zero, e.g. 0 or an empty
+// text, while the other settings of overrides only replace them when they are not zero.
+// When path is empty the file of the environment variable PORTIMPORTER_CONFIG is used, if any.
+func Load(path string, overrides Config, flags ...string) (Config, error) {
+	config := Default()
+
+	if path == "" {
+		path = os.Getenv(FileEnv)
+	}
+
+	if path != "" {
+		fromFile, keys, err := loadFile(path)
+		if err != nil {
+			return config, err
+		}
+
+		merge(reflect.ValueOf(&config).Elem(), reflect.ValueOf(fromFile), "", func(field reflect.Value,
+			key string, _ string) bool {
+			return keys[key]
+		})
+	}
+
+	if err := loadEnv(reflect.ValueOf(&config).Elem()); err != nil {
+		return config, err
+	}
+
+	given := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		given[flag] = true
+	}
+
+	merge(reflect.ValueOf(&config).Elem(), reflect.ValueOf(overrides), "", func(field reflect.Value, _ string,
+		flag string) bool {
+		return !field.IsZero() || (flag != "" && given[flag])
+	})
+
+	return config, nil
+}
+
+// Reads the file as YAML or TOML, based on its extension, with the keys of the settings it
+// defines, e.g. "import.conflict_retries". Unknown settings are rejected, so typos are not
+// silently ignored.
+func loadFile(path string) (Config, map[string]bool, error) {
+	var config Config
+
+	keys := map[string]bool{}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return config, keys, fmt.Errorf("Error reading configuration file %s. Error: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+
+		if err = decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+			return config, keys, fmt.Errorf("Error decoding configuration file %s. Error: %w", path, err)
+		}
+
+		var settings map[string]interface{}
+		if err = yaml.Unmarshal(content, &settings); err != nil {
+			return config, keys, fmt.Errorf("Error decoding configuration file %s. Error: %w", path, err)
+		}
+
+		addKeys(keys, "", settings)
+	case ".toml":
+		metadata, err := toml.Decode(string(content), &config)
+		if err != nil {
+			return config, keys, fmt.Errorf("Error decoding configuration file %s. Error: %w", path, err)
+		}
+
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			return config, keys, fmt.Errorf("Error decoding configuration file %s. Unknown settings: %v", path,
+				undecoded)
+		}
+
+		for _, key := range metadata.Keys() {
+			keys[key.String()] = true
+		}
+	default:
+		return config, keys, fmt.Errorf("Configuration file %s must be .yaml, .yml or .toml", path)
+	}
+
+	return config, keys, nil
+}
+
+// Adds to keys the key of every setting, and of every section, of the decoded YAML settings.
+func addKeys(keys map[string]bool, prefix string, settings map[string]interface{}) {
+	for name, value := range settings {
+		key := prefix + name
+		keys[key] = true
+
+		if section, ok := value.(map[string]interface{}); ok {
+			addKeys(keys, key+".", section)
+		}
+	}
+}
+
+// Sets every field with an env tag whose environment variable is defined. When it is not,
+// the content of the file at the environment variable with the _FILE suffix is used.
+func loadEnv(value reflect.Value) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		fieldType := value.Type().Field(i)
+
+		if field.Kind() == reflect.Struct && fieldType.Type != reflect.TypeOf(time.Duration(0)) {
+			if err := loadEnv(field); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		env := fieldType.Tag.Get("env")
+		if env == "" {
+			continue
+		}
+
+		content, err := lookupEnv(env)
+		if err != nil {
+			return err
+		}
+
+		if content == "" {
+			continue
+		}
+
+		if err := setFromString(field, content); err != nil {
+			return fmt.Errorf("Invalid value %q for %s. Error: %w", content, env, err)
+		}
+	}
+
+	return nil
+}
+
+// Retrieves the value of the environment variable env or, when it is empty, the content of
+// the file at env_FILE without the trailing line break.
+func lookupEnv(env string) (string, error) {
+	if content := os.Getenv(env); content != "" {
+		return content, nil
+	}
+
+	path := os.Getenv(env + fileEnvSuffix)
+	if path == "" {
+		return "", nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("Error reading %s%s file %s. Error: %w", env, fileEnvSuffix, path, err)
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// Sets the field from the text of an environment variable. Slices are separated by commas.
+func setFromString(field reflect.Value, content string) error {
+	switch {
+	case field.Type() == reflect.TypeOf(time.Duration(0)):
+		duration, err := time.ParseDuration(content)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(duration))
+	case field.Kind() == reflect.String:
+		field.SetString(content)
+	case field.Kind() == reflect.Int:
+		number, err := strconv.Atoi(content)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(number))
+	case field.Kind() == reflect.Float64:
+		number, err := strconv.ParseFloat(content, 64)
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(number)
+	case field.Kind() == reflect.Bool:
+		boolean, err := strconv.ParseBool(content)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(boolean)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		var values []string
+
+		for _, value := range strings.Split(content, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+
+		field.Set(reflect.ValueOf(values))
+	default:
+		return fmt.Errorf("Unsupported setting type %s", field.Type())
+	}
+
+	return nil
+}
+
+// Sets at destination every value of source that is set, as told by isSet from the value, the
+// key of the setting at the files, e.g. "import.conflict_retries", and its flag, if any.
+func merge(destination reflect.Value, source reflect.Value, prefix string,
+	isSet func(field reflect.Value, key string, flag string) bool,
+) {
+	for i := 0; i < source.NumField(); i++ {
+		field := source.Field(i)
+		fieldType := source.Type().Field(i)
+		key := prefix + fieldType.Tag.Get("yaml")
+
+		if field.Kind() == reflect.Struct && field.Type() != reflect.TypeOf(time.Duration(0)) {
+			merge(destination.Field(i), field, key+".", isSet)
+
+			continue
+		}
+
+		if isSet(field, key, fieldType.Tag.Get("flag")) {
+			destination.Field(i).Set(field)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Clears the environment variables of the settings, so the tests do not depend on the environment.
+func clearEnv(t *testing.T) {
+	t.Helper()
+
+	for _, env := range []string{"DB_DRIVER", "DB_CONNECTION_URI", "DB_NAME", "DB_AUTHENTICATION_NAME",
+		"DB_USER_NAME", "DB_USER_PASSWORD", "PORT_JSON_PATH", "DAEMON_SCHEDULE", "DAEMON_INTERVAL", "WATCH_DEBOUNCE",
+		"LOCK_MODE", "LOCK_OWNER", "LOCK_TTL", "IMPORT_CONFLICT_RETRIES", "IMPORT_MERGE", "IMPORT_SOURCES", "IMPORT_DUPLICATES", "IMPORT_NORMALIZE", "IMPORT_COUNTRIES", "IMPORT_COORDINATES", "IMPORT_TIMEZONES",
+		"SERVE_ADDRESS", "METRICS_ADDRESS",
+		"DB_RETRY_ATTEMPTS", "DB_CONNECT_ATTEMPTS", "DB_RETRY_INITIAL_BACKOFF", "DB_RETRY_MAX_BACKOFF",
+		"DB_RETRY_JITTER", "TRACE_EXPORTER", "TRACE_FILE", "TRACE_OTLP_ENDPOINT",
+		"LOG_FORMAT", "LOG_LEVEL", "LOG_COMPONENT_LEVELS", "LOG_SAMPLE_EVERY", FileEnv} {
+		t.Setenv(env, "")
+		t.Setenv(env+fileEnvSuffix, "")
+	}
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Given no sources When loading Then the defaults are expected", func(t *testing.T) {
+		clearEnv(t)
+
+		config, err := Load("", Config{})
+		assert.NoError(t, err)
+		assert.Equal(t, Default(), config)
+	})
+
+	t.Run("Given a YAML file When loading Then the settings of the file are expected", func(t *testing.T) {
+		clearEnv(t)
+		path := writeFile(t, "config.yaml", "database:\n  driver: sqlite\n  uri: ports.db\nimport:\n  file: ports.json\n")
+
+		config, err := Load(path, Config{})
+		assert.NoError(t, err)
+		assert.Equal(t, "sqlite", config.Database.Driver)
+		assert.Equal(t, "ports.db", config.Database.URI)
+		assert.Equal(t, "ports.json", config.Import.File)
+		assert.Equal(t, ":8080", config.Server.Address, "Settings not at the file must keep the default")
+	})
+
+	t.Run("Given a TOML file at PORTIMPORTER_CONFIG When loading Then the settings of the file are expected", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv(FileEnv, writeFile(t, "config.toml", "[database]\ndriver = \"postgres\"\nname = \"ports\"\n"))
+
+		config, err := Load("", Config{})
+		assert.NoError(t, err)
+		assert.Equal(t, "postgres", config.Database.Driver)
+		assert.Equal(t, "ports", config.Database.Name)
+	})
+
+	t.Run("Given a file, environment variables and overrides When loading Then the overrides take precedence", func(t *testing.T) {
+		clearEnv(t)
+		path := writeFile(t, "config.yml", "database:\n  driver: sqlite\n  uri: file.db\n  name: file\n")
+		t.Setenv("DB_CONNECTION_URI", "env.db")
+		t.Setenv("DB_NAME", "env")
+
+		config, err := Load(path, Config{Database: Database{Name: "flag"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "sqlite", config.Database.Driver, "File must override the default")
+		assert.Equal(t, "env.db", config.Database.URI, "Environment must override the file")
+		assert.Equal(t, "flag", config.Database.Name, "Overrides must override the environment")
+	})
+
+	t.Run("Given a YAML file with zero settings When loading Then the zeros must override the defaults", func(t *testing.T) {
+		clearEnv(t)
+		path := writeFile(t, "config.yaml", "database:\n  retry:\n    jitter: 0\nimport:\n  conflict_retries: 0\n")
+
+		config, err := Load(path, Config{})
+		assert.NoError(t, err)
+		assert.Zero(t, config.Import.ConflictRetries)
+		assert.Zero(t, config.Database.Retry.Jitter)
+		assert.Equal(t, Default().Database.Retry.Attempts, config.Database.Retry.Attempts, "Missing settings must keep the defaults")
+	})
+
+	t.Run("Given a TOML file with zero settings When loading Then the zeros must override the defaults", func(t *testing.T) {
+		clearEnv(t)
+		path := writeFile(t, "config.toml", "[database.retry]\njitter = 0.0\n\n[import]\nconflict_retries = 0\n")
+
+		config, err := Load(path, Config{})
+		assert.NoError(t, err)
+		assert.Zero(t, config.Import.ConflictRetries)
+		assert.Zero(t, config.Database.Retry.Jitter)
+		assert.Equal(t, Default().Database.Retry.Attempts, config.Database.Retry.Attempts, "Missing settings must keep the defaults")
+	})
+
+	t.Run("Given a zero override of a given flag When loading Then the zero must override the file", func(t *testing.T) {
+		clearEnv(t)
+		path := writeFile(t, "config.yaml", "database:\n  retry:\n    attempts: 7\n    connect_attempts: 4\n")
+
+		config, err := Load(path, Config{}, "db-retry-attempts")
+		assert.NoError(t, err)
+		assert.Zero(t, config.Database.Retry.Attempts)
+		assert.Equal(t, 4, config.Database.Retry.ConnectAttempts, "Zero overrides of flags not given must be ignored")
+	})
+
+	t.Run("Given a file with an unknown setting When loading Then an error is expected", func(t *testing.T) {
+		clearEnv(t)
+		path := writeFile(t, "config.yaml", "database:\n  drivr: sqlite\n")
+
+		_, err := Load(path, Config{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Given a TOML file with an unknown setting When loading Then an error is expected", func(t *testing.T) {
+		clearEnv(t)
+		path := writeFile(t, "config.toml", "[database]\ndrivr = \"sqlite\"\n")
+
+		_, err := Load(path, Config{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Given a file with an unknown extension When loading Then an error is expected", func(t *testing.T) {
+		clearEnv(t)
+		path := writeFile(t, "config.json", "{}")
+
+		_, err := Load(path, Config{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Given a non existing file When loading Then an error is expected", func(t *testing.T) {
+		clearEnv(t)
+
+		_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), Config{})
+		assert.Error(t, err)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given mongodb without URI and name When validating the database Then both settings must be reported", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{Database: Database{Driver: DriverMongoDB}}.ValidateDatabase()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "database.uri (DB_CONNECTION_URI or --db-uri) is required")
+		assert.Contains(t, err.Error(), "database.name (DB_NAME or --db-name) is required")
+	})
+
+	t.Run("Given an unknown driver When validating the database Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{Database: Database{Driver: "oracle"}}.ValidateDatabase()
+		assert.ErrorContains(t, err, "database.driver (DB_DRIVER or --store) must be")
+	})
+
+	t.Run("Given the memory driver When validating the database Then no error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		config := Default()
+		config.Database.Driver = DriverMemory
+
+		assert.NoError(t, config.ValidateDatabase())
+	})
+
+	t.Run("Given invalid retries When validating the database Then every invalid setting must be reported", func(t *testing.T) {
+		t.Parallel()
+
+		config := Default()
+		config.Database.Driver = DriverMemory
+		config.Database.Retry = Retry{Attempts: 0, ConnectAttempts: 1, InitialBackoff: -time.Second, Jitter: 1.5}
+
+		err := config.ValidateDatabase()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "database.retry.attempts (DB_RETRY_ATTEMPTS or --db-retry-attempts) must be at least 1")
+		assert.Contains(t, err.Error(), "database.retry.initial_backoff (DB_RETRY_INITIAL_BACKOFF) and")
+		assert.Contains(t, err.Error(), "database.retry.jitter (DB_RETRY_JITTER) must be between 0 and 1")
+		assert.NotContains(t, err.Error(), "connect_attempts")
+	})
+
+	t.Run("Given neither schedule nor interval When validating the daemon Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{}.ValidateDaemon()
+		assert.ErrorContains(t, err, "daemon.schedule (DAEMON_SCHEDULE or --schedule) or "+
+			"daemon.interval (DAEMON_INTERVAL or --interval) is required")
+	})
+
+	t.Run("Given both schedule and interval When validating the daemon Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{Daemon: Daemon{Schedule: "@hourly", Interval: time.Hour}}.ValidateDaemon()
+		assert.ErrorContains(t, err, "must not be both defined")
+	})
+
+	t.Run("Given an interval When validating the daemon Then no error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		assert.NoError(t, Config{Daemon: Daemon{Interval: time.Hour}}.ValidateDaemon())
+	})
+
+	t.Run("Given a zero debounce When validating the watch Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{}.ValidateWatch()
+		assert.ErrorContains(t, err, "watch.debounce (WATCH_DEBOUNCE or --debounce) must be positive")
+	})
+
+	t.Run("Given the default debounce When validating the watch Then no error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		assert.NoError(t, Default().ValidateWatch())
+	})
+
+	t.Run("Given an unknown lock mode and a short TTL When validating the lock Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{Lock: Lock{Mode: "skip", TTL: time.Millisecond}}.ValidateLock()
+		assert.ErrorContains(t, err, "lock.mode (LOCK_MODE or --lock) must be fail, wait or off")
+		assert.ErrorContains(t, err, "lock.ttl (LOCK_TTL) must be at least 1s")
+	})
+
+	t.Run("Given the default lock When validating the lock Then no error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		assert.NoError(t, Default().ValidateLock())
+	})
+
+	t.Run("Given no file When validating the import Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{}.ValidateImport()
+		assert.ErrorContains(t, err, "import.file (PORT_JSON_PATH or --file) is required")
+	})
+
+	t.Run("Given negative conflict retries When validating the import Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{Import: Import{File: writeFile(t, "ports.json", "{}"), ConflictRetries: -1}}.ValidateImport()
+		assert.ErrorContains(t, err, "import.conflict_retries (IMPORT_CONFLICT_RETRIES) must not be negative")
+	})
+
+	t.Run("Given a non existing file When validating the import Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{Import: Import{File: filepath.Join(t.TempDir(), "missing.json")}}.ValidateImport()
+		assert.ErrorContains(t, err, "must be an existing file")
+	})
+
+	t.Run("Given an existing file When validating the import Then no error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{Import: Import{File: writeFile(t, "ports.json", "{}")}}.ValidateImport()
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given an unknown policy of duplicates When validating the import Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{Import: Import{File: writeFile(t, "ports.json", "{}"), Duplicates: "merge"}}.ValidateImport()
+		assert.ErrorContains(t, err, "import.duplicates (IMPORT_DUPLICATES or --duplicates) must be last-wins, "+
+			"first-wins or fail")
+	})
+
+	t.Run("Given an unknown normalization When validating the import Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{Import: Import{File: writeFile(t, "ports.json", "{}"), Normalize: "ascii"}}.ValidateImport()
+		assert.ErrorContains(t, err, "import.normalize (IMPORT_NORMALIZE or --normalize) must be off, on or fold")
+	})
+
+	t.Run("Given an invalid countries mode When validating the import Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{Import: Import{File: writeFile(t, "ports.json", "{}"), Countries: "yes"}}.ValidateImport()
+		assert.ErrorContains(t, err, "import.countries (IMPORT_COUNTRIES or --countries) must be off or on")
+	})
+
+	t.Run("Given an invalid coordinates mode When validating the import Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{Import: Import{File: writeFile(t, "ports.json", "{}"), Coordinates: "on"}}.ValidateImport()
+		assert.ErrorContains(t, err, "import.coordinates (IMPORT_COORDINATES or --coordinates) must be off, check or fix")
+	})
+
+	t.Run("Given an invalid timezones mode When validating the import Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{Import: Import{File: writeFile(t, "ports.json", "{}"), Timezones: "on"}}.ValidateImport()
+		assert.ErrorContains(t, err, "import.timezones (IMPORT_TIMEZONES or --timezones) must be off, check or fill")
+	})
+
+	t.Run("Given sources without file When validating the sources Then no error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{Import: Import{Sources: []string{writeFile(t, "unlocode.json", "{}") + "=10",
+			writeFile(t, "partner.json", "{}")}}}.ValidateSources()
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given invalid sources When validating the sources Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{Import: Import{Sources: []string{writeFile(t, "ports.json", "{}") + "=high"}}}.ValidateSources()
+		assert.ErrorContains(t, err, "import.sources (IMPORT_SOURCES or --sources) is invalid")
+
+		err = Config{Import: Import{Sources: []string{filepath.Join(t.TempDir(), "missing.json") + "=1"}}}.ValidateSources()
+		assert.ErrorContains(t, err, "must be an existing file")
+	})
+
+	t.Run("Given no sources When validating the sources Then the file must be validated", func(t *testing.T) {
+		t.Parallel()
+
+		err := Config{}.ValidateSources()
+		assert.ErrorContains(t, err, "import.file (PORT_JSON_PATH or --file) is required")
+	})
+}
+
+func TestParseSources(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given sources with and without priority When parsing Then they must be retrieved in order", func(t *testing.T) {
+		t.Parallel()
+
+		sources, err := Import{Sources: []string{"unlocode.json=10", " partner.json ", "a=b.json = -1"}}.ParseSources()
+
+		require.NoError(t, err)
+		assert.Equal(t, []Source{{"unlocode.json", 10}, {"partner.json", 0}, {"a=b.json", -1}}, sources)
+	})
+
+	t.Run("Given a source without path When parsing Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Import{Sources: []string{"=10"}}.ParseSources()
+
+		assert.ErrorContains(t, err, "expected path=priority")
+	})
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/redact"
+)
+
+// Secret is a setting that must not be shown. It is printed, logged and encoded as
+// redact.Placeholder, Value retrieves the real value.
+type Secret string
+
+// Value retrieves the value of the secret.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+
+	return redact.Placeholder
+}
+
+// GoString is used by the %#v verb.
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Secrets retrieves the values of every Secret of the Config and the passwords embedded at the
+// connection URIs, so they can be redacted from the logs.
+func (c Config) Secrets() []string {
+	secrets := collectSecrets(reflect.ValueOf(c), nil)
+
+	return append(secrets, redact.URIPassword(c.Database.URI))
+}
+
+func collectSecrets(value reflect.Value, secrets []string) []string {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+
+		switch {
+		case field.Type() == reflect.TypeOf(Secret("")):
+			secrets = append(secrets, field.String())
+		case field.Kind() == reflect.Struct:
+			secrets = collectSecrets(field, secrets)
+		}
+	}
+
+	return secrets
+}
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ValidateDatabase retrieves an error describing every missing or invalid setting of the store.
+func (c Config) ValidateDatabase() error {
+	var errs []error
+
+	switch c.Database.Driver {
+	case DriverMongoDB:
+		errs = append(errs, required(c.Database, "URI"), required(c.Database, "Name"))
+	case DriverPostgres, DriverSQLite:
+		errs = append(errs, required(c.Database, "URI"))
+	case DriverMemory:
+	default:
+		errs = append(errs, fmt.Errorf("%s must be %s, %s, %s or %s, found %q", describe(c.Database, "Driver"),
+			DriverMongoDB, DriverPostgres, DriverSQLite, DriverMemory, c.Database.Driver))
+	}
+
+	errs = append(errs, c.Database.Retry.validate())
+
+	return errors.Join(errs...)
+}
+
+// Retrieves an error describing every invalid setting of the retries.
+func (r Retry) validate() error {
+	var errs []error
+
+	if r.Attempts < 1 {
+		errs = append(errs, fmt.Errorf("%s must be at least 1, found %d", describe(r, "Attempts"), r.Attempts))
+	}
+
+	if r.ConnectAttempts < 1 {
+		errs = append(errs, fmt.Errorf("%s must be at least 1, found %d", describe(r, "ConnectAttempts"),
+			r.ConnectAttempts))
+	}
+
+	if r.InitialBackoff < 0 || r.MaxBackoff < 0 {
+		errs = append(errs, fmt.Errorf("%s and %s must not be negative", describe(r, "InitialBackoff"),
+			describe(r, "MaxBackoff")))
+	}
+
+	if r.Jitter < 0 || r.Jitter > 1 {
+		errs = append(errs, fmt.Errorf("%s must be between 0 and 1, found %v", describe(r, "Jitter"), r.Jitter))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateImport retrieves an error when the file to be imported is not defined or does not exist,
+// the conflict retries are negative, or the policy of the repeated keys or the normalization is
+// unknown.
+func (c Config) ValidateImport() error {
+	if err := c.Import.validate(); err != nil {
+		return err
+	}
+
+	if err := required(c.Import, "File"); err != nil {
+		return err
+	}
+
+	return existingFile(describe(c.Import, "File"), c.Import.File)
+}
+
+// ValidateSources validates the import as ValidateImport does, but when the sources are defined
+// they replace the file: every source must be valid and exist.
+func (c Config) ValidateSources() error {
+	if len(c.Import.Sources) == 0 {
+		return c.ValidateImport()
+	}
+
+	if err := c.Import.validate(); err != nil {
+		return err
+	}
+
+	sources, err := c.Import.ParseSources()
+	if err != nil {
+		return fmt.Errorf("%s is invalid. Error: %w", describe(c.Import, "Sources"), err)
+	}
+
+	errs := make([]error, 0, len(sources))
+	for _, source := range sources {
+		errs = append(errs, existingFile(describe(c.Import, "Sources"), source.Path))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Retrieves an error when a setting of the import, other than the files, is invalid.
+func (i Import) validate() error {
+	if i.ConflictRetries < 0 {
+		return fmt.Errorf("%s must not be negative, found %d", describe(i, "ConflictRetries"), i.ConflictRetries)
+	}
+
+	// Without policy the last entry wins, as it did before the policy existed.
+	switch i.Duplicates {
+	case "", DuplicatesLastWins, DuplicatesFirstWins, DuplicatesFail:
+	default:
+		return fmt.Errorf("%s must be %s, %s or %s, found %q", describe(i, "Duplicates"), DuplicatesLastWins,
+			DuplicatesFirstWins, DuplicatesFail, i.Duplicates)
+	}
+
+	// Without mode the texts are not normalized.
+	switch i.Normalize {
+	case "", NormalizeOff, NormalizeOn, NormalizeFold:
+	default:
+		return fmt.Errorf("%s must be %s, %s or %s, found %q", describe(i, "Normalize"), NormalizeOff, NormalizeOn,
+			NormalizeFold, i.Normalize)
+	}
+
+	// Without mode the codes are not set.
+	switch i.Countries {
+	case "", CountriesOff, CountriesOn:
+	default:
+		return fmt.Errorf("%s must be %s or %s, found %q", describe(i, "Countries"), CountriesOff, CountriesOn,
+			i.Countries)
+	}
+
+	// Without mode the coordinates are not checked.
+	switch i.Coordinates {
+	case "", CoordinatesOff, CoordinatesCheck, CoordinatesFix:
+	default:
+		return fmt.Errorf("%s must be %s, %s or %s, found %q", describe(i, "Coordinates"), CoordinatesOff,
+			CoordinatesCheck, CoordinatesFix, i.Coordinates)
+	}
+
+	// Without mode the timezones are not inferred.
+	switch i.Timezones {
+	case "", TimezonesOff, TimezonesCheck, TimezonesFill:
+		return nil
+	default:
+		return fmt.Errorf("%s must be %s, %s or %s, found %q", describe(i, "Timezones"), TimezonesOff, TimezonesCheck,
+			TimezonesFill, i.Timezones)
+	}
+}
+
+// Retrieves an error when the file of the setting does not exist or is a directory.
+func existingFile(setting string, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%s must be an existing file. Error: %w", setting, err)
+	}
+
+	if info.IsDir() {
+		return fmt.Errorf("%s must be a file, %s is a directory", setting, path)
+	}
+
+	return nil
+}
+
+// ValidateDaemon retrieves an error unless either the schedule or the interval of the
+// recurring imports is defined.
+func (c Config) ValidateDaemon() error {
+	switch {
+	case c.Daemon.Schedule == "" && c.Daemon.Interval == 0:
+		return fmt.Errorf("%s or %s is required", describe(c.Daemon, "Schedule"), describe(c.Daemon, "Interval"))
+	case c.Daemon.Schedule != "" && c.Daemon.Interval != 0:
+		return fmt.Errorf("%s and %s must not be both defined", describe(c.Daemon, "Schedule"),
+			describe(c.Daemon, "Interval"))
+	case c.Daemon.Interval < 0:
+		return fmt.Errorf("%s must be positive, found %s", describe(c.Daemon, "Interval"), c.Daemon.Interval)
+	}
+
+	return nil
+}
+
+// ValidateWatch retrieves an error when the debounce of the watched file is not positive.
+func (c Config) ValidateWatch() error {
+	if c.Watch.Debounce <= 0 {
+		return fmt.Errorf("%s must be positive, found %s", describe(c.Watch, "Debounce"), c.Watch.Debounce)
+	}
+
+	return nil
+}
+
+// ValidateLock retrieves an error describing every invalid setting of the lock of the imports.
+func (c Config) ValidateLock() error {
+	var errs []error
+
+	switch c.Lock.Mode {
+	case LockFail, LockWait, LockOff:
+	default:
+		errs = append(errs, fmt.Errorf("%s must be %s, %s or %s, found %q", describe(c.Lock, "Mode"), LockFail,
+			LockWait, LockOff, c.Lock.Mode))
+	}
+
+	if c.Lock.TTL < time.Second {
+		errs = append(errs, fmt.Errorf("%s must be at least 1s, found %s", describe(c.Lock, "TTL"), c.Lock.TTL))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateServer retrieves an error when the address of the HTTP server is not defined.
+func (c Config) ValidateServer() error {
+	return required(c.Server, "Address")
+}
+
+// Retrieves an error when the field of the section is empty.
+func required(section interface{}, fieldName string) error {
+	if reflect.ValueOf(section).FieldByName(fieldName).IsZero() {
+		return fmt.Errorf("%s is required", describe(section, fieldName))
+	}
+
+	return nil
+}
+
+// Retrieves the name of the setting at the configuration file followed by the environment
+// variable and the flag that define it, e.g. "database.uri (DB_CONNECTION_URI or --db-uri)".
+func describe(section interface{}, fieldName string) string {
+	sectionType := reflect.TypeOf(section)
+	field, _ := sectionType.FieldByName(fieldName)
+
+	sectionKey := sectionPath(reflect.TypeOf(Config{}), sectionType)
+
+	var sources []string
+
+	if env := field.Tag.Get("env"); env != "" {
+		sources = append(sources, env)
+	}
+
+	if flag := field.Tag.Get("flag"); flag != "" {
+		sources = append(sources, "--"+flag)
+	}
+
+	return fmt.Sprintf("%s.%s (%s)", sectionKey, field.Tag.Get("yaml"), strings.Join(sources, " or "))
+}
+
+// Retrieves the keys, separated by dots, of the section of type sectionType nested at parentType,
+// e.g. "database.retry".
+func sectionPath(parentType reflect.Type, sectionType reflect.Type) string {
+	for i := 0; i < parentType.NumField(); i++ {
+		field := parentType.Field(i)
+		if field.Type == sectionType {
+			return field.Tag.Get("yaml")
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			if path := sectionPath(field.Type, sectionType); path != "" {
+				return field.Tag.Get("yaml") + "." + path
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,239 @@
+// Package coordinates checks the coordinates of the imported Ports against the bounds of their
+// country, detecting the longitudes and latitudes that were swapped or had their sign changed. It
+// works offline: the bounds are embedded at the binary from data/bounds.tsv, which has one or more
+// boxes for each country covering its territory and islands.
+//
+// The boxes are not the borders of the countries: coordinates outside the boxes are wrong, but
+// coordinates inside them may still be at a neighbouring country, e.g. Singapore is inside the box
+// of Indonesia, or far at sea, as the boxes of archipelagos are mostly sea. A country crossing the
+// antimeridian has a box at each side, as a box does not wrap around it.
+package coordinates
+
+import (
+	_ "embed"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+)
+
+// Kinds of the flags of the Ports.
+const (
+	// KindCoordinatesSwapped is a pair of coordinates that is inside the country with the longitude
+	// and the latitude swapped, and maybe with their signs changed.
+	KindCoordinatesSwapped = "coordinates-swapped"
+	// KindCoordinatesSign is a pair of coordinates that is inside the country with the sign of the
+	// longitude, the latitude or both changed.
+	KindCoordinatesSign = "coordinates-sign"
+	// KindCoordinatesOutside is a pair of coordinates outside the country that no correction brings
+	// inside it.
+	KindCoordinatesOutside = "coordinates-outside-country"
+	// KindCoordinatesAmbiguous is a pair of coordinates outside the country that several corrections
+	// bring inside it at different places, so it is not corrected.
+	KindCoordinatesAmbiguous = "coordinates-ambiguous"
+)
+
+// Coordinates nearer than this margin, in degrees, to a box of a country are inside the country,
+// as the boxes are rounded and ports may lie off the coast.
+const margin = 0.5
+
+//go:embed data/bounds.tsv
+var boundsTSV string
+
+// Box is a box of the bounds of a country, in degrees.
+type Box struct {
+	West  float64
+	South float64
+	East  float64
+	North float64
+}
+
+// Contains retrieves whether the coordinates are inside the box, or within the margin of it.
+func (b Box) Contains(longitude float64, latitude float64) bool {
+	return longitude >= b.West-margin && longitude <= b.East+margin &&
+		latitude >= b.South-margin && latitude <= b.North+margin
+}
+
+// The bounds are parsed the first time they are used.
+var load = sync.OnceValue(func() map[string][]Box {
+	bounds := map[string][]Box{}
+
+	for _, line := range strings.Split(boundsTSV, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		countryCode, boxes, _ := strings.Cut(line, "\t")
+
+		for _, text := range strings.Split(boxes, "|") {
+			box, err := parseBox(text)
+			if err != nil {
+				panic(fmt.Sprintf("Error parsing bounds.tsv line %q. Error: %s", line, err))
+			}
+
+			bounds[countryCode] = append(bounds[countryCode], box)
+		}
+	}
+
+	return bounds
+})
+
+// Retrieves the box of its west, south, east and north bounds separated by commas.
+func parseBox(text string) (Box, error) {
+	values := strings.Split(text, ",")
+	if len(values) != 4 {
+		return Box{}, fmt.Errorf("box %s has not 4 bounds", text)
+	}
+
+	var bounds [4]float64
+
+	for i, value := range values {
+		bound, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return Box{}, fmt.Errorf("bound %s of box %s is not numeric", value, text)
+		}
+
+		bounds[i] = bound
+	}
+
+	return Box{West: bounds[0], South: bounds[1], East: bounds[2], North: bounds[3]}, nil
+}
+
+// Bounds retrieves the boxes of the country of the ISO 3166-1 alpha-2 code, or false when the
+// country has no bounds, e.g. for most of the withdrawn codes.
+func Bounds(countryCode string) ([]Box, bool) {
+	boxes, ok := load()[countryCode]
+
+	return boxes, ok
+}
+
+// Inside retrieves whether the coordinates are inside one of the boxes.
+func Inside(boxes []Box, longitude float64, latitude float64) bool {
+	for _, box := range boxes {
+		if box.Contains(longitude, latitude) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Corrections tried on the coordinates outside their country, listed from the most likely.
+var corrections = []struct {
+	kind        string
+	description string
+	correct     func(longitude float64, latitude float64) (float64, float64)
+}{
+	{KindCoordinatesSwapped, "swapped", func(longitude float64, latitude float64) (float64, float64) {
+		return latitude, longitude
+	}},
+	{KindCoordinatesSign, "with the sign of the longitude changed", func(longitude float64, latitude float64) (float64, float64) {
+		return -longitude, latitude
+	}},
+	{KindCoordinatesSign, "with the sign of the latitude changed", func(longitude float64, latitude float64) (float64, float64) {
+		return longitude, -latitude
+	}},
+	{KindCoordinatesSign, "with both signs changed", func(longitude float64, latitude float64) (float64, float64) {
+		return -longitude, -latitude
+	}},
+	{KindCoordinatesSwapped, "swapped and with the sign of the longitude changed", func(longitude float64, latitude float64) (float64, float64) {
+		return -latitude, longitude
+	}},
+	{KindCoordinatesSwapped, "swapped and with the sign of the latitude changed", func(longitude float64, latitude float64) (float64, float64) {
+		return latitude, -longitude
+	}},
+	{KindCoordinatesSwapped, "swapped and with both signs changed", func(longitude float64, latitude float64) (float64, float64) {
+		return -latitude, -longitude
+	}},
+}
+
+// Options of the Enricher.
+type Options struct {
+	// Fix sets the coordinates that were swapped or had their sign changed to the corrected ones.
+	// Otherwise they are only flagged.
+	Fix bool
+}
+
+// Enricher is the domain.Enricher that flags the coordinates of the Ports outside their country,
+// and corrects the ones that were swapped or had their sign changed when it fixes them.
+type Enricher struct {
+	options Options
+}
+
+// Retrieves a new Enricher.
+func NewEnricher(options Options) Enricher {
+	return Enricher{options: options}
+}
+
+// Enrich retrieves the Port with its coordinates checked against the bounds of its country, which
+// is its CountryCode, or the prefix of the key when it has none. Coordinates outside the country are
+// flagged with the correction that brings them inside it, which is applied when the Enricher fixes
+// them. As the boxes are not the borders of the countries, coordinates that several corrections bring
+// inside the country at different places are only flagged as ambiguous. Ports without a pair of
+// coordinates, or of countries without bounds, are retrieved as they are.
+func (e Enricher) Enrich(port entities.Port) (entities.Port, []entities.Flag) {
+	if len(port.Coordinates) != 2 {
+		return port, nil
+	}
+
+	countryCode := port.CountryCode
+	if countryCode == "" && len(port.ID) >= 2 {
+		countryCode = strings.ToUpper(port.ID[:2])
+	}
+
+	boxes, ok := Bounds(countryCode)
+	if !ok {
+		return port, nil
+	}
+
+	longitude, latitude := port.Coordinates[0], port.Coordinates[1]
+	if Inside(boxes, longitude, latitude) {
+		return port, nil
+	}
+
+	var (
+		descriptions []string
+		kinds        []string
+		candidates   [][]float64
+	)
+
+	for _, correction := range corrections {
+		correctedLongitude, correctedLatitude := correction.correct(longitude, latitude)
+		corrected := []float64{correctedLongitude, correctedLatitude}
+
+		// Corrections of coordinates at 0 may bring them to the same place.
+		if !Inside(boxes, correctedLongitude, correctedLatitude) ||
+			slices.ContainsFunc(candidates, func(candidate []float64) bool { return slices.Equal(candidate, corrected) }) {
+			continue
+		}
+
+		descriptions = append(descriptions, fmt.Sprintf("%s they are inside it: %s", correction.description,
+			entities.Port{Coordinates: corrected}.Field("coordinates")))
+		kinds = append(kinds, correction.kind)
+		candidates = append(candidates, corrected)
+	}
+
+	switch len(candidates) {
+	case 0:
+		return port, []entities.Flag{{Field: "coordinates", Kind: KindCoordinatesOutside,
+			Detail: fmt.Sprintf("Coordinates %s are outside %s", port.Field("coordinates"), countryCode)}}
+	case 1:
+		flag := entities.Flag{Field: "coordinates", Kind: kinds[0],
+			Detail: fmt.Sprintf("Coordinates %s are outside %s, %s", port.Field("coordinates"), countryCode,
+				descriptions[0])}
+
+		if e.options.Fix {
+			port.Coordinates = candidates[0]
+			port = port.WithInferred("coordinates")
+		}
+
+		return port, []entities.Flag{flag}
+	default:
+		return port, []entities.Flag{{Field: "coordinates", Kind: KindCoordinatesAmbiguous,
+			Detail: fmt.Sprintf("Coordinates %s are outside %s and not corrected, as %d corrections bring them inside it: %s",
+				port.Field("coordinates"), countryCode, len(candidates), strings.Join(descriptions, "; "))}}
+	}
+}
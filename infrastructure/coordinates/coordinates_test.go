@@ -0,0 +1,198 @@
+package coordinates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBounds(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a country crossing the antimeridian When querying its bounds Then both sides must be inside", func(t *testing.T) {
+		t.Parallel()
+
+		boxes, ok := Bounds("FJ")
+		require.True(t, ok)
+
+		assert.True(t, Inside(boxes, 178.44, -18.14), "Suva must be inside")
+		assert.True(t, Inside(boxes, -178.8, -17.3), "The Lau islands must be inside")
+		assert.False(t, Inside(boxes, 0, 0))
+	})
+
+	t.Run("Given countries crossing the antimeridian When querying their bounds Then the ports at both sides must be inside", func(t *testing.T) {
+		t.Parallel()
+
+		ports := []struct {
+			countryCode         string
+			longitude, latitude float64
+		}{
+			{"RU", 177.5, 64.73},   // Anadyr
+			{"RU", -173.23, 64.42}, // Provideniya
+			{"US", 173.18, 52.83},  // Attu
+			{"US", -176.63, 51.86}, // Adak
+			{"KI", 172.98, 1.35},   // Tarawa
+			{"KI", -157.47, 1.98},  // Kiritimati
+		}
+
+		for _, port := range ports {
+			boxes, ok := Bounds(port.countryCode)
+			require.True(t, ok)
+
+			assert.True(t, Inside(boxes, port.longitude, port.latitude), "%s %v,%v must be inside",
+				port.countryCode, port.longitude, port.latitude)
+		}
+
+		boxes, _ := Bounds("KI")
+		assert.False(t, Inside(boxes, -165, 0), "The sea between the boxes must be outside")
+	})
+
+	t.Run("Given boxes that are mostly sea When checking coordinates inside them Then they must be inside whatever is there", func(t *testing.T) {
+		t.Parallel()
+
+		boxes, ok := Bounds("ID")
+		require.True(t, ok)
+
+		// The boxes are not the borders, so these are known limitations of the check.
+		assert.True(t, Inside(boxes, 128.0, -6.0), "The middle of the Banda Sea is inside the box")
+		assert.True(t, Inside(boxes, 103.85, 1.29), "Singapore is inside the box")
+
+		boxes, ok = Bounds("US")
+		require.True(t, ok)
+
+		assert.True(t, Inside(boxes, -123.1, 49.28), "Vancouver is inside the box")
+	})
+
+	t.Run("Given coordinates off the coast When checking them Then the margin must take them inside", func(t *testing.T) {
+		t.Parallel()
+
+		boxes, ok := Bounds("AE")
+		require.True(t, ok)
+
+		assert.True(t, Inside(boxes, 56.7, 25.1))
+		assert.False(t, Inside(boxes, 57.1, 25.1))
+	})
+
+	t.Run("Given a withdrawn code When querying its bounds Then no bounds are expected", func(t *testing.T) {
+		t.Parallel()
+
+		_, ok := Bounds("YU")
+		assert.False(t, ok)
+	})
+}
+
+func TestEnrich(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		options     Options
+		port        entities.Port
+		coordinates []float64
+		kinds       []string
+	}{
+		{
+			name:        "Given coordinates inside the country Then nothing is expected",
+			options:     Options{Fix: true},
+			port:        entities.Port{ID: "AEJEA", Coordinates: []float64{55.03, 24.99}},
+			coordinates: []float64{55.03, 24.99},
+		},
+		{
+			name:        "Given swapped coordinates and fix Then the corrected coordinates are expected",
+			options:     Options{Fix: true},
+			port:        entities.Port{ID: "ANGSB", Coordinates: []float64{12.17, -68.45}},
+			coordinates: []float64{-68.45, 12.17},
+			kinds:       []string{KindCoordinatesSwapped},
+		},
+		{
+			name:        "Given swapped coordinates without fix Then only the flag is expected",
+			port:        entities.Port{ID: "ANGSB", Coordinates: []float64{12.17, -68.45}},
+			coordinates: []float64{12.17, -68.45},
+			kinds:       []string{KindCoordinatesSwapped},
+		},
+		{
+			name:        "Given a longitude with the wrong sign and fix Then the corrected coordinates are expected",
+			options:     Options{Fix: true},
+			port:        entities.Port{ID: "TNJAR", Coordinates: []float64{-11.1, 33.5}},
+			coordinates: []float64{11.1, 33.5},
+			kinds:       []string{KindCoordinatesSign},
+		},
+		{
+			name:        "Given coordinates that several corrections bring inside the country and fix Then only an ambiguous flag is expected",
+			options:     Options{Fix: true},
+			port:        entities.Port{ID: "ESBCN", Coordinates: []float64{2.17, -41.38}},
+			coordinates: []float64{2.17, -41.38},
+			kinds:       []string{KindCoordinatesAmbiguous},
+		},
+		{
+			name:        "Given coordinates of another country Then an outside flag is expected",
+			options:     Options{Fix: true},
+			port:        entities.Port{ID: "BMSOU", Coordinates: []float64{-1.4, 50.91}},
+			coordinates: []float64{-1.4, 50.91},
+			kinds:       []string{KindCoordinatesOutside},
+		},
+		{
+			name:        "Given a country code Then its bounds are expected instead of the key prefix",
+			port:        entities.Port{ID: "ANEUX", CountryCode: "NL", Coordinates: []float64{4.48, 51.9}},
+			coordinates: []float64{4.48, 51.9},
+		},
+		{
+			name:    "Given no coordinates Then nothing is expected",
+			options: Options{Fix: true},
+			port:    entities.Port{ID: "AEJEA"},
+		},
+		{
+			name:        "Given a country without bounds Then nothing is expected",
+			options:     Options{Fix: true},
+			port:        entities.Port{ID: "ZZAAA", Coordinates: []float64{0, 0}},
+			coordinates: []float64{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" When enriching", func(t *testing.T) {
+			t.Parallel()
+
+			port, flags := NewEnricher(tt.options).Enrich(tt.port)
+
+			assert.Equal(t, tt.coordinates, port.Coordinates)
+
+			var kinds []string
+			for _, flag := range flags {
+				assert.Equal(t, "coordinates", flag.Field)
+				kinds = append(kinds, flag.Kind)
+			}
+
+			assert.Equal(t, tt.kinds, kinds)
+		})
+	}
+
+	t.Run("Given provenance When fixing the coordinates Then they must be inferred and the given Port kept", func(t *testing.T) {
+		t.Parallel()
+
+		provenance := entities.Provenance{Source: "ports.json", RunID: "run", UpdatedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+		port := entities.Port{ID: "ANGSB", Coordinates: []float64{12.17, -68.45}}.WithProvenance(provenance)
+
+		fixed, flags := NewEnricher(Options{Fix: true}).Enrich(port)
+
+		require.Len(t, flags, 1)
+		assert.Equal(t, "Coordinates 12.17,-68.45 are outside AN, swapped they are inside it: -68.45,12.17", flags[0].Detail)
+		assert.Equal(t, entities.SourceInferred, fixed.Provenance["coordinates"].Source)
+		assert.Equal(t, []float64{12.17, -68.45}, port.Coordinates, "The given Port must not be changed")
+		assert.Equal(t, provenance, port.Provenance["coordinates"], "The given Port must not be changed")
+	})
+
+	t.Run("Given ambiguous coordinates When enriching Then the flag must list every correction", func(t *testing.T) {
+		t.Parallel()
+
+		_, flags := NewEnricher(Options{Fix: true}).Enrich(entities.Port{ID: "ESBCN", Coordinates: []float64{2.17, -41.38}})
+
+		require.Len(t, flags, 1)
+		assert.Equal(t, "Coordinates 2.17,-41.38 are outside ES and not corrected, as 2 corrections bring them inside it: "+
+			"with the sign of the latitude changed they are inside it: 2.17,41.38; "+
+			"with both signs changed they are inside it: -2.17,41.38", flags[0].Detail)
+	})
+}
@@ -0,0 +1,299 @@
+// Package export writes the Ports back to files. Every format writes the Ports in the order
+// they are received and the fields in a fixed order, so exports can be diffed.
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/jsonstream"
+)
+
+// Formats supported by NewWriter.
+const (
+	FormatJSON    = "json"
+	FormatNDJSON  = "ndjson"
+	FormatCSV     = "csv"
+	FormatGeoJSON = "geojson"
+)
+
+// Separator of the values of the slices at the CSV format.
+const csvListSeparator = "|"
+
+// Writer writes Ports in a format. Close must be called after the last Port to finish the output.
+type Writer interface {
+	Write(entities.Port) error
+	Close() error
+}
+
+// NewWriter retrieves the Writer for the format.
+func NewWriter(format string, output io.Writer) (Writer, error) {
+	switch format {
+	case FormatJSON:
+		return &jsonWriter{output: output}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{output: output}, nil
+	case FormatCSV:
+		return &csvWriter{output: csv.NewWriter(output)}, nil
+	case FormatGeoJSON:
+		return &geoJSONWriter{output: output}, nil
+	default:
+		return nil, fmt.Errorf("Unknown export format %s", format)
+	}
+}
+
+// Export writes every Port of the repository to the output in the format and retrieves
+// how many Ports were written.
+func Export(ctx context.Context, portRepository domain.PortRepository, format string, output io.Writer) (int, error) {
+	writer, err := NewWriter(format, output)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+
+	err = portRepository.ForEach(ctx, func(port entities.Port) error {
+		total++
+
+		return writer.Write(port)
+	})
+	if err != nil {
+		return total, err
+	}
+
+	return total, writer.Close()
+}
+
+// Retrieves the Port at the format of the imported file.
+func toPortStream(port entities.Port) jsonstream.PortStream {
+	return jsonstream.PortStream{
+		Name:        port.Name,
+		City:        port.City,
+		Country:     port.Country,
+		Alias:       nonNilStrings(port.Alias),
+		Regions:     nonNilStrings(port.Regions),
+		Coordinates: nonNilFloats(port.Coordinates),
+		Province:    port.Province,
+		Timezone:    port.Timezone,
+		Unlocs:      nonNilStrings(port.Unlocs),
+		Code:        port.Code,
+	}
+}
+
+// Retrieves the JSON of value without escaping HTML characters, so texts are kept as imported.
+func marshal(value interface{}, prefix string, indent string) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent(prefix, indent)
+
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+// jsonWriter writes the keyed object format of the imported file.
+type jsonWriter struct {
+	output io.Writer
+	count  int
+}
+
+func (w *jsonWriter) Write(port entities.Port) error {
+	key, err := marshal(port.ID, "", "")
+	if err != nil {
+		return err
+	}
+
+	value, err := marshal(toPortStream(port), "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+	if w.count == 0 {
+		separator = "{\n"
+	}
+
+	w.count++
+
+	_, err = fmt.Fprintf(w.output, "%s  %s: %s", separator, key, value)
+
+	return err
+}
+
+func (w *jsonWriter) Close() error {
+	if w.count == 0 {
+		_, err := io.WriteString(w.output, "{}\n")
+
+		return err
+	}
+
+	_, err := io.WriteString(w.output, "\n}\n")
+
+	return err
+}
+
+// ndjsonLine is a Port of the NDJSON format, the key is written before the other fields.
+type ndjsonLine struct {
+	Key string `json:"key"`
+	jsonstream.PortStream
+}
+
+// ndjsonWriter writes one Port per line.
+type ndjsonWriter struct {
+	output io.Writer
+}
+
+func (w *ndjsonWriter) Write(port entities.Port) error {
+	line, err := marshal(ndjsonLine{Key: port.ID, PortStream: toPortStream(port)}, "", "")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w.output, "%s\n", line)
+
+	return err
+}
+
+func (w *ndjsonWriter) Close() error {
+	return nil
+}
+
+// csvWriter writes one Port per row. Slices are joined by csvListSeparator and the coordinates
+// are split into longitude and latitude.
+type csvWriter struct {
+	output        *csv.Writer
+	headerWritten bool
+}
+
+var csvHeader = []string{
+	"key", "name", "city", "province", "country", "timezone", "code",
+	"longitude", "latitude", "alias", "regions", "unlocs",
+}
+
+func (w *csvWriter) Write(port entities.Port) error {
+	if !w.headerWritten {
+		if err := w.output.Write(csvHeader); err != nil {
+			return err
+		}
+
+		w.headerWritten = true
+	}
+
+	longitude, latitude := "", ""
+	if len(port.Coordinates) == 2 {
+		longitude = strconv.FormatFloat(port.Coordinates[0], 'f', -1, 64)
+		latitude = strconv.FormatFloat(port.Coordinates[1], 'f', -1, 64)
+	}
+
+	return w.output.Write([]string{
+		port.ID, port.Name, port.City, port.Province, port.Country, port.Timezone, port.Code,
+		longitude, latitude,
+		strings.Join(port.Alias, csvListSeparator),
+		strings.Join(port.Regions, csvListSeparator),
+		strings.Join(port.Unlocs, csvListSeparator),
+	})
+}
+
+func (w *csvWriter) Close() error {
+	if !w.headerWritten {
+		if err := w.output.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+
+	w.output.Flush()
+
+	return w.output.Error()
+}
+
+type geoJSONPoint struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+type geoJSONProperties struct {
+	Key string `json:"key"`
+	jsonstream.PortStream
+}
+
+type geoJSONFeature struct {
+	Type       string            `json:"type"`
+	ID         string            `json:"id"`
+	Geometry   *geoJSONPoint     `json:"geometry"`
+	Properties geoJSONProperties `json:"properties"`
+}
+
+// geoJSONWriter writes a FeatureCollection with one Point Feature per Port. Ports without
+// a longitude and latitude pair are written with a null geometry.
+type geoJSONWriter struct {
+	output io.Writer
+	count  int
+}
+
+func (w *geoJSONWriter) Write(port entities.Port) error {
+	feature := geoJSONFeature{
+		Type:       "Feature",
+		ID:         port.ID,
+		Properties: geoJSONProperties{Key: port.ID, PortStream: toPortStream(port)},
+	}
+
+	if len(port.Coordinates) == 2 {
+		feature.Geometry = &geoJSONPoint{Type: "Point", Coordinates: port.Coordinates}
+	}
+
+	value, err := marshal(feature, "", "")
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+	if w.count == 0 {
+		separator = `{"type":"FeatureCollection","features":[` + "\n"
+	}
+
+	w.count++
+
+	_, err = fmt.Fprintf(w.output, "%s%s", separator, value)
+
+	return err
+}
+
+func (w *geoJSONWriter) Close() error {
+	if w.count == 0 {
+		_, err := io.WriteString(w.output, `{"type":"FeatureCollection","features":[]}`+"\n")
+
+		return err
+	}
+
+	_, err := io.WriteString(w.output, "\n]}\n")
+
+	return err
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
+
+func nonNilFloats(values []float64) []float64 {
+	if values == nil {
+		return []float64{}
+	}
+
+	return values
+}
@@ -0,0 +1,190 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/jsonstream"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRepository(t *testing.T) memory.PortRepository {
+	t.Helper()
+
+	portRepository := memory.NewPortRepository()
+	require.NoError(t, portRepository.Create(context.Background(), entities.NewPort(
+		"BBB",
+		"Name <B>",
+		"City B",
+		"Country",
+		[]string{"alias1", "alias2"},
+		[]string{},
+		[]float64{55.5, 25.25},
+		"Province",
+		"Asia/Dubai",
+		[]string{"BBB"},
+		"2")))
+	require.NoError(t, portRepository.Create(context.Background(), entities.NewPort(
+		"AAA",
+		"Name A",
+		"City, A",
+		"Country",
+		nil,
+		nil,
+		nil,
+		"",
+		"",
+		[]string{"AAA"},
+		"1")))
+
+	return portRepository
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			name:   "Given stored Ports When exporting to json Then the keyed object format is expected",
+			format: FormatJSON,
+			expected: `{
+  "AAA": {
+    "name": "Name A",
+    "city": "City, A",
+    "country": "Country",
+    "alias": [],
+    "regions": [],
+    "coordinates": [],
+    "province": "",
+    "timezone": "",
+    "unlocs": [
+      "AAA"
+    ],
+    "code": "1"
+  },
+  "BBB": {
+    "name": "Name <B>",
+    "city": "City B",
+    "country": "Country",
+    "alias": [
+      "alias1",
+      "alias2"
+    ],
+    "regions": [],
+    "coordinates": [
+      55.5,
+      25.25
+    ],
+    "province": "Province",
+    "timezone": "Asia/Dubai",
+    "unlocs": [
+      "BBB"
+    ],
+    "code": "2"
+  }
+}
+`,
+		},
+		{
+			name:   "Given stored Ports When exporting to ndjson Then one Port per line is expected",
+			format: FormatNDJSON,
+			expected: `{"key":"AAA","name":"Name A","city":"City, A","country":"Country","alias":[],"regions":[],"coordinates":[],"province":"","timezone":"","unlocs":["AAA"],"code":"1"}
+{"key":"BBB","name":"Name <B>","city":"City B","country":"Country","alias":["alias1","alias2"],"regions":[],"coordinates":[55.5,25.25],"province":"Province","timezone":"Asia/Dubai","unlocs":["BBB"],"code":"2"}
+`,
+		},
+		{
+			name:   "Given stored Ports When exporting to csv Then one Port per row is expected",
+			format: FormatCSV,
+			expected: `key,name,city,province,country,timezone,code,longitude,latitude,alias,regions,unlocs
+AAA,Name A,"City, A",,Country,,1,,,,,AAA
+BBB,Name <B>,City B,Province,Country,Asia/Dubai,2,55.5,25.25,alias1|alias2,,BBB
+`,
+		},
+		{
+			name:   "Given stored Ports When exporting to geojson Then a FeatureCollection is expected",
+			format: FormatGeoJSON,
+			expected: `{"type":"FeatureCollection","features":[
+{"type":"Feature","id":"AAA","geometry":null,"properties":{"key":"AAA","name":"Name A","city":"City, A","country":"Country","alias":[],"regions":[],"coordinates":[],"province":"","timezone":"","unlocs":["AAA"],"code":"1"}},
+{"type":"Feature","id":"BBB","geometry":{"type":"Point","coordinates":[55.5,25.25]},"properties":{"key":"BBB","name":"Name <B>","city":"City B","country":"Country","alias":["alias1","alias2"],"regions":[],"coordinates":[55.5,25.25],"province":"Province","timezone":"Asia/Dubai","unlocs":["BBB"],"code":"2"}}
+]}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var output bytes.Buffer
+
+			total, err := Export(context.Background(), newRepository(t), tt.format, &output)
+			assert.NoError(t, err, "Error must not be found exporting")
+			assert.Equal(t, 2, total, "Every Port must be exported")
+			assert.Equal(t, tt.expected, output.String())
+		})
+	}
+}
+
+func TestExportEmptyRepository(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []string{FormatJSON, FormatNDJSON, FormatCSV, FormatGeoJSON} {
+		format := format
+		t.Run("Given no Ports When exporting to "+format+" Then a valid empty output is expected", func(t *testing.T) {
+			t.Parallel()
+
+			var output bytes.Buffer
+
+			total, err := Export(context.Background(), memory.NewPortRepository(), format, &output)
+			assert.NoError(t, err, "Error must not be found exporting")
+			assert.Equal(t, 0, total, "No Port must be exported")
+
+			if format == FormatJSON || format == FormatGeoJSON {
+				assert.True(t, json.Valid(output.Bytes()), "Output must be a valid JSON")
+			}
+		})
+	}
+}
+
+func TestExportUnknownFormat(t *testing.T) {
+	t.Parallel()
+	t.Run("Given an unknown format When exporting Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Export(context.Background(), memory.NewPortRepository(), "xml", &bytes.Buffer{})
+		assert.Error(t, err)
+	})
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	t.Parallel()
+	t.Run("Given a json export When importing it Then the same Ports are expected", func(t *testing.T) {
+		t.Parallel()
+
+		var output bytes.Buffer
+
+		_, err := Export(context.Background(), newRepository(t), FormatJSON, &output)
+		require.NoError(t, err, "Error must not be found exporting")
+
+		stream := jsonstream.NewPortStream()
+		go stream.Start(&output)
+
+		var keys []string
+
+		for entry := range stream.Watch() {
+			assert.NoError(t, entry.Error, "Error must not be found importing the export")
+			keys = append(keys, entry.Key)
+		}
+
+		assert.Equal(t, []string{"AAA", "BBB"}, keys)
+	})
+}
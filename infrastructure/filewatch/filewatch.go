@@ -0,0 +1,76 @@
+// Package filewatch runs jobs when a file is written or replaced.
+package filewatch
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+	"github.com/fsnotify/fsnotify"
+)
+
+var logger = logging.For("filewatch")
+
+// Watch runs the job when the watch starts and each time the file at path is written or
+// replaced, until ctx is done. The job only runs once the file had no changes for the debounce
+// duration, so the many writes of an editor trigger a single run. Runs never overlap: the
+// changes made while a run is in progress trigger a run after it ends.
+func Watch(ctx context.Context, path string, debounce time.Duration, job func(context.Context)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("Error creating the file watcher. Error: %w", err)
+	}
+	defer watcher.Close()
+
+	// The directory is watched instead of the file, because editors often replace the file by
+	// renaming another one over it, and the watch of a file ends when it is replaced.
+	path = filepath.Clean(path)
+	if err = watcher.Add(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("Error watching directory %s. Error: %w", filepath.Dir(path), err)
+	}
+
+	logger.Info("Watching file", "file", path, "debounce", debounce)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if filepath.Clean(event.Name) != path || !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+
+			logger.Debug("File changed", "file", path, "operation", event.Op.String())
+			reset(timer, debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+
+			logger.Warn("Error watching file", "file", path, "error", err)
+		case <-timer.C:
+			job(ctx)
+		}
+	}
+}
+
+// Restarts the timer, dropping the expiration not received yet.
+func reset(timer *time.Timer, duration time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+
+	timer.Reset(duration)
+}
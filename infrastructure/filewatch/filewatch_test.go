@@ -0,0 +1,97 @@
+package filewatch_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/filewatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const debounce = 50 * time.Millisecond
+
+// Watches the file in the background, retrieving the number of runs of the job.
+func watch(t *testing.T, path string) *int32 {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	runs := new(int32)
+
+	go func() {
+		done <- filewatch.Watch(ctx, path, debounce, func(context.Context) { atomic.AddInt32(runs, 1) })
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(runs) == 1 }, time.Second, 5*time.Millisecond,
+		"The job must run when the watch starts")
+
+	return runs
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given many writes to the file When watching Then the job runs once", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "ports.json")
+		require.NoError(t, os.WriteFile(path, []byte("{}"), 0o600))
+		runs := watch(t, path)
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, os.WriteFile(path, []byte("{ }"), 0o600))
+		}
+
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(runs) == 2 }, time.Second, 5*time.Millisecond)
+		time.Sleep(2 * debounce)
+		assert.Equal(t, int32(2), atomic.LoadInt32(runs))
+	})
+
+	t.Run("Given the file replaced by a rename When watching Then the job runs", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "ports.json")
+		require.NoError(t, os.WriteFile(path, []byte("{}"), 0o600))
+		runs := watch(t, path)
+
+		temporary := filepath.Join(dir, "ports.json.tmp")
+		require.NoError(t, os.WriteFile(temporary, []byte("{ }"), 0o600))
+		require.NoError(t, os.Rename(temporary, path))
+
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(runs) == 2 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("Given another file of the directory written When watching Then the job does not run", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "ports.json")
+		require.NoError(t, os.WriteFile(path, []byte("{}"), 0o600))
+		runs := watch(t, path)
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "other.json"), []byte("{}"), 0o600))
+
+		time.Sleep(3 * debounce)
+		assert.Equal(t, int32(1), atomic.LoadInt32(runs))
+	})
+
+	t.Run("Given a missing directory When watching Then an error is retrieved", func(t *testing.T) {
+		t.Parallel()
+
+		err := filewatch.Watch(context.Background(), filepath.Join(t.TempDir(), "missing", "ports.json"), debounce,
+			func(context.Context) {})
+
+		assert.ErrorContains(t, err, "Error watching directory")
+	})
+}
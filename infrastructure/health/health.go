@@ -0,0 +1,128 @@
+// Package health serves the liveness and readiness of the long-running commands.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Paths of the liveness and readiness endpoints.
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// Time given to each check of the readiness.
+const checkTimeout = 5 * time.Second
+
+// ErrShuttingDown is retrieved by the readiness when the application is stopping.
+var ErrShuttingDown = errors.New("Shutting down")
+
+// Check retrieves an error when the dependency it checks is not ready.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Health has the checks of the readiness. It is safe for concurrent use.
+type Health struct {
+	mutex        sync.RWMutex
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+// Status is the body of the liveness and readiness responses. Checks has the result of
+// each check, "ok" or the error.
+type Status struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Retrieves a new Health without checks.
+func New() *Health {
+	return &Health{}
+}
+
+// AddCheck adds a check to the readiness. The checks run in the order they were added.
+func (h *Health) AddCheck(name string, check Check) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// ShutDown makes the readiness fail from now on, so no new work is sent to the application.
+func (h *Health) ShutDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Ready runs every check and retrieves the result of each of them. It retrieves false when
+// any check fails or when the application is shutting down.
+func (h *Health) Ready(ctx context.Context) (Status, bool) {
+	h.mutex.RLock()
+	checks := append([]namedCheck{}, h.checks...)
+	h.mutex.RUnlock()
+
+	status := Status{Status: "ok", Checks: map[string]string{}}
+	ready := true
+
+	if h.shuttingDown.Load() {
+		status.Checks["shutdown"] = ErrShuttingDown.Error()
+		ready = false
+	}
+
+	for _, namedCheck := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := namedCheck.check(checkCtx)
+		cancel()
+
+		if err != nil {
+			status.Checks[namedCheck.name] = err.Error()
+			ready = false
+
+			continue
+		}
+
+		status.Checks[namedCheck.name] = "ok"
+	}
+
+	if !ready {
+		status.Status = "unavailable"
+	}
+
+	return status, ready
+}
+
+// Register adds the liveness and readiness endpoints to the mux:
+//   - GET /healthz: 200 while the process is able to answer.
+//   - GET /readyz: 200 when every check succeeds and the application is not shutting down,
+//     503 otherwise. The body has the result of each check.
+func (h *Health) Register(mux *http.ServeMux) {
+	mux.HandleFunc(LivenessPath, func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, http.StatusOK, Status{Status: "ok"})
+	})
+
+	mux.HandleFunc(ReadinessPath, func(w http.ResponseWriter, r *http.Request) {
+		status, ready := h.Ready(r.Context())
+		if !ready {
+			writeStatus(w, http.StatusServiceUnavailable, status)
+
+			return
+		}
+
+		writeStatus(w, http.StatusOK, status)
+	})
+}
+
+func writeStatus(w http.ResponseWriter, code int, status Status) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func request(t *testing.T, health *Health, path string) (int, Status) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	health.Register(mux)
+
+	response := httptest.NewRecorder()
+	mux.ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var status Status
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&status), "The body must be JSON")
+
+	return response.Code, status
+}
+
+func TestLiveness(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a failing check When requesting the liveness Then 200 is expected", func(t *testing.T) {
+		t.Parallel()
+
+		health := New()
+		health.AddCheck("database", func(ctx context.Context) error { return errors.New("Error pinging database") })
+
+		code, status := request(t, health, LivenessPath)
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok", status.Status)
+	})
+}
+
+func TestReadiness(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given succeeding checks When requesting the readiness Then 200 is expected", func(t *testing.T) {
+		t.Parallel()
+
+		health := New()
+		health.AddCheck("database", func(ctx context.Context) error { return nil })
+		health.AddCheck("migrations", func(ctx context.Context) error { return nil })
+
+		code, status := request(t, health, ReadinessPath)
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, Status{Status: "ok", Checks: map[string]string{"database": "ok", "migrations": "ok"}}, status)
+	})
+
+	t.Run("Given a failing check When requesting the readiness Then 503 and the error are expected", func(t *testing.T) {
+		t.Parallel()
+
+		health := New()
+		health.AddCheck("database", func(ctx context.Context) error { return nil })
+		health.AddCheck("migrations", func(ctx context.Context) error { return errors.New("Pending migrations") })
+
+		code, status := request(t, health, ReadinessPath)
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, Status{Status: "unavailable",
+			Checks: map[string]string{"database": "ok", "migrations": "Pending migrations"}}, status)
+	})
+
+	t.Run("Given the application is shutting down When requesting the readiness Then 503 is expected", func(t *testing.T) {
+		t.Parallel()
+
+		health := New()
+		health.AddCheck("database", func(ctx context.Context) error { return nil })
+		health.ShutDown()
+
+		code, status := request(t, health, ReadinessPath)
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, ErrShuttingDown.Error(), status.Checks["shutdown"])
+	})
+}
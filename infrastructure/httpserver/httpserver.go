@@ -0,0 +1,95 @@
+// Package httpserver serves the stored Ports through HTTP.
+package httpserver
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/infrastructure/export"
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+	"github.com/cassiuspaim/portimporter/infrastructure/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	logger = logging.For("httpserver")
+	tracer = tracing.For("httpserver")
+)
+
+// NewHandler retrieves the handler with the routes:
+//   - GET /ports: every Port as NDJSON ordered by key.
+//   - GET /ports/{key}: the Port identified by key as JSON.
+func NewHandler(portRepository domain.PortRepository) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/ports", traced("GET /ports", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+
+		if _, err := export.Export(r.Context(), portRepository, export.FormatNDJSON, w); err != nil {
+			logger.Error("Error listing ports", "error", err)
+		}
+	}))
+
+	mux.HandleFunc("/ports/", traced("GET /ports/{key}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		key := strings.TrimPrefix(r.URL.Path, "/ports/")
+
+		port, err := portRepository.GetByID(r.Context(), key)
+		if err != nil {
+			logger.Error("Error querying port", logging.KeyPortKey, key, "error", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		if port == nil {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		var body bytes.Buffer
+
+		// A single Port written as NDJSON is the JSON of the Port followed by a new line.
+		writer, _ := export.NewWriter(export.FormatNDJSON, &body)
+		if err = writer.Write(*port); err != nil {
+			logger.Error("Error encoding port", logging.KeyPortKey, key, "error", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body.Bytes())
+	}))
+
+	return mux
+}
+
+// Retrieves the handler wrapped by a span named name, which continues the trace propagated by
+// the headers of the request, if any.
+func traced(name string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		handler(w, r.WithContext(ctx))
+	}
+}
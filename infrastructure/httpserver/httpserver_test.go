@@ -0,0 +1,74 @@
+package httpserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	portRepository := memory.NewPortRepository()
+	require.NoError(t, portRepository.Create(context.Background(), entities.Port{ID: "BBB", Name: "Port B"}))
+	require.NoError(t, portRepository.Create(context.Background(), entities.Port{ID: "AAA", Name: "Port A"}))
+
+	server := httptest.NewServer(NewHandler(portRepository))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	var body []byte
+	body, err = io.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	return response.StatusCode, string(body)
+}
+
+func TestPorts(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given stored Ports When listing the Ports Then every Port is expected ordered by key", func(t *testing.T) {
+		t.Parallel()
+
+		status, body := get(t, newTestServer(t).URL+"/ports")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t,
+			`{"key":"AAA","name":"Port A","city":"","country":"","alias":[],"regions":[],"coordinates":[],"province":"","timezone":"","unlocs":[],"code":""}`+"\n"+
+				`{"key":"BBB","name":"Port B","city":"","country":"","alias":[],"regions":[],"coordinates":[],"province":"","timezone":"","unlocs":[],"code":""}`+"\n",
+			body)
+	})
+
+	t.Run("Given a stored Port When querying it by key Then the Port is expected", func(t *testing.T) {
+		t.Parallel()
+
+		status, body := get(t, newTestServer(t).URL+"/ports/AAA")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `"name":"Port A"`)
+	})
+
+	t.Run("Given a non existing Port When querying it by key Then not found is expected", func(t *testing.T) {
+		t.Parallel()
+
+		status, _ := get(t, newTestServer(t).URL+"/ports/CCC")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
@@ -0,0 +1,219 @@
+// Package iso3166 enriches the imported Ports with the ISO 3166-1 code of their country and the
+// ISO 3166-2 code of their province, using datasets embedded at the binary, so it works offline.
+// The datasets at the data folder are generated from the iso-codes package: countries.tsv has the
+// code, the name and the other names of each country, and subdivisions.tsv has the code, the name
+// and the type of each subdivision.
+package iso3166
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/normalize"
+)
+
+// Kinds of the flags of the Ports.
+const (
+	// KindCountryMismatch is a country whose code is not the prefix of the key.
+	KindCountryMismatch = "country-mismatch"
+	// KindUnknownCountry is a country, or a key prefix when there is no country, not found at ISO 3166-1.
+	KindUnknownCountry = "unknown-country"
+)
+
+//go:embed data/countries.tsv
+var countriesTSV string
+
+//go:embed data/subdivisions.tsv
+var subdivisionsTSV string
+
+// Country is a country of ISO 3166-1, including the withdrawn ones whose code was not reassigned.
+type Country struct {
+	// Code is the alpha-2 code, e.g. "AE".
+	Code string
+	Name string
+}
+
+// Subdivision is a subdivision of a country at ISO 3166-2.
+type Subdivision struct {
+	// Code is the code prefixed by the country code, e.g. "AE-DU".
+	Code string
+	Name string
+	// Type is the kind of subdivision at the country, e.g. "Emirate".
+	Type string
+}
+
+type dataset struct {
+	countries       map[string]Country
+	countriesByName map[string]Country
+	// subdivisions by country code and by name.
+	subdivisions map[string]map[string]Subdivision
+}
+
+// The datasets are parsed the first time they are used.
+var load = sync.OnceValue(func() dataset {
+	data := dataset{
+		countries:       map[string]Country{},
+		countriesByName: map[string]Country{},
+		subdivisions:    map[string]map[string]Subdivision{},
+	}
+
+	for _, columns := range rows(countriesTSV) {
+		country := Country{Code: columns[0], Name: columns[1]}
+		data.countries[country.Code] = country
+
+		names := []string{country.Name}
+		if len(columns) > 2 {
+			names = append(names, strings.Split(columns[2], "|")...)
+		}
+
+		for _, name := range names {
+			data.countriesByName[matchKey(name)] = country
+		}
+	}
+
+	for _, columns := range rows(subdivisionsTSV) {
+		subdivision := Subdivision{Code: columns[0], Name: columns[1], Type: columns[2]}
+		countryCode, _, _ := strings.Cut(subdivision.Code, "-")
+
+		if data.subdivisions[countryCode] == nil {
+			data.subdivisions[countryCode] = map[string]Subdivision{}
+		}
+
+		// A name repeated at several levels of the country keeps its first subdivision.
+		if _, ok := data.subdivisions[countryCode][matchKey(subdivision.Name)]; !ok {
+			data.subdivisions[countryCode][matchKey(subdivision.Name)] = subdivision
+		}
+	}
+
+	return data
+})
+
+// Retrieves the columns of each row of the TSV content, skipping the comments.
+func rows(content string) [][]string {
+	var rows [][]string
+
+	for _, line := range strings.Split(content, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rows = append(rows, strings.Split(line, "\t"))
+	}
+
+	return rows
+}
+
+// Retrieves the name as compared with the names of the datasets: folded to ASCII, in lower case and
+// with punctuation replaced by spaces, e.g. "cote d ivoire" for "Côte d’Ivoire".
+func matchKey(name string) string {
+	key := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+
+		return ' '
+	}, normalize.FoldASCII(name))
+
+	return strings.Join(strings.Fields(key), " ")
+}
+
+// Retrieves the names of a text, which may have an alternative name between brackets, e.g. "Dubayy"
+// and "Dubai" for "Dubayy [Dubai]".
+func names(text string) []string {
+	name, alternative, found := strings.Cut(text, "[")
+	if !found {
+		return []string{text}
+	}
+
+	return []string{name, strings.TrimSuffix(strings.TrimSpace(alternative), "]")}
+}
+
+// CountryByCode retrieves the country of the alpha-2 code, in upper case.
+func CountryByCode(code string) (Country, bool) {
+	country, ok := load().countries[code]
+
+	return country, ok
+}
+
+// CountryByName retrieves the country of the name, whatever its case and diacritics. The name may be
+// the short name, the official one, a common one or a former one.
+func CountryByName(name string) (Country, bool) {
+	for _, name := range names(name) {
+		if country, ok := load().countriesByName[matchKey(name)]; ok {
+			return country, true
+		}
+	}
+
+	return Country{}, false
+}
+
+// SubdivisionByName retrieves the subdivision of the country with the name, whatever its case and
+// diacritics.
+func SubdivisionByName(countryCode string, name string) (Subdivision, bool) {
+	for _, name := range names(name) {
+		if subdivision, ok := load().subdivisions[countryCode][matchKey(name)]; ok {
+			return subdivision, true
+		}
+	}
+
+	return Subdivision{}, false
+}
+
+// Enricher is the domain.Enricher that sets the CountryCode and the SubdivisionCode of the Ports, and
+// flags the countries that do not match the prefix of their keys.
+type Enricher struct{}
+
+// Retrieves a new Enricher.
+func NewEnricher() Enricher {
+	return Enricher{}
+}
+
+// Enrich retrieves the Port with the code of its country, taken from the prefix of the key when
+// the country is empty, unknown or does not match the key, and the code of its province when it is
+// a subdivision of the country. So the checks made with the code of the country, e.g. of the
+// coordinates, follow the key, which identifies the port, and never a mismatched name.
+func (e Enricher) Enrich(port entities.Port) (entities.Port, []entities.Flag) {
+	var flags []entities.Flag
+
+	prefix := port.ID
+	if len(prefix) > 2 {
+		prefix = prefix[:2]
+	}
+
+	prefix = strings.ToUpper(prefix)
+	keyCountry, keyKnown := CountryByCode(prefix)
+	country, known := CountryByName(port.Country)
+
+	switch {
+	case known && country.Code != prefix:
+		flags = append(flags, entities.Flag{Field: "country", Kind: KindCountryMismatch,
+			Detail: fmt.Sprintf("Country %s has code %s but the key starts with %s", port.Country, country.Code, prefix)})
+		country, known = keyCountry, keyKnown
+	case known:
+	case port.Country != "":
+		flags = append(flags, entities.Flag{Field: "country", Kind: KindUnknownCountry,
+			Detail: fmt.Sprintf("Country %s is not found at ISO 3166-1", port.Country)})
+		country, known = keyCountry, keyKnown
+	case !keyKnown:
+		flags = append(flags, entities.Flag{Field: "country", Kind: KindUnknownCountry,
+			Detail: fmt.Sprintf("Key prefix %s is not found at ISO 3166-1", prefix)})
+	default:
+		country, known = keyCountry, keyKnown
+	}
+
+	port.CountryCode, port.SubdivisionCode = "", ""
+
+	if known {
+		port.CountryCode = country.Code
+	}
+
+	if subdivision, ok := SubdivisionByName(port.CountryCode, port.Province); ok {
+		port.SubdivisionCode = subdivision.Code
+	}
+
+	return port, flags
+}
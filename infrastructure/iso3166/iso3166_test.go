@@ -0,0 +1,136 @@
+package iso3166
+
+import (
+	"testing"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountryByName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		country  string
+		expected string
+	}{
+		{name: "Given the ISO name", country: "United Arab Emirates", expected: "AE"},
+		{name: "Given a common name", country: "South Korea", expected: "KR"},
+		{name: "Given a former name", country: "Cape Verde", expected: "CV"},
+		{name: "Given the name of a withdrawn code", country: "Netherlands Antilles", expected: "AN"},
+		{name: "Given other case, diacritics and punctuation", country: "COTE D’IVOIRE", expected: "CI"},
+		{name: "Given an alternative name between brackets", country: "Holland [Netherlands]", expected: "NL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" When querying the country Then its code is expected", func(t *testing.T) {
+			t.Parallel()
+
+			country, ok := CountryByName(tt.country)
+
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, country.Code)
+		})
+	}
+
+	t.Run("Given an unknown name When querying the country Then no country is expected", func(t *testing.T) {
+		t.Parallel()
+
+		_, ok := CountryByName("Atlantis")
+		assert.False(t, ok)
+	})
+}
+
+func TestSubdivisionByName(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given names of subdivisions When querying the subdivision Then its code is expected", func(t *testing.T) {
+		t.Parallel()
+
+		subdivision, ok := SubdivisionByName("AE", "Abu Z̧aby [Abu Dhabi]")
+		assert.True(t, ok)
+		assert.Equal(t, Subdivision{Code: "AE-AZ", Name: "Abū Z̧aby", Type: "Emirate"}, subdivision)
+
+		subdivision, ok = SubdivisionByName("AE", "Ajman")
+		assert.True(t, ok)
+		assert.Equal(t, "AE-AJ", subdivision.Code, "Punctuation and diacritics must be ignored")
+	})
+
+	t.Run("Given a subdivision of another country When querying the subdivision Then no subdivision is expected", func(t *testing.T) {
+		t.Parallel()
+
+		_, ok := SubdivisionByName("BR", "Ajman")
+		assert.False(t, ok)
+	})
+}
+
+func TestEnrich(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		port     entities.Port
+		expected entities.Port
+		kinds    []string
+	}{
+		{
+			name: "Given a country matching the key Then the codes are expected",
+			port: entities.Port{ID: "AEDXB", Country: "United Arab Emirates", Province: "Dubayy [Dubai]"},
+			expected: entities.Port{ID: "AEDXB", Country: "United Arab Emirates", Province: "Dubayy [Dubai]",
+				CountryCode: "AE", SubdivisionCode: "AE-DU"},
+		},
+		{
+			name:     "Given a province that is not a subdivision Then only the country code is expected",
+			port:     entities.Port{ID: "ARRPA", Country: "Argentina", Province: "Rio Parana"},
+			expected: entities.Port{ID: "ARRPA", Country: "Argentina", Province: "Rio Parana", CountryCode: "AR"},
+		},
+		{
+			name:     "Given a country not matching the key Then the code of the key and a mismatch are expected",
+			port:     entities.Port{ID: "BRRIG", Country: "Argentina", Province: "Buenos Aires"},
+			expected: entities.Port{ID: "BRRIG", Country: "Argentina", Province: "Buenos Aires", CountryCode: "BR"},
+			kinds:    []string{KindCountryMismatch},
+		},
+		{
+			name:     "Given a country not matching a withdrawn key prefix Then the withdrawn code and a mismatch are expected",
+			port:     entities.Port{ID: "ANEUX", Country: "Netherlands"},
+			expected: entities.Port{ID: "ANEUX", Country: "Netherlands", CountryCode: "AN"},
+			kinds:    []string{KindCountryMismatch},
+		},
+		{
+			name:     "Given an unknown country Then the code of the key and an unknown country are expected",
+			port:     entities.Port{ID: "BRSSZ", Country: "Brasil do Sul"},
+			expected: entities.Port{ID: "BRSSZ", Country: "Brasil do Sul", CountryCode: "BR"},
+			kinds:    []string{KindUnknownCountry},
+		},
+		{
+			name:     "Given no country Then the code of the key is expected",
+			port:     entities.Port{ID: "aeajm", Province: "Ajman"},
+			expected: entities.Port{ID: "aeajm", Province: "Ajman", CountryCode: "AE", SubdivisionCode: "AE-AJ"},
+		},
+		{
+			name:     "Given no country and an unknown key prefix Then an unknown country is expected",
+			port:     entities.Port{ID: "ZZAAA", CountryCode: "ZZ"},
+			expected: entities.Port{ID: "ZZAAA"},
+			kinds:    []string{KindUnknownCountry},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" When enriching", func(t *testing.T) {
+			t.Parallel()
+
+			port, flags := NewEnricher().Enrich(tt.port)
+
+			assert.Equal(t, tt.expected, port)
+
+			var kinds []string
+			for _, flag := range flags {
+				assert.Equal(t, "country", flag.Field)
+				kinds = append(kinds, flag.Kind)
+			}
+
+			assert.Equal(t, tt.kinds, kinds)
+		})
+	}
+}
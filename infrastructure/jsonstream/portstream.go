@@ -2,11 +2,16 @@ package jsonstream
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+	"github.com/cassiuspaim/portimporter/infrastructure/metrics"
 )
 
+var logger = logging.For("jsonstream")
+
 // Structure used to stream the Port data.
 type PortStream struct {
 	Name        string    `json:"name"`
@@ -22,24 +27,57 @@ type PortStream struct {
 }
 
 // Entry represents each stream. If the stream fails, an error will be present.
+// Offset is the position, in bytes, where the port ends at the input.
 type Entry struct {
-	Key   string
-	Error error
-	Data  PortStream
+	Key    string
+	Error  error
+	Data   PortStream
+	Offset int64
+	// DuplicateOf is the offset of the first entry with the same key at the input, 0 when the
+	// key was not read before.
+	DuplicateOf int64
+	// Ignored is true for the entries that must not be imported, the repeated keys when the
+	// first entry wins.
+	Ignored bool
 }
 
+// DuplicatePolicy tells what the Stream does with the entries whose key was read before.
+type DuplicatePolicy string
+
+// Policies of the repeated keys.
+const (
+	// DuplicateLastWins sends the repeated entries, so the last one is imported over the first.
+	DuplicateLastWins DuplicatePolicy = "last-wins"
+	// DuplicateFirstWins sends the repeated entries as ignored.
+	DuplicateFirstWins DuplicatePolicy = "first-wins"
+	// DuplicateFail sends the repeated entries with an error wrapping ErrDuplicateKey.
+	DuplicateFail DuplicatePolicy = "fail"
+)
+
+// ErrDuplicateKey is wrapped by the errors of the entries whose key was read before.
+var ErrDuplicateKey = errors.New("duplicate key")
+
 // Stream helps transmit each streams within a channel.
 type Stream struct {
-	stream chan Entry
+	stream     chan Entry
+	duplicates DuplicatePolicy
 }
 
-// NewPortStream returns a new `Stream` type.
+// NewPortStream returns a new `Stream` type, whose repeated keys follow DuplicateLastWins.
 func NewPortStream() Stream {
 	return Stream{
-		stream: make(chan Entry),
+		stream:     make(chan Entry),
+		duplicates: DuplicateLastWins,
 	}
 }
 
+// WithDuplicates retrieves a copy of the Stream whose repeated keys follow the policy.
+func (s Stream) WithDuplicates(policy DuplicatePolicy) Stream {
+	s.duplicates = policy
+
+	return s
+}
+
 // Watch watches JSON streams. Each stream entry will either have an error or a
 // PortSteam object. Client code does not need to explicitly exit after catching an
 // error as the `Start` method will close the channel automatically.
@@ -47,10 +85,41 @@ func (s Stream) Watch() <-chan Entry {
 	return s.stream
 }
 
+// Sends the entry, counting it as decoded or failed.
+func (s Stream) send(entry Entry) {
+	if entry.Error != nil {
+		metrics.EntriesFailed.Inc()
+	} else {
+		metrics.EntriesDecoded.Inc()
+	}
+
+	s.stream <- entry
+}
+
+// Sends the entry whose key was read before following the policy of the repeated keys.
+func (s Stream) sendDuplicate(entry Entry) {
+	logger.Warn("Duplicate key", logging.KeyPortKey, entry.Key, logging.KeyOffset, entry.Offset,
+		"first_offset", entry.DuplicateOf, "policy", s.duplicates)
+
+	metrics.DuplicateKeys.Inc()
+
+	switch s.duplicates {
+	case DuplicateFirstWins:
+		entry.Ignored = true
+	case DuplicateFail:
+		entry.Error = fmt.Errorf("Error decoding port. Key %s at offset %d was first read at offset %d. Error: %w",
+			entry.Key, entry.Offset, entry.DuplicateOf, ErrDuplicateKey)
+		entry.Data = PortStream{}
+	case DuplicateLastWins:
+	}
+
+	s.send(entry)
+}
+
 // Start starts streaming JSON file line by line. If an error occurs, the channel
 // will be closed.
 func (s Stream) Start(file io.Reader) {
-	log.Println("Start Port Stream")
+	logger.Debug("Start Port Stream")
 
 	// Stop streaming channel as soon as nothing left to read in the file.
 	defer close(s.stream)
@@ -62,35 +131,38 @@ func (s Stream) Start(file io.Reader) {
 	if err != nil {
 		// #todo replace errors by struct in order to help the asserts at tests.
 		errorMessage := fmt.Errorf("Error decoding opening delimiter: %w", err)
-		log.Println(errorMessage)
-		s.stream <- Entry{Error: errorMessage}
+		logger.Error(errorMessage.Error())
+		s.send(Entry{Error: errorMessage})
 
 		return
 	}
 
 	if openingDelimiter != json.Delim('{') {
 		errorMessage := fmt.Errorf("Opening delimiter is wrong. Expected { - Found %v", openingDelimiter)
-		log.Println(errorMessage)
-		s.stream <- Entry{
+		logger.Error(errorMessage.Error())
+		s.send(Entry{
 			Error: errorMessage,
-		}
+		})
 
 		return
 	}
 
-	log.Printf("Opening delimiter read %v.\n", openingDelimiter)
+	logger.Debug("Opening delimiter read")
 
 	// Read file content as long as there is something.
 	line := 1
 
+	// Offsets of the keys read, only the keys are kept so the memory does not grow with the ports.
+	seen := map[string]int64{}
+
 	for decoder.More() {
 		// Reading key
 		token, err := decoder.Token()
 
 		if err != nil {
 			errorMessage := fmt.Errorf("Error decoding key. Line %d - Error: %w", line, err)
-			log.Println(errorMessage)
-			s.stream <- Entry{Error: errorMessage}
+			logger.Error(errorMessage.Error(), logging.KeyOffset, decoder.InputOffset())
+			s.send(Entry{Error: errorMessage, Offset: decoder.InputOffset()})
 
 			return
 		}
@@ -98,26 +170,36 @@ func (s Stream) Start(file io.Reader) {
 		key, ok := token.(string)
 		if !ok {
 			errorMessage := fmt.Errorf("Error type asserting the key. Line %d - Error: %w", line, err)
-			log.Println(errorMessage)
-			s.stream <- Entry{Error: errorMessage}
+			logger.Error(errorMessage.Error(), logging.KeyOffset, decoder.InputOffset())
+			s.send(Entry{Error: errorMessage, Offset: decoder.InputOffset()})
 		}
 
-		log.Printf("Key %s decoded.\n", key)
+		logger.Debug("Key decoded", logging.KeyPortKey, key, logging.KeyOffset, decoder.InputOffset())
 
 		// Reading port
 		var port PortStream
 		if err := decoder.Decode(&port); err != nil {
 			errorMessage := fmt.Errorf("Error decoding port. Key %v - Line %d - Error: %w", key, line, err)
-			log.Println(errorMessage)
-			s.stream <- Entry{
-				Key:   key,
-				Error: errorMessage}
+			logger.Error(errorMessage.Error(), logging.KeyPortKey, key, logging.KeyOffset, decoder.InputOffset())
+			s.send(Entry{
+				Key:    key,
+				Error:  errorMessage,
+				Offset: decoder.InputOffset()})
+		} else if first, ok := seen[key]; ok {
+			s.sendDuplicate(Entry{
+				Key:         key,
+				Data:        port,
+				Offset:      decoder.InputOffset(),
+				DuplicateOf: first,
+			})
 		} else {
-			s.stream <- Entry{
-				Key:  key,
-				Data: port,
-			}
-			log.Printf("Port ID'd by %s decoded.\n", key)
+			seen[key] = decoder.InputOffset()
+			s.send(Entry{
+				Key:    key,
+				Data:   port,
+				Offset: decoder.InputOffset(),
+			})
+			logger.Debug("Port decoded", logging.KeyPortKey, key, logging.KeyOffset, decoder.InputOffset())
 		}
 
 		line++
@@ -127,11 +209,11 @@ func (s Stream) Start(file io.Reader) {
 	closingDelimiter, err := decoder.Token()
 	if err != nil {
 		errorMessage := fmt.Errorf("Error decoding closing delimiter: %w", err)
-		log.Println(errorMessage)
-		s.stream <- Entry{Error: errorMessage}
+		logger.Error(errorMessage.Error(), logging.KeyOffset, decoder.InputOffset())
+		s.send(Entry{Error: errorMessage})
 
 		return
 	}
 
-	log.Printf("Closing delimiter read %v.\n", closingDelimiter)
+	logger.Debug("Closing delimiter read", "delimiter", closingDelimiter)
 }
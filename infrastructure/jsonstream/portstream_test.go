@@ -129,6 +129,59 @@ func TestStartWithInvalidContentFile(t *testing.T) {
 	}
 }
 
+func TestStartWithDuplicateKeys(t *testing.T) {
+	t.Parallel()
+
+	content := `{"AEAJM": {"name": "First"}, "AEAUH": {"name": "Other"}, "AEAJM": {"name": "Last"}}`
+
+	read := func(policy DuplicatePolicy) []Entry {
+		stream := NewPortStream().WithDuplicates(policy)
+		go stream.Start(strings.NewReader(content))
+
+		var entries []Entry
+		for entry := range stream.Watch() {
+			entries = append(entries, entry)
+		}
+
+		return entries
+	}
+
+	t.Run("Given a repeated key and last-wins When reading the file Then both entries must be sent", func(t *testing.T) {
+		t.Parallel()
+
+		entries := read(DuplicateLastWins)
+
+		assert.Len(t, entries, 3)
+		assert.Equal(t, "Last", entries[2].Data.Name)
+		assert.Equal(t, entries[0].Offset, entries[2].DuplicateOf, "The offset of the first entry must be kept")
+		assert.Zero(t, entries[0].DuplicateOf)
+		assert.False(t, entries[2].Ignored)
+	})
+
+	t.Run("Given a repeated key and first-wins When reading the file Then the repeated entry must be ignored", func(t *testing.T) {
+		t.Parallel()
+
+		entries := read(DuplicateFirstWins)
+
+		assert.Len(t, entries, 3)
+		assert.True(t, entries[2].Ignored)
+		assert.NoError(t, entries[2].Error)
+		assert.Equal(t, entries[0].Offset, entries[2].DuplicateOf)
+	})
+
+	t.Run("Given a repeated key and fail When reading the file Then the repeated entry must fail with both offsets", func(t *testing.T) {
+		t.Parallel()
+
+		entries := read(DuplicateFail)
+
+		assert.Len(t, entries, 3)
+		assert.ErrorIs(t, entries[2].Error, ErrDuplicateKey)
+		assert.ErrorContains(t, entries[2].Error, fmt.Sprintf("Key AEAJM at offset %d was first read at offset %d",
+			entries[2].Offset, entries[0].Offset))
+		assert.NoError(t, entries[1].Error, "Keys not repeated must not fail")
+	})
+}
+
 func getJSONPort(name string, city string, country string, alias []string, regions []string,
 	coordinates []float64, province string, timezone string, unlocs []string, code string) string {
 
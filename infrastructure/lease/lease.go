@@ -0,0 +1,172 @@
+// Package lease grants a named lock to a single owner for a limited time, so only one instance
+// of the application runs an operation at a time. The owner renews the lease while it holds it,
+// and the lease of an instance that stopped without releasing it expires.
+package lease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+)
+
+var logger = logging.For("lease")
+
+// Time given to release the lease, which happens after the operation was canceled.
+const releaseTimeout = 5 * time.Second
+
+// ErrLost is the cause of the cancellation of the context of a Lease that could not be renewed.
+var ErrLost = errors.New("Lease lost")
+
+// Store keeps the leases shared by every instance.
+type Store interface {
+	// Acquire grants the named lease to the owner for ttl when it is free, expired or already
+	// held by the owner, so it also renews the lease. It retrieves a *HeldError when another
+	// owner holds the lease.
+	Acquire(ctx context.Context, name string, owner string, ttl time.Duration) error
+	// Release frees the named lease when the owner holds it.
+	Release(ctx context.Context, name string, owner string) error
+}
+
+// HeldError is retrieved when another owner holds the lease.
+type HeldError struct {
+	Name      string
+	Owner     string
+	ExpiresAt time.Time
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("Lease %s is held by %s until %s", e.Name, e.Owner, e.ExpiresAt.Format(time.RFC3339))
+}
+
+// Options define the lease to acquire.
+type Options struct {
+	Name string
+	// Owner identifies the instance, e.g. by host name and process ID.
+	Owner string
+	// TTL is how long the lease lasts without being renewed. It is renewed every third of it.
+	TTL time.Duration
+	// Wait makes Acquire wait for a lease held by another owner instead of retrieving a
+	// *HeldError.
+	Wait bool
+}
+
+// Lease is a lease held by this instance.
+type Lease struct {
+	store   Store
+	options Options
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	stopped chan struct{}
+}
+
+// Acquire acquires the lease and renews it until Release is called. The context of the Lease is
+// canceled with ErrLost when the lease may not be renewed before it expires, so the operation
+// stops while the lease is still held, before another owner may acquire it.
+func Acquire(ctx context.Context, store Store, options Options) (*Lease, error) {
+	for {
+		err := store.Acquire(ctx, options.Name, options.Owner, options.TTL)
+		if err == nil {
+			break
+		}
+
+		var held *HeldError
+		if !options.Wait || !errors.As(err, &held) {
+			return nil, err
+		}
+
+		logger.Info("Waiting for lease", "lease", options.Name, "owner", held.Owner, "expires_at", held.ExpiresAt)
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("Error waiting for lease %s. Error: %w", options.Name, ctx.Err())
+		case <-time.After(options.TTL / 3):
+		}
+	}
+
+	logger.Info("Lease acquired", "lease", options.Name, "owner", options.Owner, "ttl", options.TTL)
+
+	leaseCtx, cancel := context.WithCancelCause(ctx)
+	lease := &Lease{
+		store:   store,
+		options: options,
+		ctx:     leaseCtx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+
+	go lease.renew(time.Now().Add(options.TTL))
+
+	return lease, nil
+}
+
+// Context retrieves a context canceled when the lease is lost or released.
+func (l *Lease) Context() context.Context {
+	return l.ctx
+}
+
+// Release stops renewing the lease and frees it.
+func (l *Lease) Release() {
+	l.cancel(context.Canceled)
+	<-l.stopped
+
+	if errors.Is(context.Cause(l.ctx), ErrLost) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	if err := l.store.Release(ctx, l.options.Name, l.options.Owner); err != nil {
+		logger.Error("Error releasing lease", "lease", l.options.Name, "error", err)
+
+		return
+	}
+
+	logger.Info("Lease released", "lease", l.options.Name, "owner", l.options.Owner)
+}
+
+// Renews the lease every third of the TTL, until the context of the lease is canceled. A failed
+// renewal is retried at the next one, unless less than a renewal interval is left before the lease
+// expires: the lease is then lost at once, as the next renewal may come too late.
+func (l *Lease) renew(expiresAt time.Time) {
+	defer close(l.stopped)
+
+	interval := l.options.TTL / 3
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+
+		err := l.store.Acquire(l.ctx, l.options.Name, l.options.Owner, l.options.TTL)
+		if err == nil {
+			expiresAt = now.Add(l.options.TTL)
+
+			continue
+		}
+
+		if l.ctx.Err() != nil {
+			return
+		}
+
+		var held *HeldError
+		if errors.As(err, &held) || time.Until(expiresAt) < interval {
+			logger.Error("Lease lost", "lease", l.options.Name, "owner", l.options.Owner, "error", err)
+			l.cancel(fmt.Errorf("%w. Error: %w", ErrLost, err))
+
+			return
+		}
+
+		logger.Warn("Error renewing lease", "lease", l.options.Name, "expires_at", expiresAt, "error", err)
+	}
+}
@@ -0,0 +1,147 @@
+package lease_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/lease"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ttl = 60 * time.Millisecond
+
+func options(owner string) lease.Options {
+	return lease.Options{Name: "import", Owner: owner, TTL: ttl}
+}
+
+// failingStore fails to renew the leases once failing is set.
+type failingStore struct {
+	*lease.MemoryStore
+	failing atomic.Bool
+}
+
+func (s *failingStore) Acquire(ctx context.Context, name string, owner string, ttl time.Duration) error {
+	if s.failing.Load() {
+		return errors.New("connection refused")
+	}
+
+	return s.MemoryStore.Acquire(ctx, name, owner, ttl)
+}
+
+func TestAcquire(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a free lease When acquiring Then it is acquired", func(t *testing.T) {
+		t.Parallel()
+
+		held, err := lease.Acquire(context.Background(), lease.NewMemoryStore(), options("first"))
+		require.NoError(t, err)
+		defer held.Release()
+
+		assert.NoError(t, held.Context().Err(), "The context of the lease must not be canceled")
+	})
+
+	t.Run("Given a lease held by another owner When acquiring Then a HeldError is expected", func(t *testing.T) {
+		t.Parallel()
+
+		store := lease.NewMemoryStore()
+		held, err := lease.Acquire(context.Background(), store, options("first"))
+		require.NoError(t, err)
+		defer held.Release()
+
+		_, err = lease.Acquire(context.Background(), store, options("second"))
+
+		var heldErr *lease.HeldError
+		require.ErrorAs(t, err, &heldErr)
+		assert.Equal(t, "first", heldErr.Owner)
+	})
+
+	t.Run("Given a lease held for longer than its TTL When acquiring Then the renewed lease is still held", func(t *testing.T) {
+		t.Parallel()
+
+		store := lease.NewMemoryStore()
+		held, err := lease.Acquire(context.Background(), store, options("first"))
+		require.NoError(t, err)
+		defer held.Release()
+
+		time.Sleep(2 * ttl)
+
+		_, err = lease.Acquire(context.Background(), store, options("second"))
+		assert.Error(t, err, "The lease must be renewed by its owner")
+	})
+
+	t.Run("Given a released lease When acquiring Then it is acquired", func(t *testing.T) {
+		t.Parallel()
+
+		store := lease.NewMemoryStore()
+		held, err := lease.Acquire(context.Background(), store, options("first"))
+		require.NoError(t, err)
+		held.Release()
+
+		assert.ErrorIs(t, held.Context().Err(), context.Canceled)
+
+		second, err := lease.Acquire(context.Background(), store, options("second"))
+		require.NoError(t, err)
+		second.Release()
+	})
+
+	t.Run("Given wait mode and a lease held by another owner When acquiring Then it waits for the release", func(t *testing.T) {
+		t.Parallel()
+
+		store := lease.NewMemoryStore()
+		held, err := lease.Acquire(context.Background(), store, options("first"))
+		require.NoError(t, err)
+
+		time.AfterFunc(ttl, held.Release)
+
+		waiting := options("second")
+		waiting.Wait = true
+
+		second, err := lease.Acquire(context.Background(), store, waiting)
+		require.NoError(t, err)
+		second.Release()
+	})
+
+	t.Run("Given wait mode and a canceled context When acquiring a held lease Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		store := lease.NewMemoryStore()
+		held, err := lease.Acquire(context.Background(), store, options("first"))
+		require.NoError(t, err)
+		defer held.Release()
+
+		ctx, cancel := context.WithTimeout(context.Background(), ttl)
+		defer cancel()
+
+		waiting := options("second")
+		waiting.Wait = true
+
+		_, err = lease.Acquire(ctx, store, waiting)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Given a lease that can not be renewed When it is about to expire Then its context is canceled before", func(t *testing.T) {
+		t.Parallel()
+
+		store := &failingStore{MemoryStore: lease.NewMemoryStore()}
+		acquiredAt := time.Now()
+		held, err := lease.Acquire(context.Background(), store, options("first"))
+		require.NoError(t, err)
+		defer held.Release()
+
+		store.failing.Store(true)
+
+		select {
+		case <-held.Context().Done():
+		case <-time.After(5 * ttl):
+			require.Fail(t, "The context of the lease must be canceled")
+		}
+
+		assert.ErrorIs(t, context.Cause(held.Context()), lease.ErrLost)
+		assert.Less(t, time.Since(acquiredAt), ttl, "The context must be canceled while the lease is held")
+	})
+}
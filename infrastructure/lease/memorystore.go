@@ -0,0 +1,45 @@
+package lease
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is the implementation of Store that keeps the leases in memory, shared only by
+// the users of the same MemoryStore.
+type MemoryStore struct {
+	mutex  sync.Mutex
+	leases map[string]HeldError
+}
+
+// NewMemoryStore retrieves an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{leases: map[string]HeldError{}}
+}
+
+func (s *MemoryStore) Acquire(_ context.Context, name string, owner string, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+
+	if holder, ok := s.leases[name]; ok && holder.Owner != owner && now.Before(holder.ExpiresAt) {
+		return &holder
+	}
+
+	s.leases[name] = HeldError{Name: name, Owner: owner, ExpiresAt: now.Add(ttl)}
+
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, name string, owner string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if holder, ok := s.leases[name]; ok && holder.Owner == owner {
+		delete(s.leases, name)
+	}
+
+	return nil
+}
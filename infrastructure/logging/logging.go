@@ -0,0 +1,231 @@
+// Package logging configures the structured logs of the application. Every component gets its
+// logger from For, which follows the configuration set by Setup even if the logger was
+// retrieved before it.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Keys of the fields shared by the logs of every component.
+const (
+	KeyRunID     = "run_id"
+	KeyComponent = "component"
+	KeyPortKey   = "port_key"
+	KeyOffset    = "offset"
+)
+
+// Formats of the logs.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Config is the configuration of the logs.
+type Config struct {
+	// Format is text or json.
+	Format string
+	// Level is the minimum level of the logs: debug, info, warn or error.
+	Level string
+	// ComponentLevels overrides Level by component, e.g. "jsonstream=debug".
+	ComponentLevels []string
+	// SampleEvery keeps one of every SampleEvery debug logs with the same message of a component.
+	// Values lower than 2 keep every log.
+	SampleEvery int
+}
+
+// state is the configuration in use, replaced as a whole by Setup.
+type state struct {
+	handler         slog.Handler
+	runID           string
+	level           slog.Level
+	componentLevels map[string]slog.Level
+	sampleEvery     uint64
+	samples         *sync.Map
+}
+
+var current atomic.Pointer[state]
+
+func init() {
+	current.Store(&state{
+		handler:         slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		level:           slog.LevelInfo,
+		componentLevels: map[string]slog.Level{},
+		samples:         &sync.Map{},
+	})
+}
+
+// Setup configures the logs written to output, adds the run ID to every log, unless the context of
+// the log has another one, and makes the configured logger the default one, so the log package
+// also writes structured logs.
+func Setup(config Config, output io.Writer, runID string) error {
+	level, err := ParseLevel(config.Level)
+	if err != nil {
+		return err
+	}
+
+	componentLevels, err := parseComponentLevels(config.ComponentLevels)
+	if err != nil {
+		return err
+	}
+
+	// The levels are filtered by the component handlers, the handler accepts everything.
+	options := &slog.HandlerOptions{Level: slog.LevelDebug}
+
+	var handler slog.Handler
+
+	switch config.Format {
+	case "", FormatText:
+		handler = slog.NewTextHandler(output, options)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(output, options)
+	default:
+		return fmt.Errorf("Unknown log format %s", config.Format)
+	}
+
+	sampleEvery := uint64(1)
+	if config.SampleEvery > 1 {
+		sampleEvery = uint64(config.SampleEvery)
+	}
+
+	current.Store(&state{
+		handler:         handler,
+		runID:           runID,
+		level:           level,
+		componentLevels: componentLevels,
+		sampleEvery:     sampleEvery,
+		samples:         &sync.Map{},
+	})
+
+	slog.SetDefault(For("app"))
+
+	return nil
+}
+
+// For retrieves the logger of the component. Every log has the component field.
+func For(component string) *slog.Logger {
+	return slog.New(&componentHandler{component: component})
+}
+
+type runIDKey struct{}
+
+// WithRunID retrieves a context whose logs have the run ID instead of the one given to Setup, e.g.
+// for each of the imports of a process that imports many times. Only the logs written with the
+// context, e.g. by InfoContext, have it.
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// NewRunID retrieves a random ID to identify the logs of a run.
+func NewRunID() string {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(bytes)
+}
+
+// ParseLevel retrieves the level named debug, info, warn or error. An empty name is info.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, fmt.Errorf("Unknown log level %s", name)
+	}
+
+	return level, nil
+}
+
+func parseComponentLevels(values []string) (map[string]slog.Level, error) {
+	levels := map[string]slog.Level{}
+
+	for _, value := range values {
+		component, name, found := strings.Cut(value, "=")
+		if !found || component == "" {
+			return nil, fmt.Errorf("Component level %q must be component=level", value)
+		}
+
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+
+		levels[component] = level
+	}
+
+	return levels, nil
+}
+
+// componentHandler filters and samples the logs of a component before handing them to the
+// handler of the current state.
+type componentHandler struct {
+	component string
+	// wrappers add the attributes and groups of the logger to the handler, in order.
+	wrappers []func(slog.Handler) slog.Handler
+}
+
+func (h *componentHandler) Enabled(_ context.Context, level slog.Level) bool {
+	state := current.Load()
+
+	minimum, ok := state.componentLevels[h.component]
+	if !ok {
+		minimum = state.level
+	}
+
+	return level >= minimum
+}
+
+func (h *componentHandler) Handle(ctx context.Context, record slog.Record) error {
+	state := current.Load()
+
+	if record.Level == slog.LevelDebug && state.sampleEvery > 1 {
+		counter, _ := state.samples.LoadOrStore(h.component+"\x00"+record.Message, new(atomic.Uint64))
+		if counter.(*atomic.Uint64).Add(1)%state.sampleEvery != 1 {
+			return nil
+		}
+	}
+
+	runID := state.runID
+	if id, ok := ctx.Value(runIDKey{}).(string); ok {
+		runID = id
+	}
+
+	handler := state.handler.WithAttrs([]slog.Attr{slog.String(KeyRunID, runID), slog.String(KeyComponent, h.component)})
+	for _, wrapper := range h.wrappers {
+		handler = wrapper(handler)
+	}
+
+	return handler.Handle(ctx, record)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+func (h *componentHandler) with(wrapper func(slog.Handler) slog.Handler) *componentHandler {
+	wrappers := make([]func(slog.Handler) slog.Handler, 0, len(h.wrappers)+1)
+	wrappers = append(wrappers, h.wrappers...)
+
+	return &componentHandler{
+		component: h.component,
+		wrappers:  append(wrappers, wrapper),
+	}
+}
@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Retrieves the JSON logs written to output.
+func decodeLogs(t *testing.T, output *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var logs []map[string]interface{}
+
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+
+		logs = append(logs, entry)
+	}
+
+	return logs
+}
+
+// The tests are not parallel because Setup changes the configuration of every logger.
+func TestSetup(t *testing.T) {
+	t.Run("Given a logger retrieved before Setup When logging Then the configuration of Setup must be used", func(t *testing.T) {
+		logger := For("jsonstream")
+
+		var output bytes.Buffer
+		require.NoError(t, Setup(Config{Format: FormatJSON}, &output, "run1"))
+
+		logger.Info("Port decoded", KeyPortKey, "AEAJM", KeyOffset, 42)
+
+		logs := decodeLogs(t, &output)
+		require.Len(t, logs, 1)
+		assert.Equal(t, "run1", logs[0][KeyRunID])
+		assert.Equal(t, "jsonstream", logs[0][KeyComponent])
+		assert.Equal(t, "AEAJM", logs[0][KeyPortKey])
+		assert.Equal(t, float64(42), logs[0][KeyOffset])
+	})
+
+	t.Run("Given a context with a run ID When logging Then its run ID must replace the one of Setup", func(t *testing.T) {
+		var output bytes.Buffer
+		require.NoError(t, Setup(Config{Format: FormatJSON}, &output, "process"))
+
+		For("services").InfoContext(WithRunID(context.Background(), "import"), "Port flagged")
+		For("services").InfoContext(context.Background(), "Port flagged")
+
+		logs := decodeLogs(t, &output)
+		require.Len(t, logs, 2)
+		assert.Equal(t, "import", logs[0][KeyRunID])
+		assert.Equal(t, "process", logs[1][KeyRunID])
+	})
+
+	t.Run("Given component levels When logging Then each component must use its level", func(t *testing.T) {
+		var output bytes.Buffer
+		require.NoError(t, Setup(Config{Format: FormatJSON, Level: "warn", ComponentLevels: []string{"mongodb=debug"}},
+			&output, "run2"))
+
+		For("services").Info("not logged")
+		For("services").Warn("logged")
+		For("mongodb").Debug("logged")
+
+		logs := decodeLogs(t, &output)
+		require.Len(t, logs, 2)
+		assert.Equal(t, "services", logs[0][KeyComponent])
+		assert.Equal(t, "mongodb", logs[1][KeyComponent])
+	})
+
+	t.Run("Given sampling When logging many debug logs Then one of every SampleEvery must be kept", func(t *testing.T) {
+		var output bytes.Buffer
+		require.NoError(t, Setup(Config{Format: FormatJSON, Level: "debug", SampleEvery: 10}, &output, "run3"))
+
+		logger := For("jsonstream").With(KeyPortKey, "AEAJM")
+		for i := 0; i < 25; i++ {
+			logger.Debug("Key decoded")
+			logger.Info("Not sampled")
+		}
+
+		debugLogs := 0
+		for _, entry := range decodeLogs(t, &output) {
+			if entry["msg"] == "Key decoded" {
+				debugLogs++
+
+				assert.Equal(t, "AEAJM", entry[KeyPortKey], "Attributes of the logger must be kept")
+			}
+		}
+
+		assert.Equal(t, 3, debugLogs)
+		assert.Equal(t, 28, len(decodeLogs(t, &output)))
+	})
+
+	t.Run("Given invalid settings When calling Setup Then an error is expected", func(t *testing.T) {
+		assert.Error(t, Setup(Config{Format: "xml"}, &bytes.Buffer{}, "run4"))
+		assert.Error(t, Setup(Config{Level: "verbose"}, &bytes.Buffer{}, "run4"))
+		assert.Error(t, Setup(Config{ComponentLevels: []string{"mongodb"}}, &bytes.Buffer{}, "run4"))
+	})
+}
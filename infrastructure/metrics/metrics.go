@@ -0,0 +1,138 @@
+// Package metrics has the Prometheus metrics of the imports and of the repository operations.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "portimporter"
+
+// Results of an upsert, used as the result label of PortsTotal.
+const (
+	ResultCreated   = "created"
+	ResultUpdated   = "updated"
+	ResultUnchanged = "unchanged"
+	ResultFailed    = "failed"
+)
+
+// Registry has every metric of the application, besides the Go and process metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// EntriesDecoded counts the entries decoded from the source files.
+	EntriesDecoded = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "entries_decoded_total",
+		Help:      "Entries decoded from the source files.",
+	})
+
+	// EntriesFailed counts the entries of the source files that could not be decoded.
+	EntriesFailed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "entries_failed_total",
+		Help:      "Entries of the source files that could not be decoded.",
+	})
+
+	// DuplicateKeys counts the entries of the source files whose key was read before at the same file.
+	DuplicateKeys = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "duplicate_keys_total",
+		Help:      "Entries of the source files whose key was read before at the same file.",
+	})
+
+	// PortsTotal counts the upserted ports by result.
+	PortsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ports_total",
+		Help:      "Upserted ports by result: created, updated, unchanged or failed.",
+	}, []string{"result"})
+
+	// UpsertDuration observes the duration of each upsert.
+	UpsertDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upsert_duration_seconds",
+		Help:      "Duration of the upserts of ports.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	})
+
+	// RepositoryDuration observes the duration of the repository calls by operation.
+	RepositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_duration_seconds",
+		Help:      "Duration of the repository calls by operation.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"operation"})
+
+	// RepositoryErrors counts the repository calls that failed by operation.
+	RepositoryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "repository_errors_total",
+		Help:      "Repository calls that failed by operation.",
+	}, []string{"operation"})
+
+	// Retries counts the retries of the operations that failed with transient errors.
+	Retries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "retries_total",
+		Help:      "Retries of the operations that failed with transient errors by operation.",
+	}, []string{"operation"})
+
+	// ImportsInFlight is the number of imports running.
+	ImportsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "imports_in_flight",
+		Help:      "Imports running.",
+	})
+
+	// UpsertsInFlight is the number of upserts running.
+	UpsertsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "upserts_in_flight",
+		Help:      "Upserts running.",
+	})
+
+	// LastSuccessfulImport is the Unix time of the end of the last import without failures.
+	LastSuccessfulImport = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_successful_import_timestamp_seconds",
+		Help:      "Unix time of the end of the last import without failures.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		EntriesDecoded,
+		EntriesFailed,
+		DuplicateKeys,
+		PortsTotal,
+		UpsertDuration,
+		RepositoryDuration,
+		RepositoryErrors,
+		Retries,
+		ImportsInFlight,
+		UpsertsInFlight,
+		LastSuccessfulImport,
+	)
+}
+
+// Handler retrieves the handler that exposes the metrics of the Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveRepositoryCall observes the duration of the repository operation started at start,
+// counting it as an error when err is not nil.
+func ObserveRepositoryCall(operation string, start time.Time, err error) {
+	RepositoryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		RepositoryErrors.WithLabelValues(operation).Inc()
+	}
+}
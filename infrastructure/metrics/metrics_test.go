@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveRepositoryCall(t *testing.T) {
+	t.Run("Given a failed repository call When observing it Then the call must be counted as an error", func(t *testing.T) {
+		errorsBefore := testutil.ToFloat64(RepositoryErrors.WithLabelValues("test_failed"))
+
+		ObserveRepositoryCall("test_failed", time.Now(), errors.New("Error querying Port"))
+
+		assert.Equal(t, errorsBefore+1, testutil.ToFloat64(RepositoryErrors.WithLabelValues("test_failed")))
+	})
+
+	t.Run("Given a successful repository call When observing it Then the call must not be counted as an error", func(t *testing.T) {
+		ObserveRepositoryCall("test_succeeded", time.Now(), nil)
+
+		assert.Equal(t, float64(0), testutil.ToFloat64(RepositoryErrors.WithLabelValues("test_succeeded")))
+		assert.GreaterOrEqual(t, testutil.CollectAndCount(RepositoryDuration, "portimporter_repository_duration_seconds"), 1)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("Given counted ports When requesting the metrics Then the counters must be served", func(t *testing.T) {
+		PortsTotal.WithLabelValues(ResultCreated).Inc()
+
+		response := httptest.NewRecorder()
+		Handler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, string(body), `portimporter_ports_total{result="created"}`)
+		assert.Contains(t, string(body), "portimporter_last_successful_import_timestamp_seconds")
+		assert.Contains(t, string(body), "go_goroutines")
+	})
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+)
+
+// PortRepository is the domain.PortRepository that measures the calls of the decorated one: their
+// duration and the failed ones by operation, whatever store implements it.
+type PortRepository struct {
+	portRepository domain.PortRepository
+}
+
+// Retrieves a new PortRepository measuring the calls of portRepository.
+func NewPortRepository(portRepository domain.PortRepository) PortRepository {
+	return PortRepository{portRepository: portRepository}
+}
+
+func (r PortRepository) GetByID(ctx context.Context, id string) (_ *entities.Port, err error) {
+	defer observe("get_by_id", time.Now(), &err)
+
+	return r.portRepository.GetByID(ctx, id)
+}
+
+func (r PortRepository) Create(ctx context.Context, port entities.Port) (err error) {
+	defer observe("create", time.Now(), &err)
+
+	return r.portRepository.Create(ctx, port)
+}
+
+func (r PortRepository) Update(ctx context.Context, port entities.Port, id string) (err error) {
+	defer observe("update", time.Now(), &err)
+
+	return r.portRepository.Update(ctx, port, id)
+}
+
+// ForEach is measured as a whole, including the calls of fn.
+func (r PortRepository) ForEach(ctx context.Context, fn func(entities.Port) error) (err error) {
+	defer observe("for_each", time.Now(), &err)
+
+	return r.portRepository.ForEach(ctx, fn)
+}
+
+// Observes the call of the operation started at start, with the error it retrieved.
+func observe(operation string, start time.Time, err *error) {
+	ObserveRepositoryCall(operation, start, *err)
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortRepository(t *testing.T) {
+	t.Run("Given repository calls When calling the PortRepository Then the failed ones must be counted by operation", func(t *testing.T) {
+		getErrorsBefore := testutil.ToFloat64(RepositoryErrors.WithLabelValues("get_by_id"))
+		createErrorsBefore := testutil.ToFloat64(RepositoryErrors.WithLabelValues("create"))
+		updateErrorsBefore := testutil.ToFloat64(RepositoryErrors.WithLabelValues("update"))
+
+		portRepository := NewPortRepository(domain.MockPortRepository{
+			GetByIDfn: func(id string) (*entities.Port, error) { return &entities.Port{ID: id}, nil },
+			Createfn:  func(entities.Port) error { return errors.New("Error creating Port") },
+			Updatefn:  func(entities.Port, string) error { return nil },
+		})
+
+		port, err := portRepository.GetByID(context.Background(), "AEAJM")
+		assert.NoError(t, err)
+		assert.Equal(t, &entities.Port{ID: "AEAJM"}, port)
+
+		assert.Error(t, portRepository.Create(context.Background(), entities.Port{ID: "AEAJM"}))
+		assert.NoError(t, portRepository.Update(context.Background(), entities.Port{ID: "AEAJM"}, "AEAJM"))
+
+		assert.Equal(t, getErrorsBefore, testutil.ToFloat64(RepositoryErrors.WithLabelValues("get_by_id")))
+		assert.Equal(t, createErrorsBefore+1, testutil.ToFloat64(RepositoryErrors.WithLabelValues("create")))
+		assert.Equal(t, updateErrorsBefore, testutil.ToFloat64(RepositoryErrors.WithLabelValues("update")))
+	})
+
+	t.Run("Given a failed iteration When iterating the PortRepository Then the call must be observed", func(t *testing.T) {
+		errorsBefore := testutil.ToFloat64(RepositoryErrors.WithLabelValues("for_each"))
+
+		portRepository := NewPortRepository(domain.MockPortRepository{
+			ForEachfn: func(fn func(entities.Port) error) error { return fn(entities.Port{ID: "AEAJM"}) },
+		})
+
+		err := portRepository.ForEach(context.Background(), func(entities.Port) error { return errors.New("Error writing Port") })
+
+		assert.Error(t, err)
+		assert.Equal(t, errorsBefore+1, testutil.ToFloat64(RepositoryErrors.WithLabelValues("for_each")))
+		assert.GreaterOrEqual(t, testutil.CollectAndCount(RepositoryDuration, "portimporter_repository_duration_seconds"), 4)
+	})
+}
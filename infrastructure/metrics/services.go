@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/domain/services"
+)
+
+// PortService is the domain.PortService that measures the upserts of the decorated one: their
+// duration, the upserts running and the upserted ports by result.
+type PortService struct {
+	portService domain.PortService
+}
+
+// Retrieves a new PortService measuring the upserts of portService.
+func NewPortService(portService domain.PortService) PortService {
+	return PortService{portService: portService}
+}
+
+// Upsert the Port through the decorated PortService, measuring the upsert.
+func (s PortService) Upsert(ctx context.Context, port entities.Port) (domain.UpsertResult, error) {
+	UpsertsInFlight.Inc()
+	defer UpsertsInFlight.Dec()
+
+	start := time.Now()
+	defer func() { UpsertDuration.Observe(time.Since(start).Seconds()) }()
+
+	result, err := s.portService.Upsert(ctx, port)
+	if err != nil {
+		PortsTotal.WithLabelValues(ResultFailed).Inc()
+
+		return result, err
+	}
+
+	PortsTotal.WithLabelValues(string(result)).Inc()
+
+	return result, nil
+}
+
+// ImportService is the services.Importer that measures the imports of the decorated one: the
+// imports running and the end of the last import without failures.
+type ImportService struct {
+	importService services.Importer
+}
+
+// Retrieves a new ImportService measuring the imports of importService.
+func NewImportService(importService services.Importer) ImportService {
+	return ImportService{importService: importService}
+}
+
+// Import the entries through the decorated Importer. Runs without failures set the last
+// successful import metric.
+func (s ImportService) Import(ctx context.Context, entries <-chan services.ImportEntry) services.Report {
+	ImportsInFlight.Inc()
+	defer ImportsInFlight.Dec()
+
+	report := s.importService.Import(ctx, entries)
+
+	if report.Failed() == 0 && !report.Interrupted {
+		LastSuccessfulImport.SetToCurrentTime()
+	}
+
+	return report
+}
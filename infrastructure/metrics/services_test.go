@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/domain/services"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortService(t *testing.T) {
+	t.Run("Given upserts When upserting through the PortService Then the ports must be counted by result", func(t *testing.T) {
+		createdBefore := testutil.ToFloat64(PortsTotal.WithLabelValues(ResultCreated))
+		failedBefore := testutil.ToFloat64(PortsTotal.WithLabelValues(ResultFailed))
+
+		portService := NewPortService(services.NewPortService(domain.MockPortRepository{
+			GetByIDfn: func(id string) (*entities.Port, error) {
+				if id == "FAILED" {
+					return nil, errors.New("Error querying Port")
+				}
+
+				return nil, nil
+			},
+			Createfn: func(entities.Port) error { return nil },
+		}))
+
+		result, err := portService.Upsert(context.Background(), entities.Port{ID: "AEAJM"})
+		assert.NoError(t, err)
+		assert.Equal(t, domain.UpsertCreated, result)
+
+		_, err = portService.Upsert(context.Background(), entities.Port{ID: "FAILED"})
+		assert.Error(t, err)
+
+		assert.Equal(t, createdBefore+1, testutil.ToFloat64(PortsTotal.WithLabelValues(ResultCreated)))
+		assert.Equal(t, failedBefore+1, testutil.ToFloat64(PortsTotal.WithLabelValues(ResultFailed)))
+		assert.Equal(t, float64(0), testutil.ToFloat64(UpsertsInFlight), "Finished upserts must not be running")
+	})
+}
+
+func TestImportService(t *testing.T) {
+	t.Run("Given an import without failures When importing Then the last successful import must be set", func(t *testing.T) {
+		LastSuccessfulImport.Set(0)
+
+		entries := make(chan services.ImportEntry)
+		close(entries)
+
+		NewImportService(services.NewImportService(nil)).Import(context.Background(), entries)
+
+		assert.Positive(t, testutil.ToFloat64(LastSuccessfulImport))
+		assert.Equal(t, float64(0), testutil.ToFloat64(ImportsInFlight), "Finished imports must not be running")
+	})
+
+	t.Run("Given an import with failures When importing Then the last successful import must be kept", func(t *testing.T) {
+		LastSuccessfulImport.Set(0)
+
+		entries := make(chan services.ImportEntry, 1)
+		entries <- services.ImportEntry{Port: entities.Port{ID: "AEAJM"}, Error: errors.New("Error decoding port")}
+		close(entries)
+
+		NewImportService(services.NewImportService(nil)).Import(context.Background(), entries)
+
+		assert.Equal(t, float64(0), testutil.ToFloat64(LastSuccessfulImport))
+	})
+}
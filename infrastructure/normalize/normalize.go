@@ -0,0 +1,247 @@
+// Package normalize cleans the texts of the imported Ports: Unicode NFC, whitespace and the
+// common broken encodings. It also folds texts to ASCII for search.
+package normalize
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Kinds of the changes made to a text.
+const (
+	// KindMojibake is UTF-8 text that was decoded as Latin-1 or Windows-1252, e.g. "SÃ£o Paulo".
+	KindMojibake = "mojibake"
+	// KindSpacingDiacritic is a diacritic written as a character of its own after the letter,
+	// e.g. "Z¸aby", replaced by the combining diacritic.
+	KindSpacingDiacritic = "spacing-diacritic"
+	// KindNFC is text not in Unicode Normalization Form C.
+	KindNFC = "nfc"
+	// KindWhitespace is whitespace around the text or repeated inside it.
+	KindWhitespace = "whitespace"
+)
+
+// Options of the normalization of the Ports.
+type Options struct {
+	// FoldASCII sets the NameASCII and the CityASCII of the Ports.
+	FoldASCII bool
+}
+
+// Normalizer is the domain.Normalizer that normalizes the texts of the Ports with its options.
+type Normalizer struct {
+	options Options
+}
+
+// Retrieves a new Normalizer.
+func NewNormalizer(options Options) Normalizer {
+	return Normalizer{options: options}
+}
+
+// Normalize retrieves the Port normalized by Port with the options of the Normalizer.
+func (n Normalizer) Normalize(port entities.Port) (entities.Port, []entities.Change) {
+	return Port(port, n.options)
+}
+
+// Port retrieves the Port with every text normalized, and the changes made in the order of
+// entities.FieldNames. The ID is never changed.
+func Port(port entities.Port, options Options) (entities.Port, []entities.Change) {
+	var changes []entities.Change
+
+	for _, name := range entities.FieldNames {
+		var kinds []string
+
+		before := port.Field(name)
+
+		switch name {
+		case "name":
+			port.Name, kinds = Text(port.Name)
+		case "city":
+			port.City, kinds = Text(port.City)
+		case "country":
+			port.Country, kinds = Text(port.Country)
+		case "alias":
+			port.Alias, kinds = texts(port.Alias)
+		case "regions":
+			port.Regions, kinds = texts(port.Regions)
+		case "province":
+			port.Province, kinds = Text(port.Province)
+		case "timezone":
+			port.Timezone, kinds = Text(port.Timezone)
+		case "unlocs":
+			port.Unlocs, kinds = texts(port.Unlocs)
+		case "code":
+			port.Code, kinds = Text(port.Code)
+		}
+
+		if len(kinds) > 0 {
+			changes = append(changes, entities.Change{Field: name, Before: before, After: port.Field(name), Kinds: kinds})
+		}
+	}
+
+	if options.FoldASCII {
+		port.NameASCII = FoldASCII(port.Name)
+		port.CityASCII = FoldASCII(port.City)
+	}
+
+	return port, changes
+}
+
+// Retrieves a copy of the values normalized, and the kinds of the changes made to any of them.
+func texts(values []string) ([]string, []string) {
+	var (
+		normalized []string
+		kinds      []string
+	)
+
+	for i, value := range values {
+		text, textKinds := Text(value)
+		if len(textKinds) == 0 {
+			continue
+		}
+
+		// The values are copied on their first change, the given slice is shared.
+		if normalized == nil {
+			normalized = append([]string{}, values...)
+		}
+
+		normalized[i] = text
+
+		for _, kind := range textKinds {
+			if !slices.Contains(kinds, kind) {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+
+	if normalized == nil {
+		return values, nil
+	}
+
+	return normalized, kinds
+}
+
+// Text retrieves the text normalized, and the kinds of the changes made in the order they were
+// made. The broken encodings are repaired first, then the text is converted to NFC and its
+// whitespace is trimmed and collapsed.
+func Text(value string) (string, []string) {
+	var kinds []string
+
+	steps := []struct {
+		kind      string
+		normalize func(string) string
+	}{
+		{KindMojibake, repairMojibake},
+		{KindSpacingDiacritic, combineDiacritics},
+		{KindNFC, norm.NFC.String},
+		{KindWhitespace, collapseWhitespace},
+	}
+
+	for _, step := range steps {
+		if normalized := step.normalize(value); normalized != value {
+			value = normalized
+			kinds = append(kinds, step.kind)
+		}
+	}
+
+	return value, kinds
+}
+
+// A character encoded with 2 or 3 bytes in UTF-8 decoded as Windows-1252, e.g. "Ã£" for "ã" and
+// "â€™" for "’".
+var mojibake = regexp.MustCompile(`[\x{C2}-\x{DF}][\x{80}-\x{BF}€‚ƒ„…†‡ˆ‰Š‹ŒŽ‘’“”•–—˜™š›œžŸ]|` +
+	`[\x{E0}-\x{EF}][\x{80}-\x{BF}€‚ƒ„…†‡ˆ‰Š‹ŒŽ‘’“”•–—˜™š›œžŸ]{2}`)
+
+// Retrieves the text with each sequence that looks like mojibake encoded back to the bytes it was
+// decoded from, when they are a valid UTF-8 character. The other characters are kept, so texts
+// mixing broken and correct characters are repaired too.
+func repairMojibake(value string) string {
+	return mojibake.ReplaceAllStringFunc(value, func(sequence string) string {
+		encoded := make([]byte, 0, len(sequence))
+
+		for _, r := range sequence {
+			if r < 0x100 {
+				encoded = append(encoded, byte(r))
+
+				continue
+			}
+
+			b, ok := charmap.Windows1252.EncodeRune(r)
+			if !ok {
+				return sequence
+			}
+
+			encoded = append(encoded, b)
+		}
+
+		if !utf8.Valid(encoded) {
+			return sequence
+		}
+
+		return string(encoded)
+	})
+}
+
+// Combining diacritics of the spacing ones never used as punctuation.
+var combining = map[rune]rune{
+	'\u00B8': '\u0327', // cedilla
+	'\u00A8': '\u0308', // diaeresis
+	'\u02DC': '\u0303', // tilde
+	'\u02C6': '\u0302', // circumflex
+	'\u02DA': '\u030A', // ring above
+	'\u02C7': '\u030C', // caron
+}
+
+// Retrieves the text with the spacing diacritics that follow a letter replaced by the combining ones.
+func combineDiacritics(value string) string {
+	var (
+		builder  strings.Builder
+		previous rune
+	)
+
+	for _, r := range value {
+		if mark, ok := combining[r]; ok && unicode.IsLetter(previous) {
+			r = mark
+		}
+
+		builder.WriteRune(r)
+		previous = r
+	}
+
+	return builder.String()
+}
+
+// Retrieves the text without whitespace around it and with single spaces inside it.
+func collapseWhitespace(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// Letters without decomposition into an ASCII letter and diacritics.
+var foldings = map[rune]string{
+	'ø': "o", 'Ø': "O", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ß': "ss", 'đ': "d", 'Đ': "D",
+	'ł': "l", 'Ł': "L", 'þ': "th", 'Þ': "Th", 'ð': "d", 'Ð': "D", 'ı': "i",
+	'‘': "'", '’': "'", 'ʻ': "'", '´': "'",
+}
+
+// FoldASCII retrieves the text without diacritics and with the letters without ASCII equivalent
+// removed, e.g. "Sao Paulo" for "São Paulo".
+func FoldASCII(value string) string {
+	var builder strings.Builder
+
+	for _, r := range norm.NFD.String(value) {
+		switch {
+		case r < utf8.RuneSelf:
+			builder.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+		default:
+			builder.WriteString(foldings[r])
+		}
+	}
+
+	return collapseWhitespace(builder.String())
+}
@@ -0,0 +1,143 @@
+package normalize
+
+import (
+	"testing"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		kinds    []string
+	}{
+		{
+			name:     "Given a normalized text When normalizing Then the text must be kept",
+			value:    "Abū Ẓaby",
+			expected: "Abū Ẓaby",
+		},
+		{
+			name:     "Given UTF-8 decoded as Latin-1 When normalizing Then the text must be repaired",
+			value:    "SÃ£o Paulo",
+			expected: "São Paulo",
+			kinds:    []string{KindMojibake},
+		},
+		{
+			name:     "Given UTF-8 decoded as Windows-1252 When normalizing Then the text must be repaired",
+			value:    "Côte dâ€™Ivoire",
+			expected: "Côte d’Ivoire",
+			kinds:    []string{KindMojibake},
+		},
+		{
+			name:     "Given a text that only looks like mojibake When normalizing Then the text must be kept",
+			value:    "Ãbo",
+			expected: "Ãbo",
+		},
+		{
+			name:     "Given a spacing diacritic after a letter When normalizing Then it must be combined with the letter",
+			value:    "Abu Z¸aby",
+			expected: "Abu Z̧aby",
+			kinds:    []string{KindSpacingDiacritic},
+		},
+		{
+			name:     "Given a decomposed text When normalizing Then it must be composed",
+			value:    "Zürich",
+			expected: "Zürich",
+			kinds:    []string{KindNFC},
+		},
+		{
+			name:     "Given whitespace around and inside the text When normalizing Then it must be trimmed and collapsed",
+			value:    " Port \t of  Ajman ",
+			expected: "Port of Ajman",
+			kinds:    []string{KindWhitespace},
+		},
+		{
+			name:     "Given several problems When normalizing Then every kind must be retrieved in order",
+			value:    "Moˆ Ã¶ ",
+			expected: "Mô ö",
+			kinds:    []string{KindMojibake, KindSpacingDiacritic, KindNFC, KindWhitespace},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			normalized, kinds := Text(tt.value)
+
+			assert.Equal(t, tt.expected, normalized)
+			assert.Equal(t, tt.kinds, kinds)
+		})
+	}
+}
+
+func TestFoldASCII(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "São Paulo", expected: "Sao Paulo"},
+		{value: "Abū Ẓaby", expected: "Abu Zaby"},
+		{value: "Øresund Straße", expected: "Oresund Strasse"},
+		{value: "Côte d’Ivoire", expected: "Cote d'Ivoire"},
+		{value: "東京 Port", expected: "Port"},
+	}
+
+	for _, tt := range tests {
+		t.Run("Given "+tt.value+" When folding Then "+tt.expected+" is expected", func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, FoldASCII(tt.value))
+		})
+	}
+}
+
+func TestPort(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a Port with texts to normalize When normalizing Then every change must be retrieved", func(t *testing.T) {
+		t.Parallel()
+
+		alias := []string{"Ajman", " Ajman  Port"}
+		port := entities.Port{ID: " AEAJM", Name: "Ajman", City: "Ajman ", Alias: alias}
+
+		normalized, changes := Port(port, Options{})
+
+		assert.Equal(t, entities.Port{ID: " AEAJM", Name: "Ajman", City: "Ajman", Alias: []string{"Ajman", "Ajman Port"}},
+			normalized, "The ID must never be changed")
+		assert.Equal(t, []entities.Change{
+			{Field: "city", Before: "Ajman ", After: "Ajman", Kinds: []string{KindWhitespace}},
+			{Field: "alias", Before: "Ajman, Ajman  Port", After: "Ajman,Ajman Port", Kinds: []string{KindWhitespace}},
+		}, changes)
+		assert.Equal(t, " Ajman  Port", alias[1], "The given lists must not be changed")
+	})
+
+	t.Run("Given a Normalizer folding to ASCII When normalizing Then the folded name and city must be set", func(t *testing.T) {
+		t.Parallel()
+
+		normalized, changes := NewNormalizer(Options{FoldASCII: true}).Normalize(entities.Port{ID: "BRSSZ", Name: "São Paulo", City: "Santos"})
+
+		assert.Equal(t, "Sao Paulo", normalized.NameASCII)
+		assert.Equal(t, "Santos", normalized.CityASCII)
+		assert.Empty(t, changes, "Folding must not be recorded as a change")
+	})
+	t.Run("Given a Normalizer not folding to ASCII When normalizing Then the folded name and city must be kept", func(t *testing.T) {
+		t.Parallel()
+
+		port := entities.Port{ID: "BRSSZ", Name: "São  Paulo", NameASCII: "Sao Paulo", City: "Santos"}
+
+		normalized, changes := NewNormalizer(Options{}).Normalize(port)
+
+		assert.Equal(t, entities.Port{ID: "BRSSZ", Name: "São Paulo", NameASCII: "Sao Paulo", City: "Santos"}, normalized)
+		assert.Equal(t, []entities.Change{
+			{Field: "name", Before: "São  Paulo", After: "São Paulo", Kinds: []string{KindWhitespace}},
+		}, changes)
+	})
+}
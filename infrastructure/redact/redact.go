@@ -0,0 +1,122 @@
+// Package redact hides secrets, like passwords, from texts written to logs.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Placeholder replaces the secrets.
+const Placeholder = "[REDACTED]"
+
+// Matches the user information of URIs that can not be parsed, e.g. "scheme://user:password@".
+var userInfoPattern = regexp.MustCompile(`(://[^:/@\s]*):[^@\s]*@`)
+
+// URI retrieves the uri with its password replaced by the Placeholder.
+func URI(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.User == nil {
+		return userInfoPattern.ReplaceAllString(uri, "$1:"+Placeholder+"@")
+	}
+
+	if _, hasPassword := parsed.User.Password(); !hasPassword {
+		return uri
+	}
+
+	parsed.User = url.UserPassword(parsed.User.Username(), Placeholder)
+
+	return strings.Replace(parsed.String(), url.QueryEscape(Placeholder), Placeholder, 1)
+}
+
+// URIPassword retrieves the password embedded at the uri, or an empty string when there is none.
+func URIPassword(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.User == nil {
+		return ""
+	}
+
+	password, _ := parsed.User.Password()
+
+	return password
+}
+
+// Writer replaces the secrets of every text written to the underlying writer.
+type Writer struct {
+	writer   io.Writer
+	replacer *strings.Replacer
+}
+
+// NewWriter retrieves a Writer that replaces the secrets written to writer by the Placeholder,
+// as they are and in the forms they take at JSON strings and URLs. Empty secrets are ignored.
+func NewWriter(writer io.Writer, secrets ...string) *Writer {
+	var unique []string
+
+	seen := map[string]bool{}
+
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+
+		for _, form := range forms(secret) {
+			if !seen[form] {
+				seen[form] = true
+				unique = append(unique, form)
+			}
+		}
+	}
+
+	// Longer secrets are replaced first, so a secret that contains another one is fully replaced.
+	sort.Slice(unique, func(i, j int) bool { return len(unique[i]) > len(unique[j]) })
+
+	pairs := make([]string, 0, 2*len(unique))
+	for _, secret := range unique {
+		pairs = append(pairs, secret, Placeholder)
+	}
+
+	return &Writer{
+		writer:   writer,
+		replacer: strings.NewReplacer(pairs...),
+	}
+}
+
+// Retrieves the secret and the forms it takes when it is escaped at JSON strings, with and without
+// the HTML characters escaped, and when it is encoded at URL queries, path segments, paths and user
+// information.
+func forms(secret string) []string {
+	forms := []string{secret}
+
+	for _, escapeHTML := range []bool{true, false} {
+		var buffer bytes.Buffer
+
+		encoder := json.NewEncoder(&buffer)
+		encoder.SetEscapeHTML(escapeHTML)
+
+		// Encoding a string never fails.
+		_ = encoder.Encode(secret)
+
+		// The quotes around the string and the new line after it are not part of the secret.
+		escaped := strings.TrimSuffix(buffer.String(), "\n")
+		forms = append(forms, escaped[1:len(escaped)-1])
+	}
+
+	userInfo := url.UserPassword("", secret).String()
+	path := (&url.URL{Path: secret}).EscapedPath()
+
+	return append(forms, url.QueryEscape(secret), url.PathEscape(secret), path, userInfo[strings.Index(userInfo, ":")+1:])
+}
+
+// Write writes p with its secrets replaced. It retrieves len(p) when the write succeeds,
+// even if the text written has another length.
+func (w *Writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.writer, w.replacer.Replace(string(p))); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
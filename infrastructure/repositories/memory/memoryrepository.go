@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+)
+
+// PortRepository is the in memory implementation of domain.PortRepository.
+// It is safe for concurrent use and keeps copies of the Ports, so changes made to a Port
+// after storing or retrieving it do not affect the stored Port.
+type PortRepository struct {
+	mutex *sync.RWMutex
+	ports map[string]entities.Port
+}
+
+// Retrieves a new empty PortRepository.
+func NewPortRepository() PortRepository {
+	return PortRepository{
+		mutex: &sync.RWMutex{},
+		ports: map[string]entities.Port{},
+	}
+}
+
+func (p PortRepository) GetByID(_ context.Context, id string) (*entities.Port, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	stored, ok := p.ports[id]
+	if !ok {
+		return nil, nil
+	}
+
+	port := copyPort(stored)
+
+	return &port, nil
+}
+
+func (p PortRepository) Create(_ context.Context, port entities.Port) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if _, ok := p.ports[port.ID]; ok {
+		return fmt.Errorf("Error creating port %s. Error: %w", port.ID, domain.ErrPortAlreadyExists)
+	}
+
+	stored := copyPort(port)
+	stored.Version = 0
+	p.ports[port.ID] = stored
+
+	return nil
+}
+
+// Update replaces the Port identified by id when the stored version is the version of port, as
+// the database implementations do.
+func (p PortRepository) Update(_ context.Context, port entities.Port, id string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if current, ok := p.ports[id]; !ok || current.Version != port.Version {
+		return fmt.Errorf("Error updating port %s. Error: %w", id, domain.ErrVersionConflict)
+	}
+
+	stored := copyPort(port)
+	stored.ID = id
+	stored.Version++
+	p.ports[id] = stored
+
+	return nil
+}
+
+// ForEach calls fn with a snapshot of the Ports taken when it is called, so fn is able
+// to change the repository. It stops with the error of ctx when ctx is done.
+func (p PortRepository) ForEach(ctx context.Context, fn func(entities.Port) error) error {
+	p.mutex.RLock()
+	ports := make([]entities.Port, 0, len(p.ports))
+
+	for _, port := range p.ports {
+		ports = append(ports, copyPort(port))
+	}
+	p.mutex.RUnlock()
+
+	sort.Slice(ports, func(i, j int) bool { return ports[i].ID < ports[j].ID })
+
+	for _, port := range ports {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := fn(port); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Count retrieves how many Ports are stored.
+func (p PortRepository) Count() int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return len(p.ports)
+}
+
+func copyPort(port entities.Port) entities.Port {
+	port.Alias = copyStrings(port.Alias)
+	port.Regions = copyStrings(port.Regions)
+	port.Unlocs = copyStrings(port.Unlocs)
+
+	if port.Coordinates == nil {
+		port.Coordinates = []float64{}
+	} else {
+		port.Coordinates = append([]float64{}, port.Coordinates...)
+	}
+
+	// Empty provenance and locked fields are read as nil, as the database implementations do.
+	if len(port.Provenance) == 0 {
+		port.Provenance = nil
+	} else {
+		provenance := make(map[string]entities.Provenance, len(port.Provenance))
+		for name, value := range port.Provenance {
+			provenance[name] = value
+		}
+
+		port.Provenance = provenance
+	}
+
+	if len(port.Locked) == 0 {
+		port.Locked = nil
+	} else {
+		port.Locked = append([]string{}, port.Locked...)
+	}
+
+	return port
+}
+
+// Retrieves a copy of values. Nil slices are copied as empty ones, as the database
+// implementations do.
+func copyStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return append([]string{}, values...)
+}
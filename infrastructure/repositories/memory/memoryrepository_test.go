@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/repositorytest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindPorts(t *testing.T) {
+	t.Parallel()
+	t.Run("Given an id of a non existing Port When GetByID is invoked Then no error and not Port is expected", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := NewPortRepository()
+
+		port, err := portRepository.GetByID(context.Background(), "idunique")
+		assert.Nil(t, port, "Port must not exist at repository")
+		assert.NoError(t, err, "Error must not be found")
+	})
+
+	t.Run("Given a Port is created When the Port is queried it must be found", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := NewPortRepository()
+		expectedPort := entities.NewPort(
+			"id",
+			"name",
+			"city",
+			"country",
+			[]string{"alias1", "alias2"},
+			[]string{"region1", "region2"},
+			[]float64{43.434343434, 35.2423434},
+			"province",
+			"timezone",
+			[]string{"unloc1", "unloc2"},
+			"code")
+
+		err := portRepository.Create(context.Background(), expectedPort)
+		assert.NoError(t, err, "Error must not be found creating Port")
+
+		port, err := portRepository.GetByID(context.Background(), "id")
+		assert.NoError(t, err, "Error must not be found quering Port")
+		assert.Equal(t, &expectedPort, port, "Port stored must be equal to the Port created")
+	})
+
+	t.Run("Given a Port is created When the Port is created again Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := NewPortRepository()
+
+		err := portRepository.Create(context.Background(), entities.Port{ID: "id"})
+		assert.NoError(t, err, "Error must not be found creating Port")
+
+		err = portRepository.Create(context.Background(), entities.Port{ID: "id"})
+		assert.Error(t, err, "Error must be found creating the same Port twice")
+	})
+
+	t.Run("Given a Port is stored When the Port is updated Then the changes must be found", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := NewPortRepository()
+		idPort := "idx"
+
+		port := entities.NewPort(
+			idPort,
+			"name",
+			"city",
+			"country",
+			[]string{"alias1", "alias2"},
+			[]string{"region1", "region2"},
+			[]float64{43.434343434, 35.2423434},
+			"province",
+			"timezone",
+			[]string{"unloc1", "unloc2"},
+			"code")
+		err := portRepository.Create(context.Background(), port)
+		assert.NoError(t, err, "Error must not be found creating Port")
+
+		expectedCity := "Other city"
+		port.City = expectedCity
+		err = portRepository.Update(context.Background(), port, idPort)
+		assert.NoError(t, err, "Error must not be found quering Port")
+		portExisting, _ := portRepository.GetByID(context.Background(), idPort)
+		assert.Equal(t, expectedCity, portExisting.City)
+	})
+
+	t.Run("Given a Port is stored When the retrieved Port is changed Then the stored Port must not change", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := NewPortRepository()
+		alias := []string{"alias1"}
+
+		err := portRepository.Create(context.Background(), entities.Port{ID: "id", Alias: alias})
+		assert.NoError(t, err, "Error must not be found creating Port")
+
+		alias[0] = "changed"
+		port, _ := portRepository.GetByID(context.Background(), "id")
+		port.Alias[0] = "changed again"
+
+		portExisting, _ := portRepository.GetByID(context.Background(), "id")
+		assert.Equal(t, []string{"alias1"}, portExisting.Alias)
+	})
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	t.Parallel()
+	t.Run("Given many goroutines When creating and querying Ports Then every Port must be stored", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := NewPortRepository()
+		total := 100
+
+		var waitGroup sync.WaitGroup
+
+		for i := 0; i < total; i++ {
+			waitGroup.Add(1)
+
+			go func(i int) {
+				defer waitGroup.Done()
+
+				id := fmt.Sprintf("id%d", i)
+				assert.NoError(t, portRepository.Create(context.Background(), entities.Port{ID: id}))
+				_, err := portRepository.GetByID(context.Background(), id)
+				assert.NoError(t, err)
+			}(i)
+		}
+
+		waitGroup.Wait()
+		assert.Equal(t, total, portRepository.Count())
+	})
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	repositorytest.Run(t, func(t *testing.T) domain.PortRepository {
+		t.Helper()
+
+		return NewPortRepository()
+	})
+}
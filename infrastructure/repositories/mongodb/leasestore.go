@@ -0,0 +1,80 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/lease"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LeaseDB is a lease stored by LeaseStore, identified by its name.
+type LeaseDB struct {
+	Name      string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// LeaseStore is the implementation for Mongo of lease.Store, which keeps each lease as a
+// document of the leases collection. The expiration is given by the clock of the instance, so
+// the clocks of the instances must be synchronized within a small fraction of the TTL.
+type LeaseStore struct {
+	client       *mongo.Client
+	databaseName string
+}
+
+func NewLeaseStore(client *mongo.Client, databaseName string) LeaseStore {
+	return LeaseStore{
+		client:       client,
+		databaseName: databaseName,
+	}
+}
+
+// Acquire updates the lease when it is held by the owner or expired, and inserts it when it does
+// not exist. When another owner holds the lease, the filter does not match and the insert fails
+// with a duplicate key, so two instances never acquire the lease at the same time.
+func (s LeaseStore) Acquire(ctx context.Context, name string, owner string, ttl time.Duration) (err error) {
+	ctx, done := observe(ctx, "acquire_lease")
+	defer func() { done(err) }()
+
+	leasesCollection := s.client.Database(s.databaseName).Collection("leases")
+
+	now := time.Now()
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"owner": owner},
+			bson.M{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": owner, "expires_at": now.Add(ttl)}}
+
+	_, err = leasesCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	holder := LeaseDB{Name: name}
+
+	err = leasesCollection.FindOne(ctx, bson.M{"_id": name}).Decode(&holder)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	// The lease released after the insert failed is reported as held, it is free at the next attempt.
+	return &lease.HeldError{Name: holder.Name, Owner: holder.Owner, ExpiresAt: holder.ExpiresAt}
+}
+
+func (s LeaseStore) Release(ctx context.Context, name string, owner string) (err error) {
+	ctx, done := observe(ctx, "release_lease")
+	defer func() { done(err) }()
+
+	leasesCollection := s.client.Database(s.databaseName).Collection("leases")
+
+	_, err = leasesCollection.DeleteOne(ctx, bson.M{"_id": name, "owner": owner})
+
+	return err
+}
@@ -0,0 +1,63 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/lease"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaseStore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a free lease When acquiring Then it is acquired", func(t *testing.T) {
+		t.Parallel()
+
+		store := NewLeaseStore(dbClient, "leasesTest")
+
+		assert.NoError(t, store.Acquire(context.Background(), "free", "first", time.Minute))
+		assert.NoError(t, store.Acquire(context.Background(), "free", "first", time.Minute),
+			"The owner must renew its lease")
+	})
+
+	t.Run("Given a lease held by another owner When acquiring Then a HeldError is expected", func(t *testing.T) {
+		t.Parallel()
+
+		store := NewLeaseStore(dbClient, "leasesTest")
+		require.NoError(t, store.Acquire(context.Background(), "held", "first", time.Minute))
+
+		err := store.Acquire(context.Background(), "held", "second", time.Minute)
+
+		var held *lease.HeldError
+		require.ErrorAs(t, err, &held)
+		assert.Equal(t, "first", held.Owner)
+	})
+
+	t.Run("Given an expired lease When acquiring Then it is acquired", func(t *testing.T) {
+		t.Parallel()
+
+		store := NewLeaseStore(dbClient, "leasesTest")
+		require.NoError(t, store.Acquire(context.Background(), "expired", "first", time.Millisecond))
+
+		time.Sleep(10 * time.Millisecond)
+
+		assert.NoError(t, store.Acquire(context.Background(), "expired", "second", time.Minute))
+	})
+
+	t.Run("Given a released lease When acquiring Then it is acquired", func(t *testing.T) {
+		t.Parallel()
+
+		store := NewLeaseStore(dbClient, "leasesTest")
+		require.NoError(t, store.Acquire(context.Background(), "released", "first", time.Minute))
+		require.NoError(t, store.Release(context.Background(), "released", "second"))
+		require.Error(t, store.Acquire(context.Background(), "released", "second", time.Minute),
+			"Only the owner must release the lease")
+
+		require.NoError(t, store.Release(context.Background(), "released", "first"))
+
+		assert.NoError(t, store.Acquire(context.Background(), "released", "second", time.Minute))
+	})
+}
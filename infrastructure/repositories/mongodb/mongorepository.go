@@ -3,11 +3,24 @@ package mongodb
 import (
 	"context"
 	"errors"
-	"log"
+	"fmt"
+	"time"
 
+	"github.com/cassiuspaim/portimporter/domain"
 	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+	"github.com/cassiuspaim/portimporter/infrastructure/metrics"
+	"github.com/cassiuspaim/portimporter/infrastructure/tracing"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+var (
+	logger = logging.For("mongodb")
+	tracer = tracing.For("mongodb")
 )
 
 // PortDB is used by implementation for Mongo of PortRepository
@@ -23,49 +36,116 @@ type PortDB struct {
 	Timezone    string    `bson:"timezone"`
 	Unlocs      []string  `bson:"unlocs"`
 	Code        string    `bson:"code"`
+	NameASCII   string    `bson:"name_ascii,omitempty"`
+	CityASCII   string    `bson:"city_ascii,omitempty"`
+	// CountryCode and SubdivisionCode are the ISO 3166 codes of the country and the province.
+	CountryCode     string `bson:"country_code,omitempty"`
+	SubdivisionCode string `bson:"subdivision_code,omitempty"`
+	// Version is missing at the documents stored before it existed, they are read as version 0.
+	Version    int64                   `bson:"version"`
+	Provenance map[string]ProvenanceDB `bson:"provenance,omitempty"`
+	Locked     []string                `bson:"locked,omitempty"`
+}
+
+// ProvenanceDB is the provenance of a field, stored in a subdocument by field name.
+type ProvenanceDB struct {
+	Source    string    `bson:"source"`
+	RunID     string    `bson:"run_id,omitempty"`
+	UpdatedAt time.Time `bson:"updated_at"`
 }
 
 // Retrieves a PortDB based on entities.Port passed by parameter.
 func (p PortDB) From(port entities.Port) PortDB {
 	return PortDB{
-		Key:         port.ID,
-		Name:        port.Name,
-		City:        port.City,
-		Country:     port.Country,
-		Alias:       port.Alias,
-		Regions:     port.Regions,
-		Coordinates: port.Coordinates,
-		Province:    port.Province,
-		Timezone:    port.Timezone,
-		Unlocs:      port.Unlocs,
-		Code:        port.Code,
+		Key:             port.ID,
+		Name:            port.Name,
+		City:            port.City,
+		Country:         port.Country,
+		Alias:           port.Alias,
+		Regions:         port.Regions,
+		Coordinates:     port.Coordinates,
+		Province:        port.Province,
+		Timezone:        port.Timezone,
+		Unlocs:          port.Unlocs,
+		Code:            port.Code,
+		NameASCII:       port.NameASCII,
+		CityASCII:       port.CityASCII,
+		CountryCode:     port.CountryCode,
+		SubdivisionCode: port.SubdivisionCode,
+		Version:         port.Version,
+		Provenance:      fromProvenance(port.Provenance),
+		Locked:          port.Locked,
 	}
 }
 
 // Retrieves an entities.Port based on the PortDB.
+// Slices stored as null are retrieved as empty slices.
 func (p PortDB) To() entities.Port {
 	return entities.Port{
-		ID:          p.Key,
-		Name:        p.Name,
-		City:        p.City,
-		Country:     p.Country,
-		Alias:       p.Alias,
-		Regions:     p.Regions,
-		Coordinates: p.Coordinates,
-		Province:    p.Province,
-		Timezone:    p.Timezone,
-		Unlocs:      p.Unlocs,
-		Code:        p.Code,
+		ID:              p.Key,
+		Name:            p.Name,
+		City:            p.City,
+		Country:         p.Country,
+		Alias:           nonNilStrings(p.Alias),
+		Regions:         nonNilStrings(p.Regions),
+		Coordinates:     nonNilFloats(p.Coordinates),
+		Province:        p.Province,
+		Timezone:        p.Timezone,
+		Unlocs:          nonNilStrings(p.Unlocs),
+		Code:            p.Code,
+		NameASCII:       p.NameASCII,
+		CityASCII:       p.CityASCII,
+		CountryCode:     p.CountryCode,
+		SubdivisionCode: p.SubdivisionCode,
+		Version:         p.Version,
+		Provenance:      toProvenance(p.Provenance),
+		Locked:          nilIfEmpty(p.Locked),
 	}
 }
 
+// Retrieves the provenance to store, nil when empty so it is omitted.
+func fromProvenance(provenance map[string]entities.Provenance) map[string]ProvenanceDB {
+	if len(provenance) == 0 {
+		return nil
+	}
+
+	stored := make(map[string]ProvenanceDB, len(provenance))
+	for name, value := range provenance {
+		stored[name] = ProvenanceDB{Source: value.Source, RunID: value.RunID, UpdatedAt: value.UpdatedAt}
+	}
+
+	return stored
+}
+
+// Retrieves the provenance of the entities.Port, nil when empty.
+func toProvenance(stored map[string]ProvenanceDB) map[string]entities.Provenance {
+	if len(stored) == 0 {
+		return nil
+	}
+
+	provenance := make(map[string]entities.Provenance, len(stored))
+	for name, value := range stored {
+		provenance[name] = entities.Provenance{Source: value.Source, RunID: value.RunID, UpdatedAt: value.UpdatedAt}
+	}
+
+	return provenance
+}
+
+func nilIfEmpty(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	return values
+}
+
 type PortRepository struct {
 	client       *mongo.Client
 	databaseName string
 }
 
 func NewPortRepository(client *mongo.Client, databaseName string) PortRepository {
-	log.Printf("Database is %s", databaseName)
+	logger.Info("Database selected", "database", databaseName)
 
 	return PortRepository{
 		client:       client,
@@ -73,14 +153,57 @@ func NewPortRepository(client *mongo.Client, databaseName string) PortRepository
 	}
 }
 
-func (p PortRepository) GetByID(id string) (*entities.Port, error) {
+// Name given by Mongo to the index over the key.
+const keyIndexName = "key_1"
+
+// CreateIndexes creates the unique index over the key of the ports collection.
+// It can be called many times, an existing index is kept.
+func (p PortRepository) CreateIndexes() (err error) {
+	ctx, done := observe(context.TODO(), "create_indexes")
+	defer func() { done(err) }()
+
+	portsCollection := p.client.Database(p.databaseName).Collection("ports")
+
+	_, err = portsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	return err
+}
+
+// CheckIndexes retrieves an error when the unique index created by CreateIndexes does not exist.
+func (p PortRepository) CheckIndexes(ctx context.Context) (err error) {
+	ctx, done := observe(ctx, "check_indexes")
+	defer func() { done(err) }()
+
+	portsCollection := p.client.Database(p.databaseName).Collection("ports")
+
+	specifications, err := portsCollection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, specification := range specifications {
+		if specification.Name == keyIndexName && specification.Unique != nil && *specification.Unique {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Unique index %s not found at collection ports", keyIndexName)
+}
+
+func (p PortRepository) GetByID(ctx context.Context, id string) (_ *entities.Port, err error) {
+	ctx, done := startSpan(ctx, "get_by_id", id)
+	defer func() { done(err) }()
+
 	portsCollection := p.client.Database(p.databaseName).Collection("ports")
 
 	var portDB PortDB
 
 	filter := bson.D{{Key: "key", Value: id}}
-	result := portsCollection.FindOne(context.TODO(), filter)
-	err := result.Decode(&portDB)
+	result := portsCollection.FindOne(ctx, filter)
+	err = result.Decode(&portDB)
 
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
@@ -95,22 +218,168 @@ func (p PortRepository) GetByID(id string) (*entities.Port, error) {
 	return &port, nil
 }
 
-func (p PortRepository) Create(port entities.Port) error {
+func (p PortRepository) Create(ctx context.Context, port entities.Port) (err error) {
+	ctx, done := startSpan(ctx, "create", port.ID)
+	defer func() { done(err) }()
+
 	portsCollection := p.client.Database(p.databaseName).Collection("ports")
 
 	var portDB PortDB
 
-	_, err := portsCollection.InsertOne(context.TODO(), portDB.From(port))
+	portDB = portDB.From(port)
+	portDB.Version = 0
+
+	_, err = portsCollection.InsertOne(ctx, portDB)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("Error creating port %s. Error: %w", port.ID, domain.ErrPortAlreadyExists)
+	}
 
 	return err
 }
 
-func (p PortRepository) Update(port entities.Port, id string) error {
+func (p PortRepository) Update(ctx context.Context, port entities.Port, id string) (err error) {
+	ctx, done := startSpan(ctx, "update", id)
+	defer func() { done(err) }()
+
 	portsCollection := p.client.Database(p.databaseName).Collection("ports")
 
 	var portDB PortDB
 
-	_, err := portsCollection.ReplaceOne(context.TODO(), bson.M{"key": id}, portDB.From(port))
+	portDB = portDB.From(port)
+	portDB.Version = port.Version + 1
 
-	return err
+	result, err := portsCollection.ReplaceOne(ctx, versionFilter(id, port.Version), portDB)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("Error updating port %s. Error: %w", id, domain.ErrVersionConflict)
+	}
+
+	return nil
+}
+
+// Retrieves the filter of the Port identified by key at the version. The documents without
+// version, stored before it existed, are at version 0.
+func versionFilter(key string, version int64) bson.M {
+	if version == 0 {
+		return bson.M{"key": key, "version": bson.M{"$in": bson.A{0, nil}}}
+	}
+
+	return bson.M{"key": key, "version": version}
+}
+
+// Starts the span of the repository operation on the Port identified by key, which may be empty.
+// The returned function ends the span. The calls of the PortRepository are measured by the
+// metrics.PortRepository that decorates every store.
+func startSpan(ctx context.Context, operation string, key string) (context.Context, func(error)) {
+	ctx, span := tracing.StartRepositoryCall(ctx, tracer, semconv.DBSystemMongoDB, "mongodb."+operation, key)
+
+	return ctx, func(err error) { tracing.End(span, err) }
+}
+
+// Starts the span of the operation of the indexes or the leases, as startSpan does. The returned
+// function ends the span and observes the duration of the operation.
+func observe(ctx context.Context, operation string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, end := startSpan(ctx, operation, "")
+
+	return ctx, func(err error) {
+		metrics.ObserveRepositoryCall(operation, start, err)
+		end(err)
+	}
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
+
+func nonNilFloats(values []float64) []float64 {
+	if values == nil {
+		return []float64{}
+	}
+
+	return values
+}
+
+func (p PortRepository) ForEach(ctx context.Context, fn func(entities.Port) error) (err error) {
+	ctx, done := startSpan(ctx, "for_each", "")
+	defer func() { done(err) }()
+
+	portsCollection := p.client.Database(p.databaseName).Collection("ports")
+
+	cursor, err := portsCollection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "key", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var portDB PortDB
+
+		if err = cursor.Decode(&portDB); err != nil {
+			return err
+		}
+
+		if err = fn(portDB.To()); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// Codes of the server errors raised while a node is stepping down, shutting down or unreachable,
+// which succeed when retried against the new primary.
+var retryableCodes = []int{
+	6,     // HostUnreachable
+	7,     // HostNotFound
+	89,    // NetworkTimeout
+	91,    // ShutdownInProgress
+	189,   // PrimarySteppedDown
+	262,   // ExceededTimeLimit
+	9001,  // SocketException
+	10107, // NotWritablePrimary
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+// IsRetryable retrieves true when err is transient: network errors, timeouts, errors labeled
+// as retryable by the server and errors raised during elections or shutdowns. Duplicate keys
+// and canceled operations are not retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || mongo.IsDuplicateKeyError(err) {
+		return false
+	}
+
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+
+	var serverSelectionError topology.ServerSelectionError
+	if errors.As(err, &serverSelectionError) {
+		return true
+	}
+
+	var serverError mongo.ServerError
+	if errors.As(err, &serverError) {
+		if serverError.HasErrorLabel("RetryableWriteError") || serverError.HasErrorLabel("TransientTransactionError") {
+			return true
+		}
+
+		for _, code := range retryableCodes {
+			if serverError.HasErrorCode(code) {
+				return true
+			}
+		}
+	}
+
+	return false
 }
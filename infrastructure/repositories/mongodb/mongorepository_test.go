@@ -2,15 +2,19 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"testing"
 
+	"github.com/cassiuspaim/portimporter/domain"
 	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/repositorytest"
 	"github.com/ory/dockertest/v3"
 	"github.com/ory/dockertest/v3/docker"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -95,7 +99,7 @@ func TestFindPorts(t *testing.T) {
 
 		portRepository := NewPortRepository(dbClient, "portsTest")
 
-		port, err := portRepository.GetByID("idunique")
+		port, err := portRepository.GetByID(context.Background(), "idunique")
 		assert.Nil(t, port, "Port must not exist at database")
 		assert.NoError(t, err, "Error must not be found")
 	})
@@ -105,7 +109,7 @@ func TestFindPorts(t *testing.T) {
 
 		portRepository := NewPortRepository(dbClient, "portsTest")
 
-		err := portRepository.Create(entities.NewPort(
+		err := portRepository.Create(context.Background(), entities.NewPort(
 			"id",
 			"name",
 			"city",
@@ -119,7 +123,7 @@ func TestFindPorts(t *testing.T) {
 			"code"))
 		assert.NoError(t, err, "Error must not be found creating Port")
 
-		port, err := portRepository.GetByID("id")
+		port, err := portRepository.GetByID(context.Background(), "id")
 		assert.NotNil(t, port, "Port must exist at database")
 		assert.NoError(t, err, "Error must not be found quering Port")
 	})
@@ -142,14 +146,74 @@ func TestFindPorts(t *testing.T) {
 			"timezone",
 			[]string{"unloc1", "unloc2"},
 			"code")
-		err := portRepository.Create(port)
+		err := portRepository.Create(context.Background(), port)
 		assert.NoError(t, err, "Error must not be found creating Port")
 
 		expectedCity := "Other city"
 		port.City = expectedCity
-		err = portRepository.Update(port, idPort)
+		err = portRepository.Update(context.Background(), port, idPort)
 		assert.NoError(t, err, "Error must not be found quering Port")
-		portExisting, _ := portRepository.GetByID(idPort)
+		portExisting, _ := portRepository.GetByID(context.Background(), idPort)
 		assert.Equal(t, expectedCity, portExisting.City)
 	})
 }
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	repositorytest.Run(t, func(t *testing.T) domain.PortRepository {
+		t.Helper()
+
+		portRepository := NewPortRepository(dbClient, "portsConformanceTest")
+		require.NoError(t, portRepository.CreateIndexes(), "Indexes must be created")
+
+		return portRepository
+	})
+}
+
+func TestCheckIndexes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given the indexes are created When checking them Then no error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := NewPortRepository(dbClient, "portsIndexesTest")
+		require.NoError(t, portRepository.CreateIndexes(), "Indexes must be created")
+
+		assert.NoError(t, portRepository.CheckIndexes(context.Background()), "Indexes must be found")
+	})
+
+	t.Run("Given the indexes are not created When checking them Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := NewPortRepository(dbClient, "portsWithoutIndexesTest")
+
+		assert.Error(t, portRepository.CheckIndexes(context.Background()), "Indexes must not be found")
+	})
+}
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"Given a primary stepping down", mongo.CommandError{Code: 189}, true},
+		{"Given an error labeled as retryable", mongo.CommandError{Labels: []string{"RetryableWriteError"}}, true},
+		{"Given a timeout", context.DeadlineExceeded, true},
+		{"Given a duplicate key", mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}, false},
+		{"Given a canceled context", context.Canceled, false},
+		{"Given an unknown error", errors.New("Unknown error"), false},
+		{"Given no error", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" When classifying it Then it must be retryable only if transient", func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.retryable, IsRetryable(tt.err))
+		})
+	}
+}
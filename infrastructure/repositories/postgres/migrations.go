@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"strconv"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/sqlstore"
+	"github.com/lib/pq"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// The parameters are numbered and the slices are stored at array columns.
+var dialect = sqlstore.Dialect{
+	Name:        "postgres",
+	Placeholder: func(position int) string { return "$" + strconv.Itoa(position) },
+	Timestamp:   "TIMESTAMPTZ NOT NULL DEFAULT NOW()",
+	Array:       func(value interface{}) interface{} { return pq.Array(value) },
+	IsLocked:    func(field string) string { return "'" + field + "' = ANY(ports.locked)" },
+	LockedProvenance: `excluded.provenance || COALESCE((SELECT jsonb_object_agg(key, value)
+		FROM jsonb_each(ports.provenance) WHERE key = ANY(ports.locked)), '{}'::jsonb)`,
+}
+
+// Migrate applies every migration found at the migrations folder that was not applied yet.
+// The applied migrations are registered at the schema_migrations table.
+func Migrate(db *sql.DB) error {
+	return sqlstore.Migrate(db, dialect, migrationFiles)
+}
+
+// Pending retrieves the versions of the migrations found at the migrations folder that were
+// not applied yet, ordered by version.
+func Pending(ctx context.Context, db *sql.DB) ([]string, error) {
+	return sqlstore.Pending(ctx, db, migrationFiles)
+}
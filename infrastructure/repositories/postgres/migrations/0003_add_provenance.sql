@@ -0,0 +1,2 @@
+ALTER TABLE ports ADD COLUMN provenance JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE ports ADD COLUMN locked TEXT[] NOT NULL DEFAULT '{}';
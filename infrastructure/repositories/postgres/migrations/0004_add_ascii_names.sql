@@ -0,0 +1,2 @@
+ALTER TABLE ports ADD COLUMN name_ascii TEXT NOT NULL DEFAULT '';
+ALTER TABLE ports ADD COLUMN city_ascii TEXT NOT NULL DEFAULT '';
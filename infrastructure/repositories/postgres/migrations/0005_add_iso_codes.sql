@@ -0,0 +1,2 @@
+ALTER TABLE ports ADD COLUMN country_code TEXT NOT NULL DEFAULT '';
+ALTER TABLE ports ADD COLUMN subdivision_code TEXT NOT NULL DEFAULT '';
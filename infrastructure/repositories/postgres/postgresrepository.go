@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/sqlstore"
+	"github.com/cassiuspaim/portimporter/infrastructure/tracing"
+	"github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+var tracer = tracing.For("postgres")
+
+// Code raised by Postgres when a unique constraint is violated.
+const uniqueViolation = "23505"
+
+// PortRepository is the implementation for Postgres of domain.PortRepository.
+type PortRepository struct {
+	db *sql.DB
+}
+
+func NewPortRepository(db *sql.DB) PortRepository {
+	return PortRepository{
+		db: db,
+	}
+}
+
+func (p PortRepository) GetByID(ctx context.Context, id string) (_ *entities.Port, err error) {
+	ctx, span := tracing.StartRepositoryCall(ctx, tracer, semconv.DBSystemPostgreSQL, "postgres.get_by_id", id)
+	defer func() { tracing.End(span, err) }()
+
+	var portDB sqlstore.PortDB
+
+	row := p.db.QueryRowContext(ctx, dialect.SelectByKey(), id)
+
+	err = portDB.Scan(dialect, row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	port := portDB.To()
+
+	return &port, nil
+}
+
+func (p PortRepository) Create(ctx context.Context, port entities.Port) (err error) {
+	ctx, span := tracing.StartRepositoryCall(ctx, tracer, semconv.DBSystemPostgreSQL, "postgres.create", port.ID)
+	defer func() { tracing.End(span, err) }()
+
+	var portDB sqlstore.PortDB
+
+	values, err := portDB.From(port).Values(dialect)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.db.ExecContext(ctx, dialect.Insert(), values...)
+
+	var pqError *pq.Error
+	if errors.As(err, &pqError) && pqError.Code == uniqueViolation {
+		return fmt.Errorf("Error creating port %s. Error: %w", port.ID, domain.ErrPortAlreadyExists)
+	}
+
+	return err
+}
+
+func (p PortRepository) Update(ctx context.Context, port entities.Port, id string) (err error) {
+	ctx, span := tracing.StartRepositoryCall(ctx, tracer, semconv.DBSystemPostgreSQL, "postgres.update", id)
+	defer func() { tracing.End(span, err) }()
+
+	var portDB sqlstore.PortDB
+
+	values, err := portDB.From(port).Values(dialect)
+	if err != nil {
+		return err
+	}
+
+	// The key used as filter replaces the key of the port, the same way ReplaceOne does at Mongo.
+	values[0] = id
+
+	result, err := p.db.ExecContext(ctx, dialect.Update(), append(values, port.Version)...)
+	if err != nil {
+		return err
+	}
+
+	return sqlstore.VersionConflict(result, id)
+}
+
+// Upsert creates the Port or replaces it when a Port with the same key already exists, using a
+// single statement. A stored Port is only replaced at the version of the given one, otherwise
+// ErrVersionConflict is retrieved, and its locked fields keep their values and provenance.
+func (p PortRepository) Upsert(ctx context.Context, port entities.Port) (err error) {
+	ctx, span := tracing.StartRepositoryCall(ctx, tracer, semconv.DBSystemPostgreSQL, "postgres.upsert", port.ID)
+	defer func() { tracing.End(span, err) }()
+
+	var portDB sqlstore.PortDB
+
+	values, err := portDB.From(port).Values(dialect)
+	if err != nil {
+		return err
+	}
+
+	result, err := p.db.ExecContext(ctx, dialect.Upsert(), append(values, port.Version)...)
+	if err != nil {
+		return err
+	}
+
+	return sqlstore.VersionConflict(result, port.ID)
+}
+
+func (p PortRepository) ForEach(ctx context.Context, fn func(entities.Port) error) (err error) {
+	ctx, span := tracing.StartRepositoryCall(ctx, tracer, semconv.DBSystemPostgreSQL, "postgres.for_each", "")
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := p.db.QueryContext(ctx, sqlstore.SelectAll)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var portDB sqlstore.PortDB
+
+		if err = portDB.Scan(dialect, rows); err != nil {
+			return err
+		}
+
+		if err = fn(portDB.To()); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Codes raised by Postgres for transient conditions: serialization failures, deadlocks,
+// shutdowns, a server that is starting and too many connections.
+var retryableCodes = []pq.ErrorCode{"40001", "40P01", "57P01", "57P02", "57P03", "53300"}
+
+// Class of the codes raised by Postgres when the connection fails.
+const connectionException = "08"
+
+// IsRetryable retrieves true when err is transient: broken connections, timeouts, network
+// errors and the errors of Postgres for transient conditions. Canceled operations are not
+// retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var pqError *pq.Error
+	if errors.As(err, &pqError) {
+		return pqError.Code.Class() == connectionException || slices.Contains(retryableCodes, pqError.Code)
+	}
+
+	var netError net.Error
+
+	return errors.As(err, &netError)
+}
@@ -0,0 +1,360 @@
+// Package repositorytest has the conformance suite that every implementation of
+// domain.PortRepository must pass, so all backends behave the same way.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory retrieves the PortRepository under test. Repositories retrieved by the Factory
+// may share the same storage, every test of the suite uses its own Port IDs.
+type Factory func(t *testing.T) domain.PortRepository
+
+// Upserter is implemented by the repositories that create or replace a Port in a single statement.
+// The suite checks it when the PortRepository under test implements it.
+type Upserter interface {
+	Upsert(ctx context.Context, port entities.Port) error
+}
+
+// Retrieves the PortRepository under test as an Upserter, skipping the test when it is not one.
+func upserter(t *testing.T, factory Factory) (domain.PortRepository, Upserter) {
+	t.Helper()
+
+	portRepository := factory(t)
+
+	upserter, ok := portRepository.(Upserter)
+	if !ok {
+		t.Skip("The PortRepository does not upsert")
+	}
+
+	return portRepository, upserter
+}
+
+// Run runs the conformance suite against the PortRepository retrieved by factory.
+func Run(t *testing.T, factory Factory) {
+	t.Helper()
+
+	t.Run("Given an id of a non existing Port When GetByID is invoked Then no error and no Port is expected", func(t *testing.T) {
+		portRepository := factory(t)
+
+		port, err := portRepository.GetByID(context.Background(), "conformance-not-found")
+		assert.NoError(t, err, "Error must not be found")
+		assert.Nil(t, port, "Port must not exist")
+	})
+
+	t.Run("Given a Port is created When the Port is queried Then it must be equal to the created one", func(t *testing.T) {
+		portRepository := factory(t)
+		expectedPort := newPort("conformance-create")
+
+		err := portRepository.Create(context.Background(), expectedPort)
+		require.NoError(t, err, "Error must not be found creating Port")
+
+		port, err := portRepository.GetByID(context.Background(), expectedPort.ID)
+		assert.NoError(t, err, "Error must not be found querying Port")
+		assert.Equal(t, &expectedPort, port, "Port stored must be equal to the Port created")
+	})
+
+	t.Run("Given a Port is stored When the Port is updated Then the changes must be found", func(t *testing.T) {
+		portRepository := factory(t)
+		port := newPort("conformance-update")
+
+		err := portRepository.Create(context.Background(), port)
+		require.NoError(t, err, "Error must not be found creating Port")
+
+		port.Name = "other name"
+		port.Alias = []string{"alias3"}
+		port.Coordinates = []float64{-12.5, 7.25}
+		err = portRepository.Update(context.Background(), port, port.ID)
+		assert.NoError(t, err, "Error must not be found updating Port")
+
+		stored, err := portRepository.GetByID(context.Background(), port.ID)
+		assert.NoError(t, err, "Error must not be found querying Port")
+
+		port.Version = 1
+		assert.Equal(t, &port, stored, "Port stored must be equal to the Port updated, with the next version")
+	})
+
+	t.Run("Given a Port with provenance and locked fields When the Port is queried Then they must be kept", func(t *testing.T) {
+		portRepository := factory(t)
+		// Stores keep the times with millisecond precision at least.
+		imported := entities.Provenance{Source: "ports.json", RunID: "run1", UpdatedAt: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)}
+		port := newPort("conformance-provenance").WithProvenance(imported)
+		port.Locked = []string{"timezone"}
+
+		err := portRepository.Create(context.Background(), port)
+		require.NoError(t, err, "Error must not be found creating Port")
+
+		stored, err := portRepository.GetByID(context.Background(), port.ID)
+		assert.NoError(t, err, "Error must not be found querying Port")
+		assert.Equal(t, &port, stored, "Port stored must keep the provenance and the locked fields")
+
+		port.Name = "manual name"
+		port.Provenance["name"] = entities.Provenance{Source: entities.SourceManual, UpdatedAt: imported.UpdatedAt.Add(time.Hour)}
+		port.Locked = []string{"timezone", "name"}
+		err = portRepository.Update(context.Background(), port, port.ID)
+		assert.NoError(t, err, "Error must not be found updating Port")
+
+		stored, err = portRepository.GetByID(context.Background(), port.ID)
+		assert.NoError(t, err, "Error must not be found querying Port")
+
+		port.Version = 1
+		assert.Equal(t, &port, stored, "Port stored must have the updated provenance and locked fields")
+	})
+
+	t.Run("Given a Port changed since it was read When the Port is updated Then ErrVersionConflict is expected", func(t *testing.T) {
+		portRepository := factory(t)
+		port := newPort("conformance-update-conflict")
+
+		err := portRepository.Create(context.Background(), port)
+		require.NoError(t, err, "Error must not be found creating Port")
+
+		changed := port
+		changed.Name = "changed name"
+		require.NoError(t, portRepository.Update(context.Background(), changed, port.ID), "Error must not be found updating Port")
+
+		port.City = "other city"
+		err = portRepository.Update(context.Background(), port, port.ID)
+		assert.ErrorIs(t, err, domain.ErrVersionConflict, "Updating a Port changed since it was read must fail")
+
+		stored, err := portRepository.GetByID(context.Background(), port.ID)
+		assert.NoError(t, err, "Error must not be found querying Port")
+
+		changed.Version = 1
+		assert.Equal(t, &changed, stored, "Port stored must keep the changes made since it was read")
+	})
+
+	t.Run("Given a non existing Port When the Port is updated Then ErrVersionConflict is expected and the Port must not be created", func(t *testing.T) {
+		portRepository := factory(t)
+		port := newPort("conformance-update-not-found")
+
+		err := portRepository.Update(context.Background(), port, port.ID)
+		assert.ErrorIs(t, err, domain.ErrVersionConflict, "Updating a non existing Port must fail")
+
+		stored, err := portRepository.GetByID(context.Background(), port.ID)
+		assert.NoError(t, err, "Error must not be found querying Port")
+		assert.Nil(t, stored, "Port must not be created by Update")
+	})
+
+	t.Run("Given a Port is stored When a Port with the same ID is created Then ErrPortAlreadyExists is expected", func(t *testing.T) {
+		portRepository := factory(t)
+		port := newPort("conformance-duplicate")
+
+		err := portRepository.Create(context.Background(), port)
+		require.NoError(t, err, "Error must not be found creating Port")
+
+		duplicated := port
+		duplicated.Name = "duplicated"
+		err = portRepository.Create(context.Background(), duplicated)
+		assert.ErrorIs(t, err, domain.ErrPortAlreadyExists, "Creating a duplicated Port must fail")
+
+		stored, err := portRepository.GetByID(context.Background(), port.ID)
+		assert.NoError(t, err, "Error must not be found querying Port")
+		assert.Equal(t, &port, stored, "Port stored must not be changed by the duplicated one")
+	})
+
+	t.Run("Given a Port with unicode texts When the Port is queried Then the texts must be kept", func(t *testing.T) {
+		portRepository := factory(t)
+		port := newPort("conformance-unicode-ÅÉ")
+		port.Name = "Abū Ẓaby"
+		port.City = "東京"
+		port.Province = "Zürich 🚢"
+		port.Alias = []string{"Ñandú", "Ελλάδα"}
+
+		err := portRepository.Create(context.Background(), port)
+		require.NoError(t, err, "Error must not be found creating Port")
+
+		stored, err := portRepository.GetByID(context.Background(), port.ID)
+		assert.NoError(t, err, "Error must not be found querying Port")
+		assert.Equal(t, &port, stored, "Unicode texts must be kept")
+	})
+
+	t.Run("Given a Port with folded names When the Port is updated Then the folded names must be kept", func(t *testing.T) {
+		portRepository := factory(t)
+		port := newPort("conformance-ascii")
+		port.Name = "São Paulo"
+		port.NameASCII = "Sao Paulo"
+		port.City = "Zürich"
+		port.CityASCII = "Zurich"
+
+		err := portRepository.Create(context.Background(), port)
+		require.NoError(t, err, "Error must not be found creating Port")
+
+		stored, err := portRepository.GetByID(context.Background(), port.ID)
+		assert.NoError(t, err, "Error must not be found querying Port")
+		assert.Equal(t, &port, stored, "Port stored must keep the folded names")
+
+		port.City = "Malmö"
+		port.CityASCII = "Malmo"
+		err = portRepository.Update(context.Background(), port, port.ID)
+		assert.NoError(t, err, "Error must not be found updating Port")
+
+		stored, err = portRepository.GetByID(context.Background(), port.ID)
+		assert.NoError(t, err, "Error must not be found querying Port")
+
+		port.Version = 1
+		assert.Equal(t, &port, stored, "Port stored must have the updated folded names")
+	})
+
+	t.Run("Given a Port with ISO 3166 codes When the Port is queried Then the codes must be kept", func(t *testing.T) {
+		portRepository := factory(t)
+		port := newPort("conformance-iso-codes")
+		port.CountryCode = "AE"
+		port.SubdivisionCode = "AE-DU"
+
+		err := portRepository.Create(context.Background(), port)
+		require.NoError(t, err, "Error must not be found creating Port")
+
+		stored, err := portRepository.GetByID(context.Background(), port.ID)
+		assert.NoError(t, err, "Error must not be found querying Port")
+		assert.Equal(t, &port, stored, "Port stored must keep the codes")
+	})
+
+	t.Run("Given a stored Port at its version When the Port is upserted Then it must be replaced", func(t *testing.T) {
+		portRepository, upserter := upserter(t, factory)
+		port := newPort("conformance-upsert")
+
+		require.NoError(t, upserter.Upsert(context.Background(), port), "Error must not be found creating Port")
+
+		port.Alias = []string{"alias3"}
+		require.NoError(t, upserter.Upsert(context.Background(), port), "Error must not be found replacing Port")
+
+		stored, err := portRepository.GetByID(context.Background(), port.ID)
+		assert.NoError(t, err, "Error must not be found querying Port")
+
+		port.Version = 1
+		assert.Equal(t, &port, stored, "Port stored must be the upserted one, with the next version")
+	})
+
+	t.Run("Given a Port changed since it was read When the Port is upserted Then ErrVersionConflict is expected", func(t *testing.T) {
+		portRepository, upserter := upserter(t, factory)
+		port := newPort("conformance-upsert-conflict")
+
+		require.NoError(t, portRepository.Create(context.Background(), port), "Error must not be found creating Port")
+
+		changed := port
+		changed.Name = "changed name"
+		require.NoError(t, portRepository.Update(context.Background(), changed, port.ID), "Error must not be found updating Port")
+
+		port.City = "other city"
+		err := upserter.Upsert(context.Background(), port)
+		assert.ErrorIs(t, err, domain.ErrVersionConflict, "Upserting a Port changed since it was read must fail")
+
+		stored, err := portRepository.GetByID(context.Background(), port.ID)
+		assert.NoError(t, err, "Error must not be found querying Port")
+
+		changed.Version = 1
+		assert.Equal(t, &changed, stored, "Port stored must keep the changes made since it was read")
+	})
+
+	t.Run("Given a Port with locked fields When the Port is upserted Then the locked fields must be kept", func(t *testing.T) {
+		portRepository, upserter := upserter(t, factory)
+		manual := entities.Provenance{Source: entities.SourceManual, UpdatedAt: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)}
+		port := newPort("conformance-upsert-locked").WithProvenance(manual)
+		port.NameASCII = "name"
+		port.Locked = []string{"name", "timezone"}
+
+		require.NoError(t, portRepository.Create(context.Background(), port), "Error must not be found creating Port")
+
+		imported := entities.Provenance{Source: "ports.json", RunID: "run2", UpdatedAt: manual.UpdatedAt.Add(time.Hour)}
+		upserted := newPort(port.ID).WithProvenance(imported)
+		upserted.Name, upserted.NameASCII = "imported name", "imported name"
+		upserted.Timezone = "Asia/Dubai"
+		upserted.City = "imported city"
+		require.NoError(t, upserter.Upsert(context.Background(), upserted), "Error must not be found upserting Port")
+
+		stored, err := portRepository.GetByID(context.Background(), port.ID)
+		require.NoError(t, err, "Error must not be found querying Port")
+		require.NotNil(t, stored, "Port must exist")
+
+		assert.Equal(t, "name", stored.Name, "Locked fields must keep their values")
+		assert.Equal(t, "name", stored.NameASCII, "Values derived from locked fields must be kept")
+		assert.Equal(t, "timezone", stored.Timezone, "Locked fields must keep their values")
+		assert.Equal(t, "imported city", stored.City, "Fields not locked must be replaced")
+		assert.Equal(t, manual, stored.Provenance["name"], "Locked fields must keep their provenance")
+		assert.Equal(t, imported, stored.Provenance["city"], "Fields not locked must take the upserted provenance")
+		assert.Equal(t, []string{"name", "timezone"}, stored.Locked, "Locked fields must stay locked")
+	})
+
+	t.Run("Given a Port with nil and empty slices When the Port is queried Then empty slices must be found", func(t *testing.T) {
+		portRepository := factory(t)
+		port := entities.Port{
+			ID:      "conformance-empty-slices",
+			Name:    "name",
+			Alias:   []string{},
+			Regions: nil,
+		}
+
+		err := portRepository.Create(context.Background(), port)
+		require.NoError(t, err, "Error must not be found creating Port")
+
+		stored, err := portRepository.GetByID(context.Background(), port.ID)
+		require.NoError(t, err, "Error must not be found querying Port")
+		require.NotNil(t, stored, "Port must exist")
+		assert.Equal(t, []string{}, stored.Alias, "Empty Alias must be retrieved as empty")
+		assert.Equal(t, []string{}, stored.Regions, "Nil Regions must be retrieved as empty")
+		assert.Equal(t, []string{}, stored.Unlocs, "Nil Unlocs must be retrieved as empty")
+		assert.Equal(t, []float64{}, stored.Coordinates, "Nil Coordinates must be retrieved as empty")
+	})
+
+	t.Run("Given Ports are stored When ForEach is invoked Then every Port must be found ordered by ID", func(t *testing.T) {
+		portRepository := factory(t)
+		prefix := "conformance-foreach-"
+
+		for _, id := range []string{"b", "c", "a"} {
+			require.NoError(t, portRepository.Create(context.Background(), newPort(prefix+id)), "Error must not be found creating Port")
+		}
+
+		var ids []string
+
+		err := portRepository.ForEach(context.Background(), func(port entities.Port) error {
+			if strings.HasPrefix(port.ID, prefix) {
+				ids = append(ids, port.ID)
+			}
+
+			return nil
+		})
+		assert.NoError(t, err, "Error must not be found iterating Ports")
+		assert.Equal(t, []string{prefix + "a", prefix + "b", prefix + "c"}, ids, "Ports must be ordered by ID")
+	})
+
+	t.Run("Given Ports are stored When the ForEach function fails Then the iteration must stop with the error", func(t *testing.T) {
+		portRepository := factory(t)
+		expectedError := errors.New("stop")
+
+		require.NoError(t, portRepository.Create(context.Background(), newPort("conformance-foreach-error-a")), "Error must not be found creating Port")
+		require.NoError(t, portRepository.Create(context.Background(), newPort("conformance-foreach-error-b")), "Error must not be found creating Port")
+
+		calls := 0
+		err := portRepository.ForEach(context.Background(), func(port entities.Port) error {
+			calls++
+
+			return expectedError
+		})
+		assert.ErrorIs(t, err, expectedError, "The error of the function must be retrieved")
+		assert.Equal(t, 1, calls, "The iteration must stop at the first error")
+	})
+}
+
+func newPort(id string) entities.Port {
+	return entities.NewPort(
+		id,
+		"name",
+		"city",
+		"country",
+		[]string{"alias1", "alias2"},
+		[]string{"region1", "region2"},
+		[]float64{43.434343434, 35.2423434},
+		"province",
+		"timezone",
+		[]string{"unloc1", "unloc2"},
+		"code")
+}
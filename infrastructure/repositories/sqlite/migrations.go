@@ -0,0 +1,42 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"strconv"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/sqlstore"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// The slices are stored as JSON arrays because SQLite has no array columns.
+var dialect = sqlstore.Dialect{
+	Name:        "sqlite",
+	Placeholder: func(position int) string { return "?" + strconv.Itoa(position) },
+	Timestamp:   "TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP",
+	Array:       func(value interface{}) interface{} { return sqlstore.JSONArray{Slice: value} },
+	IsLocked: func(field string) string {
+		return "EXISTS (SELECT 1 FROM json_each(ports.locked) WHERE value = '" + field + "')"
+	},
+	LockedProvenance: `(SELECT json_group_object(key, json(value)) FROM (
+		SELECT key, value FROM json_each(excluded.provenance)
+		WHERE key NOT IN (SELECT value FROM json_each(ports.locked))
+		UNION ALL
+		SELECT key, value FROM json_each(ports.provenance)
+		WHERE key IN (SELECT value FROM json_each(ports.locked))))`,
+}
+
+// Migrate applies every migration found at the migrations folder that was not applied yet.
+// The applied migrations are registered at the schema_migrations table.
+func Migrate(db *sql.DB) error {
+	return sqlstore.Migrate(db, dialect, migrationFiles)
+}
+
+// Pending retrieves the versions of the migrations found at the migrations folder that were
+// not applied yet, ordered by version.
+func Pending(ctx context.Context, db *sql.DB) ([]string, error) {
+	return sqlstore.Pending(ctx, db, migrationFiles)
+}
@@ -0,0 +1 @@
+ALTER TABLE ports ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,189 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/sqlstore"
+	"github.com/cassiuspaim/portimporter/infrastructure/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	// Pure Go SQLite driver registered as "sqlite", it does not require CGO.
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var tracer = tracing.For("sqlite")
+
+// PortRepository is the implementation for SQLite of domain.PortRepository.
+type PortRepository struct {
+	db *sql.DB
+}
+
+func NewPortRepository(db *sql.DB) PortRepository {
+	return PortRepository{
+		db: db,
+	}
+}
+
+// Open opens the SQLite database file at path, creating it when it does not exist,
+// and applies the migrations.
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)", path))
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows only one writer at a time.
+	db.SetMaxOpenConns(1)
+
+	if err = Migrate(db); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func (p PortRepository) GetByID(ctx context.Context, id string) (_ *entities.Port, err error) {
+	ctx, span := tracing.StartRepositoryCall(ctx, tracer, semconv.DBSystemSqlite, "sqlite.get_by_id", id)
+	defer func() { tracing.End(span, err) }()
+
+	var portDB sqlstore.PortDB
+
+	row := p.db.QueryRowContext(ctx, dialect.SelectByKey(), id)
+
+	err = portDB.Scan(dialect, row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	port := portDB.To()
+
+	return &port, nil
+}
+
+func (p PortRepository) Create(ctx context.Context, port entities.Port) (err error) {
+	ctx, span := tracing.StartRepositoryCall(ctx, tracer, semconv.DBSystemSqlite, "sqlite.create", port.ID)
+	defer func() { tracing.End(span, err) }()
+
+	var portDB sqlstore.PortDB
+
+	values, err := portDB.From(port).Values(dialect)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.db.ExecContext(ctx, dialect.Insert(), values...)
+
+	// The primary result code is kept at the lowest byte of the extended ones.
+	var sqliteError *sqlite.Error
+	if errors.As(err, &sqliteError) && sqliteError.Code()&0xff == sqlite3.SQLITE_CONSTRAINT {
+		return fmt.Errorf("Error creating port %s. Error: %w", port.ID, domain.ErrPortAlreadyExists)
+	}
+
+	return err
+}
+
+func (p PortRepository) Update(ctx context.Context, port entities.Port, id string) (err error) {
+	ctx, span := tracing.StartRepositoryCall(ctx, tracer, semconv.DBSystemSqlite, "sqlite.update", id)
+	defer func() { tracing.End(span, err) }()
+
+	var portDB sqlstore.PortDB
+
+	values, err := portDB.From(port).Values(dialect)
+	if err != nil {
+		return err
+	}
+
+	// The key used as filter replaces the key of the port, the same way ReplaceOne does at Mongo.
+	values[0] = id
+
+	result, err := p.db.ExecContext(ctx, dialect.Update(), append(values, port.Version)...)
+	if err != nil {
+		return err
+	}
+
+	return sqlstore.VersionConflict(result, id)
+}
+
+// Upsert creates the Port or replaces it when a Port with the same key already exists, using a
+// single statement. A stored Port is only replaced at the version of the given one, otherwise
+// ErrVersionConflict is retrieved, and its locked fields keep their values and provenance.
+func (p PortRepository) Upsert(ctx context.Context, port entities.Port) (err error) {
+	ctx, span := tracing.StartRepositoryCall(ctx, tracer, semconv.DBSystemSqlite, "sqlite.upsert", port.ID)
+	defer func() { tracing.End(span, err) }()
+
+	var portDB sqlstore.PortDB
+
+	values, err := portDB.From(port).Values(dialect)
+	if err != nil {
+		return err
+	}
+
+	result, err := p.db.ExecContext(ctx, dialect.Upsert(), append(values, port.Version)...)
+	if err != nil {
+		return err
+	}
+
+	return sqlstore.VersionConflict(result, port.ID)
+}
+
+func (p PortRepository) ForEach(ctx context.Context, fn func(entities.Port) error) (err error) {
+	ctx, span := tracing.StartRepositoryCall(ctx, tracer, semconv.DBSystemSqlite, "sqlite.for_each", "")
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := p.db.QueryContext(ctx, sqlstore.SelectAll)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Ports are read before calling fn because SQLite keeps a single connection open,
+	// so fn would not be able to query the repository while rows are open.
+	var ports []entities.Port
+
+	for rows.Next() {
+		var portDB sqlstore.PortDB
+
+		if err = portDB.Scan(dialect, rows); err != nil {
+			return err
+		}
+
+		ports = append(ports, portDB.To())
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, port := range ports {
+		if err = fn(port); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsRetryable retrieves true when err is raised because the database file is busy or locked
+// by another connection.
+func IsRetryable(err error) bool {
+	var sqliteError *sqlite.Error
+	if !errors.As(err, &sqliteError) {
+		return false
+	}
+
+	code := sqliteError.Code() & 0xff
+
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
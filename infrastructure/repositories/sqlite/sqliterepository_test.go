@@ -0,0 +1,189 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/repositorytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRepository(t *testing.T) PortRepository {
+	t.Helper()
+
+	db, err := Open(filepath.Join(t.TempDir(), "ports.db"))
+	require.NoError(t, err, "Error must not be found opening database")
+	t.Cleanup(func() { db.Close() })
+
+	return NewPortRepository(db)
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+	t.Run("Given an existing database file When opening it again Then the migrations must not fail", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "ports.db")
+
+		db, err := Open(path)
+		require.NoError(t, err, "Error must not be found opening database")
+		db.Close()
+
+		db, err = Open(path)
+		assert.NoError(t, err, "Migrations must be idempotent")
+		db.Close()
+	})
+
+	t.Run("Given an opened database When listing the pending migrations Then none must be found", func(t *testing.T) {
+		t.Parallel()
+
+		db, err := Open(filepath.Join(t.TempDir(), "ports.db"))
+		require.NoError(t, err, "Error must not be found opening database")
+		defer db.Close()
+
+		pending, err := Pending(context.Background(), db)
+		assert.NoError(t, err, "Error must not be found listing pending migrations")
+		assert.Empty(t, pending, "Every migration must be applied")
+	})
+
+	t.Run("Given a database without migrations When listing the pending migrations Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "ports.db"))
+		require.NoError(t, err, "Error must not be found opening database")
+		defer db.Close()
+
+		_, err = Pending(context.Background(), db)
+		assert.Error(t, err, "The migrations table does not exist")
+	})
+}
+
+func TestFindPorts(t *testing.T) {
+	t.Parallel()
+	t.Run("Given an id of a non existing Port When GetByID is invoked Then no error and not Port is expected", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := newTestRepository(t)
+
+		port, err := portRepository.GetByID(context.Background(), "idunique")
+		assert.Nil(t, port, "Port must not exist at database")
+		assert.NoError(t, err, "Error must not be found")
+	})
+
+	t.Run("Given a Port is created When the Port is queried it must be found", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := newTestRepository(t)
+		expectedPort := entities.NewPort(
+			"id",
+			"name",
+			"city",
+			"country",
+			[]string{"alias1", "alias2"},
+			[]string{"region1", "region2"},
+			[]float64{43.434343434, 35.2423434},
+			"province",
+			"timezone",
+			[]string{"unloc1", "unloc2"},
+			"code")
+
+		err := portRepository.Create(context.Background(), expectedPort)
+		assert.NoError(t, err, "Error must not be found creating Port")
+
+		port, err := portRepository.GetByID(context.Background(), "id")
+		assert.NoError(t, err, "Error must not be found quering Port")
+		assert.Equal(t, &expectedPort, port, "Port stored must be equal to the Port created")
+	})
+
+	t.Run("Given a Port is stored When the Port is updated Then the changes must be found", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := newTestRepository(t)
+		idPort := "idx"
+
+		port := entities.NewPort(
+			idPort,
+			"name",
+			"city",
+			"country",
+			[]string{"alias1", "alias2"},
+			[]string{"region1", "region2"},
+			[]float64{43.434343434, 35.2423434},
+			"province",
+			"timezone",
+			[]string{"unloc1", "unloc2"},
+			"code")
+		err := portRepository.Create(context.Background(), port)
+		assert.NoError(t, err, "Error must not be found creating Port")
+
+		expectedCity := "Other city"
+		port.City = expectedCity
+		err = portRepository.Update(context.Background(), port, idPort)
+		assert.NoError(t, err, "Error must not be found quering Port")
+		portExisting, _ := portRepository.GetByID(context.Background(), idPort)
+		assert.Equal(t, expectedCity, portExisting.City)
+	})
+
+	t.Run("Given a Port with nil slices When the Port is created Then empty slices must be found", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := newTestRepository(t)
+		idPort := "idnil"
+
+		err := portRepository.Create(context.Background(), entities.Port{ID: idPort, Name: "name"})
+		assert.NoError(t, err, "Error must not be found creating Port")
+
+		port, err := portRepository.GetByID(context.Background(), idPort)
+		assert.NoError(t, err, "Error must not be found quering Port")
+		assert.Equal(t, []string{}, port.Alias, "Alias must be empty")
+		assert.Equal(t, []float64{}, port.Coordinates, "Coordinates must be empty")
+	})
+}
+
+func TestUpsertPorts(t *testing.T) {
+	t.Parallel()
+	t.Run("Given a Port is upserted twice When the Port is queried Then the last version must be found", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := newTestRepository(t)
+		idPort := "idupsert"
+
+		port := entities.NewPort(
+			idPort,
+			"name",
+			"city",
+			"country",
+			[]string{"alias1"},
+			[]string{"region1"},
+			[]float64{43.434343434, 35.2423434},
+			"province",
+			"timezone",
+			[]string{"unloc1"},
+			"code")
+		err := portRepository.Upsert(context.Background(), port)
+		assert.NoError(t, err, "Error must not be found inserting Port")
+
+		port.Alias = []string{"alias1", "alias2"}
+		err = portRepository.Upsert(context.Background(), port)
+		assert.NoError(t, err, "Error must not be found updating Port")
+
+		portExisting, err := portRepository.GetByID(context.Background(), idPort)
+		assert.NoError(t, err, "Error must not be found quering Port")
+		assert.Equal(t, []string{"alias1", "alias2"}, portExisting.Alias)
+	})
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	repositorytest.Run(t, func(t *testing.T) domain.PortRepository {
+		t.Helper()
+
+		return newTestRepository(t)
+	})
+}
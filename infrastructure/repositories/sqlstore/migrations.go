@@ -0,0 +1,140 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+)
+
+// Migrate applies every migration found at the migrations folder of files that was not applied
+// yet, in the order of their names. The applied migrations are registered at the schema_migrations
+// table.
+func Migrate(db *sql.DB, dialect Dialect, files fs.FS) error {
+	_, err := db.ExecContext(context.TODO(), `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at `+dialect.Timestamp+`
+	)`)
+	if err != nil {
+		return fmt.Errorf("Error creating schema_migrations table. Error: %w", err)
+	}
+
+	names, err := migrations(files)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		err = applyMigration(db, dialect, files, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Retrieves the names of the migrations found at the migrations folder of files, ordered.
+func migrations(files fs.FS) ([]string, error) {
+	names, err := fs.Glob(files, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// Retrieves the version of the migration file, its name without folder nor extension.
+func version(name string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+}
+
+func applyMigration(db *sql.DB, dialect Dialect, files fs.FS, name string) error {
+	var applied bool
+
+	err := db.QueryRowContext(context.TODO(),
+		"SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = "+dialect.Placeholder(1)+")",
+		version(name)).Scan(&applied)
+	if err != nil {
+		return fmt.Errorf("Error checking migration %s. Error: %w", version(name), err)
+	}
+
+	if applied {
+		return nil
+	}
+
+	content, err := fs.ReadFile(files, name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(context.TODO(), nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(context.TODO(), string(content)); err != nil {
+		_ = tx.Rollback()
+
+		return fmt.Errorf("Error applying migration %s. Error: %w", version(name), err)
+	}
+
+	_, err = tx.ExecContext(context.TODO(),
+		"INSERT INTO schema_migrations (version) VALUES ("+dialect.Placeholder(1)+")", version(name))
+	if err != nil {
+		_ = tx.Rollback()
+
+		return fmt.Errorf("Error registering migration %s. Error: %w", version(name), err)
+	}
+
+	logging.For(dialect.Name).Info("Migration applied", "version", version(name))
+
+	return tx.Commit()
+}
+
+// Pending retrieves the versions of the migrations found at the migrations folder of files that
+// were not applied yet, ordered by version.
+func Pending(ctx context.Context, db *sql.DB, files fs.FS) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("Error listing applied migrations. Error: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[string]bool{}
+
+	for rows.Next() {
+		var version string
+
+		if err = rows.Scan(&version); err != nil {
+			return nil, err
+		}
+
+		applied[version] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	names, err := migrations(files)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []string{}
+
+	for _, name := range names {
+		if !applied[version(name)] {
+			pending = append(pending, version(name))
+		}
+	}
+
+	return pending, nil
+}
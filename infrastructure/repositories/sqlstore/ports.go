@@ -0,0 +1,350 @@
+// Package sqlstore has what the SQL implementations of domain.PortRepository share: the migration
+// runner, the mapping of the Ports to the rows of the ports table and its statements. Each database
+// gives a Dialect with what differs, so a change of the schema is made once for all of them, besides
+// its migrations.
+package sqlstore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+)
+
+// Dialect tells how a database differs from the others.
+type Dialect struct {
+	// Name of the database, used as component of the logs.
+	Name string
+	// Placeholder retrieves the placeholder of the parameter at the position, starting at 1.
+	Placeholder func(position int) string
+	// Timestamp is the definition of the columns of times that default to the current time.
+	Timestamp string
+	// Array retrieves the value written for a slice, or the destination read into a pointer to a
+	// slice.
+	Array func(value interface{}) interface{}
+	// IsLocked retrieves the condition telling whether the field is locked at the stored Port, at
+	// the statements of Upsert.
+	IsLocked func(field string) string
+	// LockedProvenance is the provenance written by Upsert over the stored one: the imported one,
+	// with the stored provenance of the locked fields.
+	LockedProvenance string
+}
+
+const portColumns = "key, name, city, country, alias, regions, coordinates, province, timezone, unlocs, code, " +
+	"provenance, locked, name_ascii, city_ascii, country_code, subdivision_code"
+
+// Columns read from the ports table, the version is written by the statements themselves.
+const selectColumns = portColumns + ", version"
+
+// Retrieves the placeholders of the parameters from 1 to count, separated by commas.
+func (d Dialect) placeholders(count int) string {
+	placeholders := make([]string, count)
+	for i := range placeholders {
+		placeholders[i] = d.Placeholder(i + 1)
+	}
+
+	return strings.Join(placeholders, ", ")
+}
+
+// SelectByKey retrieves the statement reading the Port whose key is the first parameter.
+func (d Dialect) SelectByKey() string {
+	return "SELECT " + selectColumns + " FROM ports WHERE key = " + d.Placeholder(1)
+}
+
+// SelectAll is the statement reading every Port ordered by key.
+const SelectAll = "SELECT " + selectColumns + " FROM ports ORDER BY key"
+
+// Insert retrieves the statement creating a Port from the Values of a PortDB.
+func (d Dialect) Insert() string {
+	return "INSERT INTO ports (" + portColumns + ") VALUES (" + d.placeholders(strings.Count(portColumns, ",")+1) + ")"
+}
+
+// Update retrieves the statement replacing the Port whose key is the first of the Values of a
+// PortDB, followed by the version it must have.
+func (d Dialect) Update() string {
+	p := d.Placeholder
+
+	return `UPDATE ports SET name = ` + p(2) + `, city = ` + p(3) + `, country = ` + p(4) + `, alias = ` + p(5) +
+		`, regions = ` + p(6) + `, coordinates = ` + p(7) + `, province = ` + p(8) + `, timezone = ` + p(9) +
+		`, unlocs = ` + p(10) + `, code = ` + p(11) + `, provenance = ` + p(12) + `, locked = ` + p(13) +
+		`, name_ascii = ` + p(14) + `, city_ascii = ` + p(15) + `, country_code = ` + p(16) +
+		`, subdivision_code = ` + p(17) + `, version = version + 1 WHERE key = ` + p(1) + ` AND version = ` + p(18)
+}
+
+// Columns of each field of a Port. The columns derived from a field follow it.
+var fieldColumns = []struct {
+	field   string
+	columns []string
+}{
+	{"name", []string{"name", "name_ascii"}},
+	{"city", []string{"city", "city_ascii"}},
+	{"country", []string{"country", "country_code"}},
+	{"alias", []string{"alias"}},
+	{"regions", []string{"regions"}},
+	{"coordinates", []string{"coordinates"}},
+	{"province", []string{"province", "subdivision_code"}},
+	{"timezone", []string{"timezone"}},
+	{"unlocs", []string{"unlocs"}},
+	{"code", []string{"code"}},
+}
+
+// Upsert retrieves the statement creating a Port from the Values of a PortDB, or replacing the
+// stored one when its version is the one following the Values, the same way Update does. The locked
+// fields of the stored Port keep their values, the values derived from them and their provenance,
+// and so do the locked fields themselves.
+func (d Dialect) Upsert() string {
+	set := make([]string, 0, len(fieldColumns)+2)
+
+	for _, field := range fieldColumns {
+		for _, column := range field.columns {
+			set = append(set, fmt.Sprintf("%s = CASE WHEN %s THEN ports.%s ELSE excluded.%s END", column,
+				d.IsLocked(field.field), column, column))
+		}
+	}
+
+	set = append(set, "provenance = "+d.LockedProvenance, "version = ports.version + 1")
+
+	return d.Insert() + " ON CONFLICT (key) DO UPDATE SET " + strings.Join(set, ", ") +
+		" WHERE ports.version = " + d.Placeholder(strings.Count(portColumns, ",")+2)
+}
+
+// VersionConflict retrieves ErrVersionConflict when the update did not find the Port at its
+// version.
+func VersionConflict(result sql.Result, id string) error {
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updated == 0 {
+		return fmt.Errorf("Error updating port %s. Error: %w", id, domain.ErrVersionConflict)
+	}
+
+	return nil
+}
+
+// PortDB is a Port as a row of the ports table.
+type PortDB struct {
+	Key             string
+	Name            string
+	City            string
+	Country         string
+	Alias           []string
+	Regions         []string
+	Coordinates     []float64
+	Province        string
+	Timezone        string
+	Unlocs          []string
+	Code            string
+	NameASCII       string
+	CityASCII       string
+	CountryCode     string
+	SubdivisionCode string
+	Version         int64
+	Provenance      map[string]ProvenanceDB
+	Locked          []string
+}
+
+// ProvenanceDB is the provenance of a field, stored in a JSON object by field name.
+type ProvenanceDB struct {
+	Source    string    `json:"source"`
+	RunID     string    `json:"run_id,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Retrieves a PortDB based on entities.Port passed by parameter.
+// Nil slices are replaced by empty ones because the array columns are not nullable.
+func (p PortDB) From(port entities.Port) PortDB {
+	return PortDB{
+		Key:             port.ID,
+		Name:            port.Name,
+		City:            port.City,
+		Country:         port.Country,
+		Alias:           nonNilStrings(port.Alias),
+		Regions:         nonNilStrings(port.Regions),
+		Coordinates:     nonNilFloats(port.Coordinates),
+		Province:        port.Province,
+		Timezone:        port.Timezone,
+		Unlocs:          nonNilStrings(port.Unlocs),
+		Code:            port.Code,
+		NameASCII:       port.NameASCII,
+		CityASCII:       port.CityASCII,
+		CountryCode:     port.CountryCode,
+		SubdivisionCode: port.SubdivisionCode,
+		Version:         port.Version,
+		Provenance:      fromProvenance(port.Provenance),
+		Locked:          nonNilStrings(port.Locked),
+	}
+}
+
+// Retrieves an entities.Port based on the PortDB.
+func (p PortDB) To() entities.Port {
+	return entities.Port{
+		ID:              p.Key,
+		Name:            p.Name,
+		City:            p.City,
+		Country:         p.Country,
+		Alias:           p.Alias,
+		Regions:         p.Regions,
+		Coordinates:     p.Coordinates,
+		Province:        p.Province,
+		Timezone:        p.Timezone,
+		Unlocs:          p.Unlocs,
+		Code:            p.Code,
+		NameASCII:       p.NameASCII,
+		CityASCII:       p.CityASCII,
+		CountryCode:     p.CountryCode,
+		SubdivisionCode: p.SubdivisionCode,
+		Version:         p.Version,
+		Provenance:      toProvenance(p.Provenance),
+		Locked:          nilIfEmpty(p.Locked),
+	}
+}
+
+// Retrieves the provenance to store. Nil maps are stored as empty objects.
+func fromProvenance(provenance map[string]entities.Provenance) map[string]ProvenanceDB {
+	stored := make(map[string]ProvenanceDB, len(provenance))
+	for name, value := range provenance {
+		stored[name] = ProvenanceDB{Source: value.Source, RunID: value.RunID, UpdatedAt: value.UpdatedAt.UTC()}
+	}
+
+	return stored
+}
+
+// Retrieves the provenance of the entities.Port, nil when empty.
+func toProvenance(stored map[string]ProvenanceDB) map[string]entities.Provenance {
+	if len(stored) == 0 {
+		return nil
+	}
+
+	provenance := make(map[string]entities.Provenance, len(stored))
+	for name, value := range stored {
+		provenance[name] = entities.Provenance{Source: value.Source, RunID: value.RunID, UpdatedAt: value.UpdatedAt}
+	}
+
+	return provenance
+}
+
+func nilIfEmpty(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	return values
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
+
+func nonNilFloats(values []float64) []float64 {
+	if values == nil {
+		return []float64{}
+	}
+
+	return values
+}
+
+// Values retrieves the values of the PortDB in the same order of the columns written by Insert.
+func (p PortDB) Values(dialect Dialect) ([]interface{}, error) {
+	provenance, err := json.Marshal(p.Provenance)
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{
+		p.Key,
+		p.Name,
+		p.City,
+		p.Country,
+		dialect.Array(p.Alias),
+		dialect.Array(p.Regions),
+		dialect.Array(p.Coordinates),
+		p.Province,
+		p.Timezone,
+		dialect.Array(p.Unlocs),
+		p.Code,
+		string(provenance),
+		dialect.Array(p.Locked),
+		p.NameASCII,
+		p.CityASCII,
+		p.CountryCode,
+		p.SubdivisionCode,
+	}, nil
+}
+
+// Scan reads the columns read by SelectByKey and SelectAll into the PortDB.
+func (p *PortDB) Scan(dialect Dialect, row interface{ Scan(...interface{}) error }) error {
+	var provenance []byte
+
+	err := row.Scan(
+		&p.Key,
+		&p.Name,
+		&p.City,
+		&p.Country,
+		dialect.Array(&p.Alias),
+		dialect.Array(&p.Regions),
+		dialect.Array(&p.Coordinates),
+		&p.Province,
+		&p.Timezone,
+		dialect.Array(&p.Unlocs),
+		&p.Code,
+		&provenance,
+		dialect.Array(&p.Locked),
+		&p.NameASCII,
+		&p.CityASCII,
+		&p.CountryCode,
+		&p.SubdivisionCode,
+		&p.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(provenance, &p.Provenance); err != nil {
+		return fmt.Errorf("Error decoding the provenance of port %s. Error: %w", p.Key, err)
+	}
+
+	return nil
+}
+
+// JSONArray is a slice stored as a JSON array, for the databases without array columns. It is
+// written from a slice and read into a pointer to a slice. Nil slices are stored as empty arrays.
+type JSONArray struct {
+	Slice interface{}
+}
+
+// Value retrieves the JSON array of the slice.
+func (a JSONArray) Value() (driver.Value, error) {
+	content, err := json.Marshal(a.Slice)
+	if err != nil {
+		return nil, err
+	}
+
+	if string(content) == "null" {
+		return "[]", nil
+	}
+
+	return string(content), nil
+}
+
+// Scan reads the JSON array into the slice.
+func (a JSONArray) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(src), a.Slice)
+	case []byte:
+		return json.Unmarshal(src, a.Slice)
+	default:
+		return fmt.Errorf("Error decoding JSON array. Error: unsupported type %T", src)
+	}
+}
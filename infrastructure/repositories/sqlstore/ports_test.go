@@ -0,0 +1,67 @@
+package sqlstore
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var numbered = Dialect{
+	Name:        "numbered",
+	Placeholder: func(position int) string { return "$" + strconv.Itoa(position) },
+	Array:       func(value interface{}) interface{} { return JSONArray{Slice: value} },
+}
+
+func TestStatements(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a dialect When building the statements Then its placeholders are expected", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, "SELECT "+selectColumns+" FROM ports WHERE key = $1", numbered.SelectByKey())
+		assert.Contains(t, numbered.Insert(), "VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)")
+		assert.Contains(t, numbered.Update(), "WHERE key = $1 AND version = $18")
+	})
+
+	t.Run("Given a PortDB When retrieving its values Then one value for each inserted column is expected", func(t *testing.T) {
+		t.Parallel()
+
+		values, err := PortDB{}.From(entities.Port{ID: "AEAJM"}).Values(numbered)
+		require.NoError(t, err)
+
+		assert.Len(t, values, 17)
+		assert.Equal(t, "AEAJM", values[0])
+		assert.Equal(t, "{}", values[11], "Nil provenance must be stored as an empty object")
+	})
+}
+
+func TestJSONArray(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a nil slice When writing it Then an empty array is expected", func(t *testing.T) {
+		t.Parallel()
+
+		value, err := JSONArray{Slice: []string(nil)}.Value()
+		require.NoError(t, err)
+		assert.Equal(t, "[]", value)
+	})
+
+	t.Run("Given a JSON array When reading it Then the slice is expected", func(t *testing.T) {
+		t.Parallel()
+
+		var coordinates []float64
+
+		require.NoError(t, JSONArray{Slice: &coordinates}.Scan("[55.5,25.4]"))
+		assert.Equal(t, []float64{55.5, 25.4}, coordinates)
+
+		var alias []string
+
+		require.NoError(t, JSONArray{Slice: &alias}.Scan([]byte(`["Ajman"]`)))
+		assert.Equal(t, []string{"Ajman"}, alias)
+
+		assert.Error(t, JSONArray{Slice: &alias}.Scan(42))
+	})
+}
@@ -0,0 +1,29 @@
+package retry
+
+import (
+	"context"
+
+	"github.com/cassiuspaim/portimporter/domain/services"
+)
+
+// ImportService is the services.Importer that reports the retries of the operations of the store
+// made by the imports of the decorated one, when its store is a PortRepository of this package.
+type ImportService struct {
+	importService services.Importer
+}
+
+// Retrieves a new ImportService counting the retries of the imports of importService.
+func NewImportService(importService services.Importer) ImportService {
+	return ImportService{importService: importService}
+}
+
+// Import the entries through the decorated Importer, adding the retries made during the import to
+// the ones its Report already counts.
+func (s ImportService) Import(ctx context.Context, entries <-chan services.ImportEntry) services.Report {
+	ctx, counter := WithCounter(ctx)
+
+	report := s.importService.Import(ctx, entries)
+	report.Retries += counter.Retries()
+
+	return report
+}
@@ -0,0 +1,63 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/domain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportService(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given transient errors of the repository When importing Then the report must count the retries", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		portRepository := NewPortRepository(domain.MockPortRepository{
+			GetByIDfn: func(id string) (*entities.Port, error) {
+				calls++
+				if calls == 1 {
+					return nil, errTransient
+				}
+
+				return nil, nil
+			},
+			Createfn: func(p entities.Port) error { return nil },
+		}, Policy{Attempts: 3, InitialBackoff: time.Millisecond}, isTransient)
+		importService := NewImportService(services.NewImportService(services.NewPortService(portRepository)))
+
+		entries := make(chan services.ImportEntry, 1)
+		entries <- services.ImportEntry{Port: entities.Port{ID: "AAA"}}
+		close(entries)
+
+		report := importService.Import(context.Background(), entries)
+
+		assert.Equal(t, services.Report{Decoded: 1, Created: 1, Retries: 1}, report)
+	})
+	t.Run("Given a decorated Importer reporting retries When importing Then the retries of the store must be added", func(t *testing.T) {
+		t.Parallel()
+
+		importService := NewImportService(mockImporter{report: services.Report{Decoded: 1, Created: 1, Retries: 2}})
+
+		entries := make(chan services.ImportEntry)
+		close(entries)
+
+		report := importService.Import(context.Background(), entries)
+
+		assert.Equal(t, services.Report{Decoded: 1, Created: 1, Retries: 2}, report)
+	})
+}
+
+// mockImporter is the services.Importer retrieving its report.
+type mockImporter struct {
+	report services.Report
+}
+
+func (i mockImporter) Import(context.Context, <-chan services.ImportEntry) services.Report {
+	return i.report
+}
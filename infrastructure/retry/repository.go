@@ -0,0 +1,114 @@
+package retry
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+)
+
+// PortRepository retries the operations of a domain.PortRepository that fail with errors
+// classified as retryable.
+type PortRepository struct {
+	portRepository domain.PortRepository
+	policy         Policy
+	retryable      func(error) bool
+}
+
+// Retrieves a PortRepository that retries the operations of portRepository.
+func NewPortRepository(portRepository domain.PortRepository, policy Policy, retryable func(error) bool) PortRepository {
+	return PortRepository{
+		portRepository: portRepository,
+		policy:         policy,
+		retryable:      retryable,
+	}
+}
+
+func (r PortRepository) GetByID(ctx context.Context, id string) (*entities.Port, error) {
+	var port *entities.Port
+
+	err := r.policy.Do(ctx, "get_by_id", r.retryable, func(ctx context.Context) error {
+		var err error
+		port, err = r.portRepository.GetByID(ctx, id)
+
+		return err
+	})
+
+	return port, err
+}
+
+// Create creates the Port. When a retry finds the Port already created and the Port stored at
+// version 0 is the given one, the Port was created by the attempt whose answer was lost, and no
+// error is retrieved. Otherwise another writer created it, and ErrPortAlreadyExists is retrieved.
+func (r PortRepository) Create(ctx context.Context, port entities.Port) error {
+	attempts := 0
+
+	return r.policy.Do(ctx, "create", r.retryable, func(ctx context.Context) error {
+		attempts++
+
+		err := r.portRepository.Create(ctx, port)
+		if attempts == 1 || !errors.Is(err, domain.ErrPortAlreadyExists) {
+			return err
+		}
+
+		stored, getErr := r.portRepository.GetByID(ctx, port.ID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if stored != nil && stored.Version == 0 && stored.Equal(port) {
+			logger.Warn("Port created by a previous attempt", logging.KeyPortKey, port.ID)
+
+			return nil
+		}
+
+		return err
+	})
+}
+
+// Update updates the Port. When a retry finds the version changed and the Port stored at the
+// next version is the given one, the Port was updated by the attempt whose answer was lost, and
+// no error is retrieved.
+func (r PortRepository) Update(ctx context.Context, port entities.Port, id string) error {
+	attempts := 0
+
+	return r.policy.Do(ctx, "update", r.retryable, func(ctx context.Context) error {
+		attempts++
+
+		err := r.portRepository.Update(ctx, port, id)
+		if attempts == 1 || !errors.Is(err, domain.ErrVersionConflict) {
+			return err
+		}
+
+		stored, getErr := r.portRepository.GetByID(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+
+		if stored != nil && stored.Version == port.Version+1 && stored.Equal(port) {
+			logger.Warn("Port updated by a previous attempt", logging.KeyPortKey, id)
+
+			return nil
+		}
+
+		return err
+	})
+}
+
+// ForEach is only retried while fn was not called yet, so fn is never called twice for a Port.
+// Errors retrieved by fn are not retried.
+func (r PortRepository) ForEach(ctx context.Context, fn func(entities.Port) error) error {
+	called := false
+
+	return r.policy.Do(ctx, "for_each", func(err error) bool {
+		return !called && r.retryable(err)
+	}, func(ctx context.Context) error {
+		return r.portRepository.ForEach(ctx, func(port entities.Port) error {
+			called = true
+
+			return fn(port)
+		})
+	})
+}
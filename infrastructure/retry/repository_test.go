@@ -0,0 +1,208 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortRepository(t *testing.T) {
+	t.Parallel()
+
+	policy := Policy{Attempts: 3, InitialBackoff: time.Millisecond}
+
+	t.Run("Given a transient error When querying a Port Then the query must be retried", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		portRepository := NewPortRepository(domain.MockPortRepository{
+			GetByIDfn: func(id string) (*entities.Port, error) {
+				calls++
+				if calls == 1 {
+					return nil, errTransient
+				}
+
+				return &entities.Port{ID: id}, nil
+			},
+		}, policy, isTransient)
+
+		port, err := portRepository.GetByID(context.Background(), "AEAJM")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &entities.Port{ID: "AEAJM"}, port)
+	})
+
+	t.Run("Given a retried create finding the Port When creating Then no error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		portRepository := NewPortRepository(domain.MockPortRepository{
+			Createfn: func(port entities.Port) error {
+				calls++
+				if calls == 1 {
+					return errTransient
+				}
+
+				return fmt.Errorf("Error creating port %s. Error: %w", port.ID, domain.ErrPortAlreadyExists)
+			},
+			GetByIDfn: func(id string) (*entities.Port, error) {
+				return &entities.Port{ID: id, Name: "Ajman"}, nil
+			},
+		}, policy, isTransient)
+
+		assert.NoError(t, portRepository.Create(context.Background(), entities.Port{ID: "AEAJM", Name: "Ajman"}))
+	})
+
+	t.Run("Given a retried create finding the Port created by another writer When creating Then ErrPortAlreadyExists is expected", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		portRepository := NewPortRepository(domain.MockPortRepository{
+			Createfn: func(port entities.Port) error {
+				calls++
+				if calls == 1 {
+					return errTransient
+				}
+
+				return fmt.Errorf("Error creating port %s. Error: %w", port.ID, domain.ErrPortAlreadyExists)
+			},
+			GetByIDfn: func(id string) (*entities.Port, error) {
+				return &entities.Port{ID: id, Name: "Other name"}, nil
+			},
+		}, policy, isTransient)
+
+		err := portRepository.Create(context.Background(), entities.Port{ID: "AEAJM", Name: "Ajman"})
+		assert.ErrorIs(t, err, domain.ErrPortAlreadyExists)
+	})
+
+	t.Run("Given an existing Port When creating it at the first attempt Then ErrPortAlreadyExists is expected", func(t *testing.T) {
+		t.Parallel()
+
+		portRepository := NewPortRepository(domain.MockPortRepository{
+			Createfn: func(port entities.Port) error {
+				return fmt.Errorf("Error creating port %s. Error: %w", port.ID, domain.ErrPortAlreadyExists)
+			},
+		}, policy, isTransient)
+
+		err := portRepository.Create(context.Background(), entities.Port{ID: "AEAJM"})
+		assert.ErrorIs(t, err, domain.ErrPortAlreadyExists)
+	})
+
+	t.Run("Given a retried update finding the Port updated When updating Then no error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		port := entities.Port{ID: "AEAJM", Name: "Ajman", Version: 4}
+		calls := 0
+		portRepository := NewPortRepository(domain.MockPortRepository{
+			Updatefn: func(entities.Port, string) error {
+				calls++
+				if calls == 1 {
+					return errTransient
+				}
+
+				return domain.ErrVersionConflict
+			},
+			GetByIDfn: func(id string) (*entities.Port, error) {
+				return &entities.Port{ID: id, Name: "Ajman", Version: 5}, nil
+			},
+		}, policy, isTransient)
+
+		assert.NoError(t, portRepository.Update(context.Background(), port, port.ID))
+	})
+
+	t.Run("Given a retried update finding the Port changed by another writer When updating Then ErrVersionConflict is expected", func(t *testing.T) {
+		t.Parallel()
+
+		port := entities.Port{ID: "AEAJM", Name: "Ajman", Version: 4}
+		calls := 0
+		portRepository := NewPortRepository(domain.MockPortRepository{
+			Updatefn: func(entities.Port, string) error {
+				calls++
+				if calls == 1 {
+					return errTransient
+				}
+
+				return domain.ErrVersionConflict
+			},
+			GetByIDfn: func(id string) (*entities.Port, error) {
+				return &entities.Port{ID: id, Name: "Ajman by hand", Version: 5}, nil
+			},
+		}, policy, isTransient)
+
+		err := portRepository.Update(context.Background(), port, port.ID)
+		assert.ErrorIs(t, err, domain.ErrVersionConflict)
+	})
+
+	t.Run("Given a transient error after a Port was listed When listing Then it must not be retried", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		portRepository := NewPortRepository(domain.MockPortRepository{
+			ForEachfn: func(fn func(entities.Port) error) error {
+				calls++
+				if err := fn(entities.Port{ID: "AEAJM"}); err != nil {
+					return err
+				}
+
+				return errTransient
+			},
+		}, policy, isTransient)
+
+		listed := 0
+		err := portRepository.ForEach(context.Background(), func(entities.Port) error {
+			listed++
+
+			return nil
+		})
+
+		assert.ErrorIs(t, err, errTransient)
+		assert.Equal(t, 1, calls, "ForEach must not be retried")
+		assert.Equal(t, 1, listed, "The Port must be listed once")
+	})
+
+	t.Run("Given a transient error before any Port was listed When listing Then it must be retried", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		portRepository := NewPortRepository(domain.MockPortRepository{
+			ForEachfn: func(fn func(entities.Port) error) error {
+				calls++
+				if calls == 1 {
+					return errTransient
+				}
+
+				return fn(entities.Port{ID: "AEAJM"})
+			},
+		}, policy, isTransient)
+
+		err := portRepository.ForEach(context.Background(), func(entities.Port) error { return nil })
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Given an error of the function When listing Then it must not be retried", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		portRepository := NewPortRepository(domain.MockPortRepository{
+			ForEachfn: func(fn func(entities.Port) error) error {
+				calls++
+
+				return fn(entities.Port{ID: "AEAJM"})
+			},
+		}, policy, func(error) bool { return true })
+
+		expectedErr := errors.New("Error writing port")
+		err := portRepository.ForEach(context.Background(), func(entities.Port) error { return expectedErr })
+
+		assert.ErrorIs(t, err, expectedErr)
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,103 @@
+// Package retry retries operations that fail with transient errors, waiting an exponential
+// backoff with jitter between the attempts.
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+	"github.com/cassiuspaim/portimporter/infrastructure/metrics"
+)
+
+var logger = logging.For("retry")
+
+// Policy defines how many times and how often an operation is attempted.
+type Policy struct {
+	// Attempts is the maximum number of attempts, including the first one. Values lower than 1
+	// mean a single attempt.
+	Attempts int
+	// InitialBackoff is the wait before the second attempt. Each retry doubles the wait.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts. Zero means no cap.
+	MaxBackoff time.Duration
+	// Jitter is the fraction, between 0 and 1, of each wait that is random, so clients retrying
+	// at the same time spread their attempts.
+	Jitter float64
+}
+
+// Backoff retrieves the wait before the retry, starting at 1 for the second attempt.
+func (p Policy) Backoff(retry int) time.Duration {
+	backoff := p.InitialBackoff
+
+	for i := 1; i < retry && (p.MaxBackoff == 0 || backoff < p.MaxBackoff); i++ {
+		backoff *= 2
+	}
+
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		random := time.Duration(float64(backoff) * p.Jitter * rand.Float64()) //nolint:gosec // Jitter needs no crypto.
+		backoff = backoff - time.Duration(float64(backoff)*p.Jitter) + 2*random
+	}
+
+	return backoff
+}
+
+// Do calls operation until it succeeds, it fails with an error that is not retryable, the
+// attempts of the policy end or ctx is done. It retrieves the error of the last attempt.
+// Every retry is counted by the Counter of ctx, if any. The operation names the retries at
+// the logs and metrics.
+func (p Policy) Do(ctx context.Context, operation string, retryable func(error) bool,
+	fn func(context.Context) error,
+) error {
+	err := fn(ctx)
+
+	for retry := 1; retry < p.Attempts && err != nil && retryable(err); retry++ {
+		backoff := p.Backoff(retry)
+
+		logger.Warn("Retrying", "operation", operation, "retry", retry, "backoff", backoff, "error", err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return err
+		case <-timer.C:
+		}
+
+		if counter, ok := ctx.Value(counterKey{}).(*Counter); ok {
+			counter.retries.Add(1)
+		}
+
+		metrics.Retries.WithLabelValues(operation).Inc()
+
+		err = fn(ctx)
+	}
+
+	return err
+}
+
+type counterKey struct{}
+
+// Counter counts the retries of the operations done with a context.
+type Counter struct {
+	retries atomic.Int64
+}
+
+// WithCounter retrieves a context whose retries are counted by the returned Counter.
+func WithCounter(ctx context.Context) (context.Context, *Counter) {
+	counter := &Counter{}
+
+	return context.WithValue(ctx, counterKey{}, counter), counter
+}
+
+// Retries retrieves how many retries were done.
+func (c *Counter) Retries() int {
+	return int(c.retries.Load())
+}
@@ -0,0 +1,120 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTransient = errors.New("Transient error")
+
+func isTransient(err error) bool {
+	return errors.Is(err, errTransient)
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a policy without jitter When computing the backoffs Then they must double until the maximum", func(t *testing.T) {
+		t.Parallel()
+
+		policy := Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+		assert.Equal(t, 100*time.Millisecond, policy.Backoff(1))
+		assert.Equal(t, 200*time.Millisecond, policy.Backoff(2))
+		assert.Equal(t, 800*time.Millisecond, policy.Backoff(4))
+		assert.Equal(t, time.Second, policy.Backoff(5))
+		assert.Equal(t, time.Second, policy.Backoff(100))
+	})
+
+	t.Run("Given a policy with jitter When computing the backoffs Then they must stay around the backoff", func(t *testing.T) {
+		t.Parallel()
+
+		policy := Policy{InitialBackoff: 100 * time.Millisecond, Jitter: 0.5}
+
+		for i := 0; i < 100; i++ {
+			backoff := policy.Backoff(1)
+			assert.GreaterOrEqual(t, backoff, 50*time.Millisecond)
+			assert.Less(t, backoff, 150*time.Millisecond)
+		}
+	})
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	policy := Policy{Attempts: 3, InitialBackoff: time.Millisecond}
+
+	t.Run("Given an operation failing once When doing it Then it must be retried and the retry counted", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, counter := WithCounter(context.Background())
+		calls := 0
+
+		err := policy.Do(ctx, "test", isTransient, func(ctx context.Context) error {
+			calls++
+			if calls == 1 {
+				return errTransient
+			}
+
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, 1, counter.Retries())
+	})
+
+	t.Run("Given an operation always failing When doing it Then the last error must be retrieved after every attempt", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+
+		err := policy.Do(context.Background(), "test", isTransient, func(ctx context.Context) error {
+			calls++
+
+			return errTransient
+		})
+
+		assert.ErrorIs(t, err, errTransient)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("Given an error that is not retryable When doing the operation Then it must not be retried", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		expectedErr := errors.New("Permanent error")
+
+		err := policy.Do(context.Background(), "test", isTransient, func(ctx context.Context) error {
+			calls++
+
+			return expectedErr
+		})
+
+		assert.ErrorIs(t, err, expectedErr)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Given a done context When the operation fails Then it must not be retried", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		calls := 0
+		slowPolicy := Policy{Attempts: 3, InitialBackoff: time.Hour}
+
+		err := slowPolicy.Do(ctx, "test", isTransient, func(ctx context.Context) error {
+			calls++
+
+			return errTransient
+		})
+
+		assert.ErrorIs(t, err, errTransient)
+		assert.Equal(t, 1, calls)
+	})
+}
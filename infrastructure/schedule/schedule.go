@@ -0,0 +1,77 @@
+// Package schedule runs jobs at fixed intervals or at the times of a cron expression.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+	"github.com/robfig/cron/v3"
+)
+
+var logger = logging.For("schedule")
+
+// Schedule retrieves when a job runs.
+type Schedule interface {
+	// Next retrieves the first time the job runs after the given time.
+	Next(time.Time) time.Time
+}
+
+type every time.Duration
+
+func (e every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+// Every retrieves the Schedule of a job run each interval, counted from the end of the
+// previous run.
+func Every(interval time.Duration) Schedule {
+	return every(interval)
+}
+
+// Cron retrieves the Schedule of the standard cron expression with five fields, e.g.
+// "*/15 * * * *", or of a descriptor like "@hourly".
+func Cron(expression string) (Schedule, error) {
+	schedule, err := cron.ParseStandard(expression)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid cron expression %q. Error: %w", expression, err)
+	}
+
+	return schedule, nil
+}
+
+// Run runs the job at the times of the schedule until ctx is done. The first run happens
+// immediately. Runs never overlap: the times passed while a run was in progress are skipped
+// and the next run is scheduled from the end of the previous one.
+func Run(ctx context.Context, schedule Schedule, job func(context.Context)) {
+	for {
+		start := time.Now()
+		job(ctx)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		end := time.Now()
+
+		next := schedule.Next(end)
+		// The intervals are counted from the end of the previous run, so only the times of a cron
+		// expression can be missed.
+		_, interval := schedule.(every)
+		if missed := schedule.Next(start); !interval && missed.Before(end) {
+			logger.Warn("Runs skipped because the previous run was in progress", "missed", missed, "next", next)
+		}
+
+		logger.Info("Next run scheduled", "next", next)
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		case <-timer.C:
+		}
+	}
+}
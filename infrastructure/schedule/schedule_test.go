@@ -0,0 +1,114 @@
+package schedule_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/schedule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvery(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given an interval When retrieving the next time Then it is the time plus the interval", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Date(2024, 5, 1, 10, 7, 0, 0, time.UTC)
+
+		assert.Equal(t, now.Add(15*time.Minute), schedule.Every(15*time.Minute).Next(now))
+	})
+}
+
+func TestCron(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a cron expression When retrieving the next time Then it is the next matching time", func(t *testing.T) {
+		t.Parallel()
+
+		cron, err := schedule.Cron("*/15 * * * *")
+		require.NoError(t, err)
+
+		now := time.Date(2024, 5, 1, 10, 7, 0, 0, time.UTC)
+
+		assert.Equal(t, time.Date(2024, 5, 1, 10, 15, 0, 0, time.UTC), cron.Next(now))
+	})
+
+	t.Run("Given a descriptor When retrieving the next time Then it is the next matching time", func(t *testing.T) {
+		t.Parallel()
+
+		cron, err := schedule.Cron("@hourly")
+		require.NoError(t, err)
+
+		now := time.Date(2024, 5, 1, 10, 7, 0, 0, time.UTC)
+
+		assert.Equal(t, time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC), cron.Next(now))
+	})
+
+	t.Run("Given an invalid cron expression When parsing Then an error is retrieved", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := schedule.Cron("every minute")
+
+		assert.ErrorContains(t, err, "Invalid cron expression")
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a schedule When running Then the job runs until the context is done", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var runs int32
+
+		schedule.Run(ctx, schedule.Every(time.Millisecond), func(context.Context) {
+			if atomic.AddInt32(&runs, 1) == 3 {
+				cancel()
+			}
+		})
+
+		assert.Equal(t, int32(3), atomic.LoadInt32(&runs))
+	})
+
+	t.Run("Given a job slower than the schedule When running Then the runs do not overlap", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		var running, overlaps int32
+
+		schedule.Run(ctx, schedule.Every(time.Millisecond), func(context.Context) {
+			if atomic.AddInt32(&running, 1) > 1 {
+				atomic.AddInt32(&overlaps, 1)
+			}
+
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+
+		assert.Zero(t, atomic.LoadInt32(&overlaps))
+	})
+
+	t.Run("Given a done context When running Then the job runs once", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var runs int32
+
+		schedule.Run(ctx, schedule.Every(time.Hour), func(context.Context) {
+			atomic.AddInt32(&runs, 1)
+		})
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+	})
+}
@@ -0,0 +1,394 @@
+// Package timezones infers the timezone of the imported Ports from their coordinates, and flags the
+// timezones that disagree with them. It works offline: the zones are read from the zone.tab of the
+// tz database (2025b) and their boundaries from boundaries.tsv, both embedded at the data folder,
+// and their rules from the tz database embedded at the binary by time/tzdata.
+//
+// The boundaries are simplified polygons of the zones of the countries with several zones, which only
+// split the territory of each country among its zones. So the zone at some coordinates is searched
+// among the zones of the country of the Port, whose coordinates must be inside its country.
+package timezones
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/coordinates"
+)
+
+// Kinds of the flags of the Ports.
+const (
+	// KindTimezoneInferred is a missing timezone inferred from the coordinates, which is set when
+	// the Enricher fills them.
+	KindTimezoneInferred = "timezone-inferred"
+	// KindTimezoneUnresolved is a missing timezone that is not inferred because the country has
+	// several zones and the coordinates are outside their boundaries, e.g. for Antarctica.
+	KindTimezoneUnresolved = "timezone-unresolved"
+	// KindTimezoneMismatch is a timezone whose offsets are not used by any zone of the country near
+	// the coordinates.
+	KindTimezoneMismatch = "timezone-mismatch"
+	// KindUnknownTimezone is a timezone not found at the tz database.
+	KindUnknownTimezone = "unknown-timezone"
+)
+
+// Zones whose boundaries are nearer than this margin, in degrees, to the coordinates are considered
+// to check a timezone, as the boundaries are simplified and ports lie at the borders of the zones.
+const margin = 0.5
+
+//go:embed data/zone.tab
+var zoneTab string
+
+//go:embed data/boundaries.tsv
+var boundariesTSV string
+
+// Zone is a zone of the tz database with the reference location given by zone.tab.
+type Zone struct {
+	// Name is the name of the zone, e.g. "Asia/Dubai".
+	Name string
+	// CountryCode is the ISO 3166-1 alpha-2 code of the country of the zone.
+	CountryCode string
+	Longitude   float64
+	Latitude    float64
+}
+
+// The zones are parsed the first time they are used.
+var load = sync.OnceValue(func() []Zone {
+	var zones []Zone
+
+	for _, line := range strings.Split(zoneTab, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		columns := strings.Split(line, "\t")
+
+		latitude, longitude, err := parseCoordinates(columns[1])
+		if err != nil {
+			panic(fmt.Sprintf("Error parsing zone.tab line %q. Error: %s", line, err))
+		}
+
+		zones = append(zones, Zone{Name: columns[2], CountryCode: columns[0], Longitude: longitude,
+			Latitude: latitude})
+	}
+
+	return zones
+})
+
+// Retrieves the latitude and the longitude, in degrees, of the coordinates of zone.tab, formatted
+// as ±DDMM±DDDMM or ±DDMMSS±DDDMMSS.
+func parseCoordinates(coordinates string) (float64, float64, error) {
+	split := strings.IndexAny(coordinates[1:], "+-") + 1
+	if split == 0 {
+		return 0, 0, fmt.Errorf("coordinates %s have no longitude", coordinates)
+	}
+
+	latitude, err := parseDegrees(coordinates[:split], 2)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	longitude, err := parseDegrees(coordinates[split:], 3)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return latitude, longitude, nil
+}
+
+// Retrieves the degrees of ±DDMM or ±DDMMSS, with the given number of digits of the degrees.
+func parseDegrees(value string, digits int) (float64, error) {
+	sign, value := value[:1], value[1:]
+	parts := []string{value[:digits], value[digits : digits+2]}
+
+	if len(value) > digits+2 {
+		parts = append(parts, value[digits+2:])
+	}
+
+	var degrees float64
+
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, fmt.Errorf("degrees %s%s are not numeric", sign, value)
+		}
+
+		degrees += float64(number) / math.Pow(60, float64(i))
+	}
+
+	if sign == "-" {
+		degrees = -degrees
+	}
+
+	return degrees, nil
+}
+
+// Zones retrieves the zones of the country of the ISO 3166-1 alpha-2 code, none for the countries
+// without zones, e.g. most of the withdrawn codes.
+func Zones(countryCode string) []Zone {
+	var zones []Zone
+
+	for _, zone := range load() {
+		if zone.CountryCode == countryCode {
+			zones = append(zones, zone)
+		}
+	}
+
+	return zones
+}
+
+// A polygon of the boundaries of a zone, as longitude and latitude vertices in degrees.
+type boundary struct {
+	zone     string
+	vertices [][2]float64
+}
+
+// Retrieves whether the coordinates are inside the polygon, by the crossings of a ray from them.
+func (b boundary) contains(longitude float64, latitude float64) bool {
+	inside := false
+
+	for i, j := 0, len(b.vertices)-1; i < len(b.vertices); j, i = i, i+1 {
+		longitude1, latitude1 := b.vertices[i][0], b.vertices[i][1]
+		longitude2, latitude2 := b.vertices[j][0], b.vertices[j][1]
+
+		if (latitude1 > latitude) != (latitude2 > latitude) &&
+			longitude < (longitude2-longitude1)*(latitude-latitude1)/(latitude2-latitude1)+longitude1 {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// The boundaries by country are parsed the first time they are used, in the order of the file.
+var loadBoundaries = sync.OnceValue(func() map[string][]boundary {
+	boundaries := map[string][]boundary{}
+
+	for _, line := range strings.Split(boundariesTSV, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		columns := strings.Split(line, "\t")
+		if len(columns) != 3 {
+			panic(fmt.Sprintf("Error parsing boundaries.tsv line %q. Error: it has not 3 columns", line))
+		}
+
+		vertices, err := parseVertices(columns[2])
+		if err != nil {
+			panic(fmt.Sprintf("Error parsing boundaries.tsv line %q. Error: %s", line, err))
+		}
+
+		boundaries[columns[0]] = append(boundaries[columns[0]], boundary{zone: columns[1], vertices: vertices})
+	}
+
+	return boundaries
+})
+
+// Retrieves the vertices of a polygon given as longitude,latitude pairs separated by spaces.
+func parseVertices(text string) ([][2]float64, error) {
+	var vertices [][2]float64
+
+	for _, pair := range strings.Fields(text) {
+		longitude, latitude, found := strings.Cut(pair, ",")
+		if !found {
+			return nil, fmt.Errorf("vertex %s has no latitude", pair)
+		}
+
+		var vertex [2]float64
+
+		for i, value := range []string{longitude, latitude} {
+			degrees, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("vertex %s is not numeric", pair)
+			}
+
+			vertex[i] = degrees
+		}
+
+		vertices = append(vertices, vertex)
+	}
+
+	if len(vertices) < 3 {
+		return nil, fmt.Errorf("polygon %s has less than 3 vertices", text)
+	}
+
+	return vertices, nil
+}
+
+// ZoneAt retrieves the name of the zone of the country of the ISO 3166-1 alpha-2 code at the
+// coordinates: its single zone, or the zone whose boundaries contain them. It retrieves false when
+// the country has no zones, or has several and the coordinates are outside their boundaries.
+func ZoneAt(countryCode string, longitude float64, latitude float64) (string, bool) {
+	if zones := Zones(countryCode); len(zones) == 1 {
+		return zones[0].Name, true
+	}
+
+	for _, boundary := range loadBoundaries()[countryCode] {
+		if boundary.contains(longitude, latitude) {
+			return boundary.zone, true
+		}
+	}
+
+	return "", false
+}
+
+// Retrieves the names of the zones of the country at the coordinates or within the margin of them, or
+// the names of all the zones of the country when none is, as the coordinates tell nothing of the zone.
+func zonesNear(countryCode string, longitude float64, latitude float64) []string {
+	var names []string
+
+	for _, offset := range [][2]float64{{0, 0}, {-margin, 0}, {margin, 0}, {0, -margin}, {0, margin},
+		{-margin, -margin}, {-margin, margin}, {margin, -margin}, {margin, margin}} {
+		// The antimeridian is crossed by wrapping the longitude.
+		nearLongitude := math.Remainder(longitude+offset[0], 360)
+
+		if name, ok := ZoneAt(countryCode, nearLongitude, latitude+offset[1]); ok && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		for _, zone := range Zones(countryCode) {
+			names = append(names, zone.Name)
+		}
+	}
+
+	return names
+}
+
+// Options of the Enricher.
+type Options struct {
+	// Fill sets the missing timezones to the inferred ones. Otherwise they are only flagged.
+	Fill bool
+}
+
+// Enricher is the domain.Enricher that fills the missing timezones of the Ports from their
+// coordinates and flags the timezones that disagree with them.
+type Enricher struct {
+	options Options
+	// year whose offsets are compared.
+	year int
+	// locations by name, as loading them parses the tz database.
+	locations *sync.Map
+}
+
+// Retrieves a new Enricher, which compares the offsets of the zones along the current year.
+func NewEnricher(options Options) Enricher {
+	return Enricher{options: options, year: time.Now().UTC().Year(), locations: &sync.Map{}}
+}
+
+// Enrich retrieves the Port with the timezone of the zone at the coordinates when it has none and
+// the Enricher fills them, flagging it, and flags the timezone when it is unknown or disagrees with
+// the coordinates. A missing timezone whose zone is not found is flagged as unresolved. The country
+// of the Port is its CountryCode, or the prefix of the key when it has none. Ports without valid
+// coordinates, with coordinates outside their country, which are flagged by the coordinates check,
+// or of countries without zones are retrieved as they are.
+func (e Enricher) Enrich(port entities.Port) (entities.Port, []entities.Flag) {
+	if len(port.Coordinates) != 2 || math.Abs(port.Coordinates[1]) > 90 || math.Abs(port.Coordinates[0]) > 180 {
+		return port, nil
+	}
+
+	longitude, latitude := port.Coordinates[0], port.Coordinates[1]
+
+	countryCode := port.CountryCode
+	if countryCode == "" && len(port.ID) >= 2 {
+		countryCode = strings.ToUpper(port.ID[:2])
+	}
+
+	// Coordinates outside the country, e.g. swapped ones that were not fixed, tell nothing of the
+	// zone of the port.
+	if boxes, ok := coordinates.Bounds(countryCode); ok && !coordinates.Inside(boxes, longitude, latitude) {
+		return port, nil
+	}
+
+	zones := Zones(countryCode)
+	if len(zones) == 0 {
+		return port, nil
+	}
+
+	if port.Timezone == "" {
+		return e.infer(port, countryCode, len(zones))
+	}
+
+	offsets, err := e.offsets(port.Timezone)
+	if err != nil {
+		return port, []entities.Flag{{Field: "timezone", Kind: KindUnknownTimezone,
+			Detail: fmt.Sprintf("Timezone %s is not found at the tz database", port.Timezone)}}
+	}
+
+	near := zonesNear(countryCode, longitude, latitude)
+	if e.usedBy(offsets, near) {
+		return port, nil
+	}
+
+	return port, []entities.Flag{{Field: "timezone", Kind: KindTimezoneMismatch,
+		Detail: fmt.Sprintf("Timezone %s is not used near coordinates %s, whose zones are %s", port.Timezone,
+			port.Field("coordinates"), strings.Join(near, ", "))}}
+}
+
+// Retrieves the Port without timezone with the zone at its coordinates set when the Enricher fills
+// them, flagging it. Otherwise the timezone is flagged as unresolved.
+func (e Enricher) infer(port entities.Port, countryCode string, zones int) (entities.Port, []entities.Flag) {
+	zone, found := ZoneAt(countryCode, port.Coordinates[0], port.Coordinates[1])
+	if !found {
+		return port, []entities.Flag{{Field: "timezone", Kind: KindTimezoneUnresolved,
+			Detail: fmt.Sprintf("Timezone not inferred, coordinates %s are outside the boundaries of the %d zones of %s",
+				port.Field("coordinates"), zones, countryCode)}}
+	}
+
+	flag := entities.Flag{Field: "timezone", Kind: KindTimezoneInferred,
+		Detail: fmt.Sprintf("Timezone %s inferred from coordinates %s", zone, port.Field("coordinates"))}
+
+	if e.options.Fill {
+		port.Timezone = zone
+		port = port.WithInferred("timezone")
+	}
+
+	return port, []entities.Flag{flag}
+}
+
+// Retrieves whether the offsets are the ones of any of the zones.
+func (e Enricher) usedBy(offsets [12]int, zones []string) bool {
+	for _, zone := range zones {
+		if zoneOffsets, err := e.offsets(zone); err == nil && zoneOffsets == offsets {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Retrieves the offsets from UTC, in seconds, of the zone at the first day of each month of the
+// year, so zones with the same offsets but other daylight saving time are told apart.
+func (e Enricher) offsets(name string) ([12]int, error) {
+	var offsets [12]int
+
+	// LoadLocation retrieves UTC for an empty name and the zone of the host for Local.
+	if name == "" || name == "Local" {
+		return offsets, fmt.Errorf("Error loading timezone %q. Error: not a zone", name)
+	}
+
+	location, ok := e.locations.Load(name)
+	if !ok {
+		loaded, err := time.LoadLocation(name)
+		if err != nil {
+			return offsets, fmt.Errorf("Error loading timezone %s. Error: %w", name, err)
+		}
+
+		location, _ = e.locations.LoadOrStore(name, loaded)
+	}
+
+	for month := range offsets {
+		_, offsets[month] = time.Date(e.year, time.Month(month+1), 1, 0, 0, 0, 0, time.UTC).
+			In(location.(*time.Location)).Zone()
+	}
+
+	return offsets, nil
+}
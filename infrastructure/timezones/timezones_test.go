@@ -0,0 +1,267 @@
+package timezones
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCoordinates(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given coordinates of zone.tab When parsing Then the degrees are expected", func(t *testing.T) {
+		t.Parallel()
+
+		latitude, longitude, err := parseCoordinates("+2518+05518")
+		require.NoError(t, err)
+		assert.InDelta(t, 25.3, latitude, 0.0001)
+		assert.InDelta(t, 55.3, longitude, 0.0001)
+
+		latitude, longitude, err = parseCoordinates("-332730-0703929")
+		require.NoError(t, err)
+		assert.InDelta(t, -33.4583, latitude, 0.0001)
+		assert.InDelta(t, -70.6581, longitude, 0.0001)
+	})
+
+	t.Run("Given coordinates without longitude When parsing Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := parseCoordinates("+2518")
+		assert.Error(t, err)
+	})
+}
+
+func TestBoundaries(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given the embedded boundaries When loading them Then their zones are zones of their country", func(t *testing.T) {
+		t.Parallel()
+
+		for countryCode, boundaries := range loadBoundaries() {
+			var names []string
+			for _, zone := range Zones(countryCode) {
+				names = append(names, zone.Name)
+			}
+
+			assert.Greater(t, len(names), 1, "Country %s must have several zones", countryCode)
+
+			for _, boundary := range boundaries {
+				assert.Contains(t, names, boundary.zone, "Zone of country %s", countryCode)
+			}
+		}
+	})
+
+	t.Run("Given a polygon without enough vertices When parsing Then an error is expected", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := parseVertices("1,1 2,2")
+		assert.Error(t, err)
+
+		_, err = parseVertices("1,1 2,2 3")
+		assert.Error(t, err)
+	})
+}
+
+func TestZoneAt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		countryCode string
+		longitude   float64
+		latitude    float64
+		zone        string
+	}{
+		{name: "Houston", countryCode: "US", longitude: -95.27, latitude: 29.73, zone: "America/Chicago"},
+		{name: "Seattle", countryCode: "US", longitude: -122.34, latitude: 47.6, zone: "America/Los_Angeles"},
+		{name: "Miami", countryCode: "US", longitude: -80.17, latitude: 25.77, zone: "America/New_York"},
+		{name: "Detroit", countryCode: "US", longitude: -83.05, latitude: 42.33, zone: "America/New_York"},
+		{name: "Anchorage", countryCode: "US", longitude: -149.89, latitude: 61.22, zone: "America/Anchorage"},
+		{name: "Adak", countryCode: "US", longitude: -176.64, latitude: 51.86, zone: "America/Adak"},
+		{name: "Vancouver", countryCode: "CA", longitude: -123.11, latitude: 49.29, zone: "America/Vancouver"},
+		{name: "Halifax", countryCode: "CA", longitude: -63.57, latitude: 44.65, zone: "America/Halifax"},
+		{name: "Montreal", countryCode: "CA", longitude: -73.55, latitude: 45.5, zone: "America/Toronto"},
+		{name: "St. John's", countryCode: "CA", longitude: -52.71, latitude: 47.56, zone: "America/St_Johns"},
+		{name: "Manaus", countryCode: "BR", longitude: -60.02, latitude: -3.12, zone: "America/Manaus"},
+		{name: "Santos", countryCode: "BR", longitude: -46.3, latitude: -23.96, zone: "America/Sao_Paulo"},
+		{name: "Fernando de Noronha", countryCode: "BR", longitude: -32.42, latitude: -3.85, zone: "America/Noronha"},
+		{name: "Saint Petersburg", countryCode: "RU", longitude: 30.2, latitude: 59.88, zone: "Europe/Moscow"},
+		{name: "Kaliningrad", countryCode: "RU", longitude: 20.5, latitude: 54.7, zone: "Europe/Kaliningrad"},
+		{name: "Vladivostok", countryCode: "RU", longitude: 131.89, latitude: 43.11, zone: "Asia/Vladivostok"},
+		{name: "Anadyr", countryCode: "RU", longitude: 177.5, latitude: 64.73, zone: "Asia/Anadyr"},
+		{name: "Provideniya", countryCode: "RU", longitude: -173.23, latitude: 64.42, zone: "Asia/Anadyr"},
+		{name: "Perth", countryCode: "AU", longitude: 115.74, latitude: -32.05, zone: "Australia/Perth"},
+		{name: "Darwin", countryCode: "AU", longitude: 130.84, latitude: -12.46, zone: "Australia/Darwin"},
+		{name: "Sydney", countryCode: "AU", longitude: 151.2, latitude: -33.86, zone: "Australia/Sydney"},
+		{name: "Ensenada", countryCode: "MX", longitude: -116.62, latitude: 31.86, zone: "America/Tijuana"},
+		{name: "Cancun", countryCode: "MX", longitude: -86.85, latitude: 21.16, zone: "America/Cancun"},
+		{name: "Veracruz", countryCode: "MX", longitude: -96.13, latitude: 19.2, zone: "America/Mexico_City"},
+		{name: "Tanjung Priok", countryCode: "ID", longitude: 106.88, latitude: -6.1, zone: "Asia/Jakarta"},
+		{name: "Makassar", countryCode: "ID", longitude: 119.41, latitude: -5.13, zone: "Asia/Makassar"},
+		{name: "Punta Arenas", countryCode: "CL", longitude: -70.9, latitude: -53.16, zone: "America/Punta_Arenas"},
+		{name: "Dubai", countryCode: "AE", longitude: 55.27, latitude: 25.26, zone: "Asia/Dubai"},
+	}
+
+	for _, tt := range tests {
+		t.Run("Given the coordinates of "+tt.name+" When querying Then the zone is expected", func(t *testing.T) {
+			t.Parallel()
+
+			zone, found := ZoneAt(tt.countryCode, tt.longitude, tt.latitude)
+
+			assert.True(t, found)
+			assert.Equal(t, tt.zone, zone)
+		})
+	}
+
+	t.Run("Given a country with several zones without boundaries When querying Then no zone is expected", func(t *testing.T) {
+		t.Parallel()
+
+		_, found := ZoneAt("AQ", 166.67, -77.85)
+		assert.False(t, found)
+	})
+
+	t.Run("Given a country without zones When querying Then no zone is expected", func(t *testing.T) {
+		t.Parallel()
+
+		_, found := ZoneAt("AN", -68.93, 12.11)
+		assert.False(t, found, "Zones of the neighbouring countries must not be taken")
+	})
+}
+
+func TestEnrich(t *testing.T) {
+	t.Parallel()
+
+	provenance := entities.Provenance{Source: "ports.json", RunID: "run", UpdatedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name     string
+		options  Options
+		port     entities.Port
+		timezone string
+		kinds    []string
+	}{
+		{
+			name:     "Given a timezone used at the coordinates Then nothing is expected",
+			port:     entities.Port{ID: "AEJEA", Coordinates: []float64{55.03, 24.99}, Timezone: "Asia/Dubai"},
+			timezone: "Asia/Dubai",
+		},
+		{
+			name:     "Given a timezone of another zone with the same offsets Then nothing is expected",
+			port:     entities.Port{ID: "AUCNS", Coordinates: []float64{145.77, -16.92}, Timezone: "Australia/Brisbane"},
+			timezone: "Australia/Brisbane",
+		},
+		{
+			name:     "Given a timezone of a neighbouring zone near the coordinates Then nothing is expected",
+			port:     entities.Port{ID: "USPSJ", Coordinates: []float64{-85.3, 29.81}, Timezone: "America/New_York"},
+			timezone: "America/New_York",
+		},
+		{
+			name:     "Given a timezone of another zone of the country Then a mismatch is expected",
+			port:     entities.Port{ID: "USHOU", Coordinates: []float64{-95.27, 29.73}, Timezone: "America/New_York"},
+			timezone: "America/New_York",
+			kinds:    []string{KindTimezoneMismatch},
+		},
+		{
+			name:     "Given a timezone of a country whose zone is far from the coordinates Then nothing is expected",
+			port:     entities.Port{ID: "INBOM", Coordinates: []float64{72.82, 18.97}, Timezone: "Asia/Calcutta"},
+			timezone: "Asia/Calcutta",
+		},
+		{
+			name:     "Given a timezone of another continent Then a mismatch is expected",
+			port:     entities.Port{ID: "BRMAO", Coordinates: []float64{-60.02, -3.12}, Timezone: "Europe/Stockholm"},
+			timezone: "Europe/Stockholm",
+			kinds:    []string{KindTimezoneMismatch},
+		},
+		{
+			name:     "Given an unknown timezone Then an unknown timezone is expected",
+			port:     entities.Port{ID: "ARRIC", Coordinates: []float64{-64.5, -45.8}, Timezone: "America/Argentina"},
+			timezone: "America/Argentina",
+			kinds:    []string{KindUnknownTimezone},
+		},
+		{
+			name:     "Given no timezone and fill Then the inferred timezone is expected",
+			options:  Options{Fill: true},
+			port:     entities.Port{ID: "AEJEA", Coordinates: []float64{55.03, 24.99}},
+			timezone: "Asia/Dubai",
+			kinds:    []string{KindTimezoneInferred},
+		},
+		{
+			name:     "Given no timezone without fill Then only the flag is expected",
+			port:     entities.Port{ID: "AEJEA", Coordinates: []float64{55.03, 24.99}},
+			timezone: "",
+			kinds:    []string{KindTimezoneInferred},
+		},
+		{
+			name:     "Given a country code Then the zones of the country are expected instead of the key prefix",
+			options:  Options{Fill: true},
+			port:     entities.Port{ID: "BRSSZ", CountryCode: "UY", Coordinates: []float64{-56.2, -34.9}},
+			timezone: "America/Montevideo",
+			kinds:    []string{KindTimezoneInferred},
+		},
+		{
+			name:     "Given no timezone of a country with several zones and fill Then the zone at the coordinates is expected",
+			options:  Options{Fill: true},
+			port:     entities.Port{ID: "CLPUQ", Coordinates: []float64{-70.9, -53.16}},
+			timezone: "America/Punta_Arenas",
+			kinds:    []string{KindTimezoneInferred},
+		},
+		{
+			name:     "Given no timezone of a country whose zones have no boundaries and fill Then an unresolved timezone is expected",
+			options:  Options{Fill: true},
+			port:     entities.Port{ID: "AQMCM", Coordinates: []float64{166.67, -77.85}},
+			timezone: "",
+			kinds:    []string{KindTimezoneUnresolved},
+		},
+		{
+			name:     "Given no timezone and swapped coordinates Then nothing is expected",
+			options:  Options{Fill: true},
+			port:     entities.Port{ID: "AEJEA", Coordinates: []float64{24.99, 55.03}},
+			timezone: "",
+		},
+		{
+			name:     "Given a timezone of another continent and swapped coordinates Then nothing is expected",
+			port:     entities.Port{ID: "AEJEA", Coordinates: []float64{24.99, 55.03}, Timezone: "Europe/Stockholm"},
+			timezone: "Europe/Stockholm",
+		},
+		{
+			name:     "Given invalid coordinates Then nothing is expected",
+			options:  Options{Fill: true},
+			port:     entities.Port{ID: "AEJEA", Coordinates: []float64{24.99, 155.03}},
+			timezone: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" When enriching", func(t *testing.T) {
+			t.Parallel()
+
+			port, flags := NewEnricher(tt.options).Enrich(tt.port)
+
+			assert.Equal(t, tt.timezone, port.Timezone)
+
+			var kinds []string
+			for _, flag := range flags {
+				assert.Equal(t, "timezone", flag.Field)
+				kinds = append(kinds, flag.Kind)
+			}
+
+			assert.Equal(t, tt.kinds, kinds)
+		})
+	}
+
+	t.Run("Given provenance When filling the timezone Then it must tell the timezone was inferred", func(t *testing.T) {
+		t.Parallel()
+
+		port := entities.Port{ID: "AEJEA", Coordinates: []float64{55.03, 24.99}}.WithProvenance(provenance)
+
+		enriched, _ := NewEnricher(Options{Fill: true}).Enrich(port)
+
+		assert.Equal(t, entities.Provenance{Source: entities.SourceInferred, RunID: "run", UpdatedAt: provenance.UpdatedAt},
+			enriched.Provenance["timezone"])
+		assert.Equal(t, provenance, enriched.Provenance["name"])
+		assert.Equal(t, provenance, port.Provenance["timezone"], "The given Port must not be changed")
+	})
+}
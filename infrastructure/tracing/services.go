@@ -0,0 +1,151 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/domain/services"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Entries upserted under the same batch span, so the traces of large files stay readable.
+const batchSize = 100
+
+// PortService is the domain.PortService that traces the upserts of the decorated one. The upserts
+// of an import traced by ImportService are grouped under its batch spans.
+type PortService struct {
+	portService domain.PortService
+	tracer      trace.Tracer
+}
+
+// Retrieves a new PortService tracing the upserts of portService with the tracer.
+func NewPortService(portService domain.PortService, tracer trace.Tracer) PortService {
+	return PortService{portService: portService, tracer: tracer}
+}
+
+// Upsert the Port through the decorated PortService under a span with its key and the result.
+func (s PortService) Upsert(ctx context.Context, port entities.Port) (result domain.UpsertResult, err error) {
+	if batch, ok := ctx.Value(batchKey{}).(*batch); ok {
+		ctx = batch.context(ctx)
+	}
+
+	ctx, span := s.tracer.Start(ctx, "PortService.Upsert", trace.WithAttributes(KeyPortKey.String(port.ID)))
+	defer func() {
+		span.SetAttributes(KeyUpsertResult.String(string(result)))
+		End(span, err)
+	}()
+
+	return s.portService.Upsert(ctx, port)
+}
+
+// ImportService is the services.Importer that traces the imports of the decorated one. The span of
+// an import has its Report as attributes and an event for each entry that could not be read.
+type ImportService struct {
+	importService services.Importer
+	tracer        trace.Tracer
+}
+
+// Retrieves a new ImportService tracing the imports of importService with the tracer.
+func NewImportService(importService services.Importer, tracer trace.Tracer) ImportService {
+	return ImportService{importService: importService, tracer: tracer}
+}
+
+// Import the entries through the decorated Importer under a span, grouping the upserts traced by
+// PortService in batches of batchSize Ports.
+func (s ImportService) Import(ctx context.Context, entries <-chan services.ImportEntry) services.Report {
+	ctx, span := s.tracer.Start(ctx, "ImportService.Import")
+
+	batch := &batch{parent: ctx, tracer: s.tracer}
+	report := s.importService.Import(context.WithValue(ctx, batchKey{}, batch), traceErrors(span, entries))
+	batch.end()
+
+	span.SetAttributes(reportAttributes(report)...)
+	span.End()
+
+	return report
+}
+
+// Retrieves the entries, adding to the span an event for each entry that could not be read. The
+// Importer reads every entry, so the entries are always forwarded.
+func traceErrors(span trace.Span, entries <-chan services.ImportEntry) <-chan services.ImportEntry {
+	traced := make(chan services.ImportEntry)
+
+	go func() {
+		defer close(traced)
+
+		for entry := range entries {
+			if entry.Error != nil && !entry.Ignored {
+				span.AddEvent("Error reading port", trace.WithAttributes(KeyPortKey.String(entry.Port.ID),
+					KeyOffset.Int64(entry.Offset)))
+			}
+
+			traced <- entry
+		}
+	}()
+
+	return traced
+}
+
+// Retrieves the Report as attributes of a span.
+func reportAttributes(r services.Report) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int("import.decoded", r.Decoded),
+		attribute.Int("import.decode_failed", r.DecodeFailed),
+		attribute.Int("import.created", r.Created),
+		attribute.Int("import.updated", r.Updated),
+		attribute.Int("import.unchanged", r.Unchanged),
+		attribute.Int("import.upsert_failed", r.UpsertFailed),
+		attribute.Int("import.conflicts", r.Conflicts),
+		attribute.Int("import.skipped", r.Skipped),
+		attribute.Int("import.duplicates", r.Duplicates),
+		attribute.Int("import.normalized", len(r.Normalizations)),
+		attribute.Int("import.flagged", len(r.Flags)),
+		attribute.Int("import.locked_changes", r.LockedChanges),
+		attribute.Int("import.retries", r.Retries),
+		attribute.Bool("import.interrupted", r.Interrupted),
+	}
+}
+
+type batchKey struct{}
+
+// batch groups the spans of the upserts of batchSize Ports of an import under a span. The upserts
+// of an import are made one at a time.
+type batch struct {
+	// parent is the context of the span of the import.
+	parent context.Context
+	tracer trace.Tracer
+	span   trace.Span
+	size   int
+}
+
+// Retrieves ctx with the span of the current batch, starting a new batch when the current one is
+// full. The span is added to ctx, instead of retrieving the context of the batch, so the
+// cancellation of ctx is kept.
+func (b *batch) context(ctx context.Context) context.Context {
+	if b.size == batchSize {
+		b.end()
+	}
+
+	if b.span == nil {
+		_, b.span = b.tracer.Start(b.parent, "ImportService.batch")
+	}
+
+	b.size++
+
+	return trace.ContextWithSpan(ctx, b.span)
+}
+
+// Ends the current batch, if any.
+func (b *batch) end() {
+	if b.span == nil {
+		return
+	}
+
+	b.span.SetAttributes(KeyBatchSize.Int(b.size))
+	b.span.End()
+
+	b.span = nil
+	b.size = 0
+}
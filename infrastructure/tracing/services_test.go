@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/domain/services"
+	"github.com/cassiuspaim/portimporter/infrastructure/repositories/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestImportService(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given entries When importing Then the run, its batches and the upserts must have spans", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := tracetest.NewSpanRecorder()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+		entries := make(chan services.ImportEntry, batchSize+2)
+		for i := 0; i <= batchSize; i++ {
+			entries <- services.ImportEntry{Port: entities.Port{ID: fmt.Sprintf("tracing-%03d", i)}}
+		}
+
+		entries <- services.ImportEntry{Port: entities.Port{ID: "invalid"}, Offset: 42, Error: errors.New("Error decoding port")}
+		close(entries)
+
+		portService := NewPortService(services.NewPortService(memory.NewPortRepository()), tracer)
+		NewImportService(services.NewImportService(portService), tracer).Import(context.Background(), entries)
+
+		spans := map[string][]sdktrace.ReadOnlySpan{}
+		for _, span := range recorder.Ended() {
+			spans[span.Name()] = append(spans[span.Name()], span)
+		}
+
+		require.Len(t, spans["ImportService.Import"], 1)
+		require.Len(t, spans["ImportService.batch"], 2, "A batch must be started every batchSize Ports")
+		require.Len(t, spans["PortService.Upsert"], batchSize+1)
+
+		run := spans["ImportService.Import"][0]
+		batch := spans["ImportService.batch"][0]
+		upsert := spans["PortService.Upsert"][0]
+		assert.Equal(t, run.SpanContext().SpanID(), batch.Parent().SpanID(), "The batch must be a child of the run")
+		assert.Equal(t, batch.SpanContext().SpanID(), upsert.Parent().SpanID(), "The upsert must be a child of the batch")
+		assert.Contains(t, upsert.Attributes(), KeyPortKey.String("tracing-000"))
+		assert.Contains(t, upsert.Attributes(), KeyUpsertResult.String(string(domain.UpsertCreated)))
+		assert.Contains(t, batch.Attributes(), KeyBatchSize.Int(batchSize))
+		assert.Contains(t, run.Attributes(), attribute.Int("import.created", batchSize+1))
+		assert.Contains(t, run.Attributes(), attribute.Int("import.decode_failed", 1))
+
+		require.Len(t, run.Events(), 1, "Each entry that could not be read must be an event")
+		assert.Contains(t, run.Events()[0].Attributes, KeyOffset.Int64(42))
+	})
+}
@@ -0,0 +1,157 @@
+// Package tracing configures the OpenTelemetry traces of the application. Every component gets
+// its tracer from For, which follows the exporter set by Setup even if the tracer was retrieved
+// before it.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporters of the spans.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+// Keys of the attributes shared by the spans of every component.
+const (
+	KeyPortKey      = attribute.Key("port.key")
+	KeyOffset       = attribute.Key("port.offset")
+	KeyUpsertResult = attribute.Key("port.upsert.result")
+	KeyOperation    = attribute.Key("db.operation")
+	KeyBatchSize    = attribute.Key("import.batch.size")
+)
+
+const serviceName = "portimporter"
+
+// Config is the configuration of the traces.
+type Config struct {
+	// Exporter is none, stdout, file or otlp. Empty means none.
+	Exporter string
+	// File is where the file exporter writes the spans, one JSON document per span.
+	File string
+	// Endpoint is the host and port of the OTLP HTTP collector. Empty means the endpoint of the
+	// OTEL_EXPORTER_OTLP_ENDPOINT variable, or localhost:4318.
+	Endpoint string
+}
+
+// For retrieves the tracer of the component.
+func For(component string) trace.Tracer {
+	return otel.Tracer(serviceName + "/" + component)
+}
+
+// Setup sets the exporter of the spans of every tracer. The returned function flushes the
+// pending spans and must be called before the application ends.
+func Setup(config Config) (func(context.Context) error, error) {
+	exporter, closeOutput, err := newExporter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{},
+		propagation.Baggage{}))
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+
+		if closeErr := closeOutput(); err == nil {
+			err = closeErr
+		}
+
+		return err
+	}, nil
+}
+
+// Retrieves the exporter of the configuration, nil when the spans are not exported, and the
+// function that closes its output.
+func newExporter(config Config) (sdktrace.SpanExporter, func() error, error) {
+	noClose := func() error { return nil }
+
+	switch config.Exporter {
+	case "", ExporterNone:
+		return nil, noClose, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+
+		return exporter, noClose, err
+	case ExporterFile:
+		if config.File == "" {
+			return nil, nil, fmt.Errorf("The file of the %s exporter is required", ExporterFile)
+		}
+
+		file, err := os.OpenFile(config.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error opening trace file %s. Error: %w", config.File, err)
+		}
+
+		exporter, err := NewWriterExporter(file)
+
+		return exporter, file.Close, err
+	case ExporterOTLP:
+		var options []otlptracehttp.Option
+		if config.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(config.Endpoint), otlptracehttp.WithInsecure())
+		}
+
+		exporter, err := otlptracehttp.New(context.Background(), options...)
+
+		return exporter, noClose, err
+	default:
+		return nil, nil, fmt.Errorf("Invalid trace exporter %q, expected %s, %s, %s or %s", config.Exporter,
+			ExporterNone, ExporterStdout, ExporterFile, ExporterOTLP)
+	}
+}
+
+// NewWriterExporter retrieves an exporter that writes each span to the writer as a JSON document.
+func NewWriterExporter(writer io.Writer) (sdktrace.SpanExporter, error) {
+	return stdouttrace.New(stdouttrace.WithWriter(writer))
+}
+
+// StartRepositoryCall starts the span of a call to the operation of a repository backed by the
+// database system. The key of the Port is added when it is not empty.
+func StartRepositoryCall(ctx context.Context, tracer trace.Tracer, system attribute.KeyValue, operation string,
+	key string,
+) (context.Context, trace.Span) {
+	attributes := []attribute.KeyValue{system, KeyOperation.String(operation)}
+	if key != "" {
+		attributes = append(attributes, KeyPortKey.String(key))
+	}
+
+	return tracer.Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+}
+
+// End records the error, when present, at the span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestSetup(t *testing.T) {
+	t.Run("Given the file exporter When a span ends Then the span must be written to the file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "traces.json")
+
+		shutdown, err := Setup(Config{Exporter: ExporterFile, File: path})
+		require.NoError(t, err)
+
+		_, span := StartRepositoryCall(context.Background(), For("test"), semconv.DBSystemMongoDB, "mongodb.get_by_id", "AEAJM")
+		End(span, nil)
+
+		require.NoError(t, shutdown(context.Background()))
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"Name":"mongodb.get_by_id"`)
+		assert.Contains(t, string(content), `"Key":"port.key","Value":{"Type":"STRING","Value":"AEAJM"}`)
+	})
+
+	t.Run("Given no exporter When setting up Then no error is expected", func(t *testing.T) {
+		shutdown, err := Setup(Config{Exporter: ExporterNone})
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+	})
+
+	t.Run("Given the file exporter without file When setting up Then an error is expected", func(t *testing.T) {
+		_, err := Setup(Config{Exporter: ExporterFile})
+		assert.Error(t, err)
+	})
+
+	t.Run("Given an unknown exporter When setting up Then an error is expected", func(t *testing.T) {
+		_, err := Setup(Config{Exporter: "jaeger"})
+		assert.ErrorContains(t, err, "Invalid trace exporter")
+	})
+}
+
+func TestEnd(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given an error When ending a span Then the span must have the error status", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := tracetest.NewSpanRecorder()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+		_, span := StartRepositoryCall(context.Background(), tracer, semconv.DBSystemSqlite, "sqlite.create", "")
+		End(span, errors.New("Error creating port"))
+
+		require.Len(t, recorder.Ended(), 1)
+		ended := recorder.Ended()[0]
+		assert.Equal(t, codes.Error, ended.Status().Code)
+		assert.Equal(t, "Error creating port", ended.Status().Description)
+		assert.Len(t, ended.Events(), 1, "The error must be recorded as an event")
+
+		for _, attribute := range ended.Attributes() {
+			assert.NotEqual(t, KeyPortKey, attribute.Key, "An empty key must not be an attribute")
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/config"
+	"github.com/cassiuspaim/portimporter/infrastructure/lease"
+)
+
+// Name of the lease acquired before each import.
+const importLease = "import"
+
+// importLock acquires the lease of the imports, so only one instance imports at a time.
+type importLock struct {
+	// store is nil when the lock is off or the store does not support leases.
+	store   lease.Store
+	options lease.Options
+}
+
+func newImportLock(db database, settings config.Lock) importLock {
+	if settings.Mode == config.LockOff {
+		return importLock{}
+	}
+
+	if db.leases == nil {
+		logger.Info("The store does not support locks, imports are not locked")
+
+		return importLock{}
+	}
+
+	owner := settings.Owner
+	if owner == "" {
+		hostname, _ := os.Hostname()
+		owner = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+
+	return importLock{
+		store: db.leases,
+		options: lease.Options{
+			Name:  importLease,
+			Owner: owner,
+			TTL:   settings.TTL,
+			Wait:  settings.Mode == config.LockWait,
+		},
+	}
+}
+
+// Acquires the lease of the imports. It retrieves a context canceled when the lease is lost and
+// the function releasing the lease. It retrieves a *lease.HeldError when another instance holds
+// the lease and the lock does not wait.
+func (l importLock) acquire(ctx context.Context) (context.Context, func(), error) {
+	if l.store == nil {
+		return ctx, func() {}, nil
+	}
+
+	held, err := lease.Acquire(ctx, l.store, l.options)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	return held.Context(), held.Release, nil
+}
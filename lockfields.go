@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/config"
+	"github.com/cassiuspaim/portimporter/infrastructure/iso3166"
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+	"github.com/cassiuspaim/portimporter/infrastructure/normalize"
+)
+
+// Runs the lock-fields command, which locks fields of a stored port so imports never change
+// them, optionally setting the value of the field by hand, or unlocks them.
+func runLockFields(args []string) int {
+	flags := newFlagSet("lock-fields")
+	configFlags := registerConfigFlags(flags)
+	configFlags.registerDatabase(flags)
+	key := flags.String("key", "", "Key of the port, e.g. AEAUH.")
+	fields := flags.String("fields", "", "Fields to lock, separated by commas, e.g. timezone,name. Fields: "+
+		strings.Join(entities.FieldNames, ", ")+".")
+	value := flags.String("value", "", "Value set by hand to the only field locked. Lists are separated by commas, "+
+		"and the coordinates are the longitude and the latitude.")
+	unlock := flags.Bool("unlock", false, "Unlocks the fields instead, so imports change them again.")
+
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	names, err := parseLockFields(flags, *key, *fields, *unlock)
+	if err != nil {
+		fmt.Fprintln(flags.Output(), err)
+		flags.Usage()
+
+		return exitUsage
+	}
+
+	cfg, err := configFlags.load(config.Config.ValidateDatabase)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+	defer configFlags.close()
+
+	db, err := connectToDatabase(cfg.Database)
+	if err != nil {
+		logger.Error("Error connecting to database", "error", err)
+
+		return exitConnectionFailure
+	}
+	defer db.close()
+
+	port, err := db.portRepository.GetByID(context.Background(), *key)
+	if err != nil {
+		logger.Error("Error querying port", logging.KeyPortKey, *key, "error", err)
+
+		return exitFailure
+	}
+
+	if port == nil {
+		logger.Error("Port not found", logging.KeyPortKey, *key)
+
+		return exitFailure
+	}
+
+	if *unlock {
+		port.Locked = unlockFields(port.Locked, names)
+	} else {
+		err = lockFields(port, names, flagSet(flags, "value"), *value, configFlags.runID)
+		if err != nil {
+			logger.Error("Invalid value", logging.KeyPortKey, *key, "error", err)
+
+			return exitUsage
+		}
+	}
+
+	// The update fails when the port changed since it was read, so changes are never overwritten.
+	err = db.portRepository.Update(context.Background(), *port, port.ID)
+	if errors.Is(err, domain.ErrVersionConflict) {
+		logger.Error("Port changed by another writer, run the command again", logging.KeyPortKey, *key)
+
+		return exitFailure
+	}
+
+	if err != nil {
+		logger.Error("Error updating port", logging.KeyPortKey, *key, "error", err)
+
+		return exitFailure
+	}
+
+	logger.Info("Locked fields changed", logging.KeyPortKey, *key, "locked", port.Locked)
+
+	return exitSuccess
+}
+
+// Retrieves the fields of the flags, retrieving an error when the flags are not valid.
+func parseLockFields(flags *flag.FlagSet, key string, fields string, unlock bool) ([]string, error) {
+	if key == "" {
+		return nil, errors.New("The key of the port is required")
+	}
+
+	names := splitFields(fields)
+	if len(names) == 0 {
+		return nil, errors.New("At least one field is required")
+	}
+
+	for _, name := range names {
+		if !entities.IsField(name) {
+			return nil, fmt.Errorf("Unknown field %q, expected one of %s", name, strings.Join(entities.FieldNames, ", "))
+		}
+	}
+
+	if flagSet(flags, "value") && (unlock || len(names) != 1) {
+		return nil, errors.New("A value is only allowed locking a single field")
+	}
+
+	return names, nil
+}
+
+// Locks the fields of the port. When the value is set, it is set to the only field with manual provenance,
+// and the fields derived from it are derived again.
+func lockFields(port *entities.Port, names []string, setValue bool, value string, runID string) error {
+	if setValue {
+		if err := port.SetField(names[0], value); err != nil {
+			return err
+		}
+
+		deriveFields(port, names[0])
+
+		provenance := make(map[string]entities.Provenance, len(port.Provenance)+1)
+		for name, fieldProvenance := range port.Provenance {
+			provenance[name] = fieldProvenance
+		}
+
+		provenance[names[0]] = entities.Provenance{Source: entities.SourceManual, RunID: runID, UpdatedAt: time.Now().UTC()}
+		port.Provenance = provenance
+	}
+
+	for _, name := range names {
+		if !port.IsLocked(name) {
+			port.Locked = append(port.Locked, name)
+		}
+	}
+
+	return nil
+}
+
+// Derives again the fields derived from the field by the import, when the import derived them. The
+// ASCII texts are set by the normalization folding them, and the codes by the countries enrichment.
+func deriveFields(port *entities.Port, name string) {
+	switch name {
+	case "name":
+		if port.NameASCII != "" {
+			port.NameASCII = normalize.FoldASCII(port.Name)
+		}
+	case "city":
+		if port.CityASCII != "" {
+			port.CityASCII = normalize.FoldASCII(port.City)
+		}
+	case "country", "province":
+		if port.CountryCode != "" || port.SubdivisionCode != "" {
+			*port, _ = iso3166.NewEnricher().Enrich(*port)
+		}
+	}
+}
+
+// Retrieves the locked fields without the unlocked ones, nil when none is left.
+func unlockFields(locked []string, names []string) []string {
+	var kept []string
+
+	for _, name := range locked {
+		if !slices.Contains(names, name) {
+			kept = append(kept, name)
+		}
+	}
+
+	return kept
+}
+
+// Retrieves the lowercase fields separated by commas.
+func splitFields(fields string) []string {
+	var names []string
+
+	for _, name := range strings.Split(fields, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Retrieves whether the flag was set at the command line, so empty values can be told apart from
+// missing ones.
+func flagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+
+	flags.Visit(func(visited *flag.Flag) {
+		if visited.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
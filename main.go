@@ -1,157 +1,150 @@
 package main
 
 import (
-	"context"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
-	"os/signal"
-	"sync"
-	"syscall"
-
-	"github.com/cassiuspaim/portimporter/domain/entities"
-	"github.com/cassiuspaim/portimporter/domain/services"
-	"github.com/cassiuspaim/portimporter/infrastructure/jsonstream"
-	"github.com/cassiuspaim/portimporter/infrastructure/repositories/mongodb"
+	"strings"
 
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
 	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func main() {
-	// Handle the signals to handle graceful shutdown.
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-
-	var waitGroup sync.WaitGroup
-
-	waitGroup.Add(1)
-
-	clientDB := connectToDatabase()
-
-	runApp(ctx, clientDB, &waitGroup, stop)
+var logger = logging.For("cli")
+
+// Exit codes of the commands.
+const (
+	exitSuccess           = 0
+	exitFailure           = 1
+	exitUsage             = 2
+	exitPartialFailure    = 3
+	exitValidationFailure = 4
+	exitConnectionFailure = 5
+	exitLockHeld          = 6
+)
 
-	waitGroup.Wait()
-	closeApp(clientDB)
+type command struct {
+	name        string
+	description string
+	run         func(args []string) int
 }
 
-func runApp(ctx context.Context, dbConnect *mongo.Client, waitGroup *sync.WaitGroup, stop context.CancelFunc) {
-	portRepository := mongodb.NewPortRepository(dbConnect, os.Getenv("DB_NAME"))
-	portService := services.NewPortService(portRepository)
-	stream := jsonstream.NewPortStream()
-
-	go func() {
-		defer waitGroup.Done()
-
-		for {
-			select {
-			case <-ctx.Done():
-				log.Printf("Stopping Port import. Stopping message: %v\n", ctx.Err())
-
-				return
-
-			default:
-				for entry := range stream.Watch() {
-					if entry.Error != nil {
-						log.Println(entry.Error)
-
-						continue
-					}
-
-					port := entities.Port{
-						ID:          entry.Key,
-						Name:        entry.Data.Name,
-						Coordinates: entry.Data.Coordinates,
-						City:        entry.Data.City,
-						Province:    entry.Data.Province,
-						Country:     entry.Data.Country,
-						Alias:       entry.Data.Alias,
-						Regions:     entry.Data.Regions,
-						Unlocs:      entry.Data.Unlocs,
-						Timezone:    entry.Data.Timezone,
-						Code:        entry.Data.Code,
-					}
-
-					err := portService.Upsert(port)
-					if err != nil {
-						log.Printf("Error upserting the Port %s. Error: %s", port.ID, err)
-					}
-				}
-
-				stop()
-			}
-		}
-	}()
-
-	log.Println("Openning port file.")
-
-	fileName := os.Getenv("PORT_JSON_PATH")
-	file, err := os.Open(fileName)
-
-	if err != nil {
-		log.Fatalf("Error opening file %s", fileName)
+// Retrieves the commands of the application. It is a function, not a variable, because the
+// commands refer to newFlagSet which refers back to them.
+func commands() []command {
+	return []command{
+		{"import", "Imports the ports of a JSON file into the store.", runImport},
+		{"export", "Exports every stored port to a file.", runExport},
+		{"validate", "Validates a JSON file of ports without storing them.", runValidate},
+		{"diff", "Shows the ports of a JSON file that differ from the stored ones.", runDiff},
+		{"daemon", "Imports the ports of a JSON file on a schedule, skipping files that did not change.", runDaemon},
+		{"watch", "Imports the ports of a JSON file each time the file changes.", runWatch},
+		{"lock-fields", "Locks fields of a stored port so imports never change them, or unlocks them.", runLockFields},
+		{"provenance", "Shows where the value of each field of a stored port came from.", runProvenance},
+		{"serve", "Serves the stored ports through HTTP.", runServe},
+		{"migrate", "Applies the migrations of the store.", runMigrate},
+		{"healthcheck", "Checks the readiness of a running server, e.g. as Docker HEALTHCHECK.", runHealthcheck},
 	}
-	defer file.Close()
-
-	stream.Start(file)
 }
 
-func connectToDatabase() *mongo.Client {
+func main() {
 	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found")
+		logger.Info("No .env file found")
 	} else {
-		log.Println("Env file found.")
+		logger.Info("Env file found")
 	}
 
-	dbAuthName := os.Getenv("DB_AUTHENTICATION_NAME")
-	dbUserName := os.Getenv("DB_USER_NAME")
-	dbPassword := os.Getenv("DB_USER_PASSWORD")
-	dbURI := os.Getenv("DB_CONNECTION_URI")
-
-	log.Printf("Connecting DB through auth name %s\n", dbAuthName)
-	log.Printf("Connecting DB using user name %s\n", dbUserName)
-	log.Printf("Connecting DB using password %s\n", dbPassword)
-	log.Printf("Connecting DB to URI %s\n", dbURI)
-
-	// Set credential
-	credential := options.Credential{
-		AuthSource: dbAuthName,
-		Username:   dbUserName,
-		Password:   dbPassword,
+	os.Exit(run(os.Args[1:]))
+}
+
+// Runs the command named by the first argument and retrieves its exit code.
+func run(args []string) int {
+	if len(args) == 0 {
+		// Without a command the ports are imported, as the application did before having commands.
+		return runImport(args)
 	}
 
-	// Set client options
-	clientOptions := options.Client().ApplyURI(dbURI).SetAuth(credential)
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		printUsage()
 
-	// Connect to MongoDB
-	client, err := mongo.Connect(context.TODO(), clientOptions)
-	if err != nil {
-		log.Fatal(err)
+		return exitSuccess
 	}
 
-	// Check the connection
-	err = client.Ping(context.TODO(), nil)
-	if err != nil {
-		log.Fatal(err)
+	for _, command := range commands() {
+		if command.name == args[0] {
+			return command.run(args[1:])
+		}
+	}
+
+	if strings.HasPrefix(args[0], "-") {
+		return runImport(args)
 	}
 
-	log.Println("Connected to database!")
+	fmt.Fprintf(os.Stderr, "Unknown command %s\n\n", args[0])
+	printUsage()
 
-	return client
+	return exitUsage
 }
 
-func closeApp(clientDB *mongo.Client) {
-	log.Println("Closing app.")
-	closeConnection(clientDB)
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: portimporter <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+
+	for _, command := range commands() {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", command.name, command.description)
+	}
+
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run portimporter <command> --help for the flags of a command.")
+	fmt.Fprintln(os.Stderr, "Flags take precedence over environment variables, the .env file and the configuration file.")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Exit codes:")
+	fmt.Fprintf(os.Stderr, "  %d success\n", exitSuccess)
+	fmt.Fprintf(os.Stderr, "  %d failure\n", exitFailure)
+	fmt.Fprintf(os.Stderr, "  %d invalid command, flags or configuration\n", exitUsage)
+	fmt.Fprintf(os.Stderr, "  %d partial failure, some ports were not imported\n", exitPartialFailure)
+	fmt.Fprintf(os.Stderr, "  %d validation failure\n", exitValidationFailure)
+	fmt.Fprintf(os.Stderr, "  %d connection failure\n", exitConnectionFailure)
+	fmt.Fprintf(os.Stderr, "  %d another instance is importing\n", exitLockHeld)
+}
+
+// Retrieves the flag set of a command, whose usage shows the description of the command.
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	flags.Usage = func() {
+		for _, command := range commands() {
+			if command.name == name {
+				fmt.Fprintf(flags.Output(), "Usage: portimporter %s [flags]\n\n%s\n\nFlags:\n", name, command.description)
+			}
+		}
+
+		flags.PrintDefaults()
+	}
+
+	return flags
 }
 
-func closeConnection(clientDB *mongo.Client) {
-	log.Println("Start closing database connection.")
+// Parses the flags of a command. It retrieves false, with the exit code, when the command must not run.
+func parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitSuccess, false
+	}
 
-	err := clientDB.Disconnect(context.TODO())
 	if err != nil {
-		log.Fatal(err)
+		return exitUsage, false
+	}
+
+	if flags.NArg() > 0 {
+		fmt.Fprintf(flags.Output(), "Unexpected arguments %v\n", flags.Args())
+		flags.Usage()
+
+		return exitUsage, false
 	}
 
-	log.Println("Database connection closed.")
+	return exitSuccess, true
 }
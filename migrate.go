@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/cassiuspaim/portimporter/infrastructure/config"
+)
+
+// Runs the migrate command, which applies the migrations of the store.
+func runMigrate(args []string) int {
+	flags := newFlagSet("migrate")
+	configFlags := registerConfigFlags(flags)
+	configFlags.registerDatabase(flags)
+
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	cfg, err := configFlags.load(config.Config.ValidateDatabase)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+	defer configFlags.close()
+
+	db, err := connectToDatabase(cfg.Database)
+	if err != nil {
+		logger.Error("Error connecting to database", "error", err)
+
+		return exitConnectionFailure
+	}
+	defer db.close()
+
+	if err = db.migrate(); err != nil {
+		logger.Error("Error migrating database", "error", err)
+
+		return exitFailure
+	}
+
+	logger.Info("Migrations applied")
+
+	return exitSuccess
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/health"
+	"github.com/cassiuspaim/portimporter/infrastructure/metrics"
+)
+
+// Serves GET /metrics at the address until ctx is done and, when checks is not nil, the
+// liveness and readiness of checks. Nothing is served when the address is empty. Errors are
+// only logged, the metrics must not stop the command.
+func serveOperations(ctx context.Context, address string, checks *health.Health) {
+	if address == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	if checks != nil {
+		checks.Register(mux)
+	}
+
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: shutdownTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		if err := server.Close(); err != nil {
+			logger.Error("Error stopping metrics server", "error", err)
+		}
+	}()
+
+	go func() {
+		logger.Info("Serving metrics", "address", address)
+
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Error serving metrics", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/domain/entities"
+	"github.com/cassiuspaim/portimporter/infrastructure/config"
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+)
+
+// Runs the provenance command, which writes to the standard output where the value of each
+// field of a stored port came from, and whether the field is locked.
+func runProvenance(args []string) int {
+	flags := newFlagSet("provenance")
+	configFlags := registerConfigFlags(flags)
+	configFlags.registerDatabase(flags)
+	key := flags.String("key", "", "Key of the port, e.g. AEAUH.")
+
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	if *key == "" {
+		fmt.Fprintln(flags.Output(), "The key of the port is required")
+		flags.Usage()
+
+		return exitUsage
+	}
+
+	cfg, err := configFlags.load(config.Config.ValidateDatabase)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+	defer configFlags.close()
+
+	db, err := connectToDatabase(cfg.Database)
+	if err != nil {
+		logger.Error("Error connecting to database", "error", err)
+
+		return exitConnectionFailure
+	}
+	defer db.close()
+
+	port, err := db.portRepository.GetByID(context.Background(), *key)
+	if err != nil {
+		logger.Error("Error querying port", logging.KeyPortKey, *key, "error", err)
+
+		return exitFailure
+	}
+
+	if port == nil {
+		logger.Error("Port not found", logging.KeyPortKey, *key)
+
+		return exitFailure
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "FIELD\tVALUE\tSOURCE\tRUN\tUPDATED\tLOCKED")
+
+	for _, name := range entities.FieldNames {
+		source, runID, updatedAt := "-", "-", "-"
+
+		if provenance, ok := port.Provenance[name]; ok {
+			source, runID, updatedAt = provenance.Source, provenance.RunID, provenance.UpdatedAt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%t\n", name, port.Field(name), source, runID, updatedAt,
+			port.IsLocked(name))
+	}
+
+	if err = table.Flush(); err != nil {
+		logger.Error("Error writing provenance", "error", err)
+
+		return exitFailure
+	}
+
+	return exitSuccess
+}
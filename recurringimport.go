@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/domain"
+	"github.com/cassiuspaim/portimporter/infrastructure/lease"
+	"github.com/cassiuspaim/portimporter/infrastructure/logging"
+)
+
+// recurringImport imports a file many times. The file is not imported again while its checksum
+// is the one of the last successful import, and imports never overlap. Each run has its own run ID,
+// at its logs and at the provenance of the values it imports, instead of the one of the options.
+type recurringImport struct {
+	mutex       sync.Mutex
+	portService domain.PortService
+	lock        importLock
+	path        string
+	options     importOptions
+	// lastChecksum is only kept in memory, so the first run after a restart always imports the
+	// file. The ports of an unchanged file are then counted as unchanged, and nothing is written.
+	lastChecksum string
+	// summary, when not nil, receives a line with the result of each run.
+	summary io.Writer
+}
+
+func newRecurringImport(portService domain.PortService, lock importLock, path string, options importOptions,
+	summary io.Writer,
+) *recurringImport {
+	return &recurringImport{
+		portService: portService,
+		lock:        lock,
+		path:        path,
+		options:     options,
+		summary:     summary,
+	}
+}
+
+// Imports the file unless an import is in progress, here or at another instance holding the
+// lock, or the file did not change since the last successful import. The report of the import
+// is logged.
+func (r *recurringImport) run(ctx context.Context) {
+	if !r.mutex.TryLock() {
+		logger.WarnContext(ctx, "Import skipped, the previous import is in progress", "file", r.path)
+
+		return
+	}
+	defer r.mutex.Unlock()
+
+	options := r.options
+	options.runID = logging.NewRunID()
+	ctx = logging.WithRunID(ctx, options.runID)
+
+	checksum, err := fileChecksum(r.path)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error reading file", "file", r.path, "error", err)
+		r.printSummary("error reading file: %s", err)
+
+		return
+	}
+
+	if checksum == r.lastChecksum {
+		logger.InfoContext(ctx, "Import skipped, the file did not change since the last successful import", "file", r.path,
+			"checksum", checksum)
+		r.printSummary("skipped, file unchanged")
+
+		return
+	}
+
+	ctx, release, err := r.lock.acquire(ctx)
+	if err != nil {
+		var held *lease.HeldError
+		if errors.As(err, &held) {
+			logger.WarnContext(ctx, "Import skipped, another instance is importing", "owner", held.Owner,
+				"expires_at", held.ExpiresAt)
+			r.printSummary("skipped, %s is importing", held.Owner)
+
+			return
+		}
+
+		logger.ErrorContext(ctx, "Error acquiring lock", "error", err)
+		r.printSummary("error acquiring lock: %s", err)
+
+		return
+	}
+	defer release()
+
+	report, checksum, err := importFile(ctx, r.portService, r.path, options)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error opening file", "file", r.path, "error", err)
+		r.printSummary("error opening file: %s", err)
+
+		return
+	}
+
+	logger.InfoContext(ctx, "Import finished", "report", report, "checksum", checksum)
+	r.printSummary("%s", report)
+
+	for _, detail := range report.Details() {
+		r.printDetail(detail)
+	}
+
+	if report.Failed() == 0 && !report.Interrupted {
+		r.lastChecksum = checksum
+	}
+}
+
+// Writes an indented line with a detail of the result of a run to the summary.
+func (r *recurringImport) printDetail(detail string) {
+	if r.summary == nil {
+		return
+	}
+
+	fmt.Fprintf(r.summary, "  %s\n", detail)
+}
+
+// Writes a line with the time, the file and the result of a run to the summary.
+func (r *recurringImport) printSummary(format string, args ...interface{}) {
+	if r.summary == nil {
+		return
+	}
+
+	fmt.Fprintf(r.summary, "%s %s %s\n", time.Now().Format(time.TimeOnly), r.path, fmt.Sprintf(format, args...))
+}
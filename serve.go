@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/config"
+	"github.com/cassiuspaim/portimporter/infrastructure/health"
+	"github.com/cassiuspaim/portimporter/infrastructure/httpserver"
+	"github.com/cassiuspaim/portimporter/infrastructure/metrics"
+)
+
+// Time given to the requests in progress to finish when the server is stopped.
+const shutdownTimeout = 10 * time.Second
+
+// Runs the serve command, which serves the stored ports through HTTP until a signal is received.
+func runServe(args []string) int {
+	flags := newFlagSet("serve")
+	configFlags := registerConfigFlags(flags)
+	configFlags.registerDatabase(flags)
+	configFlags.registerServer(flags)
+
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	cfg, err := configFlags.load(config.Config.ValidateDatabase, config.Config.ValidateServer)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+	defer configFlags.close()
+
+	// Handle the signals to handle graceful shutdown.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := connectToDatabase(cfg.Database)
+	if err != nil {
+		logger.Error("Error connecting to database", "error", err)
+
+		return exitConnectionFailure
+	}
+	defer db.close()
+
+	if err = db.migrate(); err != nil {
+		logger.Error("Error migrating database", "error", err)
+
+		return exitFailure
+	}
+
+	checks := health.New()
+	db.addHealthChecks(checks)
+
+	handler := httpserver.NewHandler(db.portRepository)
+	handler.Handle("/metrics", metrics.Handler())
+	checks.Register(handler)
+
+	server := &http.Server{
+		Addr:              cfg.Server.Address,
+		Handler:           handler,
+		ReadHeaderTimeout: shutdownTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		logger.Info("Stopping server")
+		checks.ShutDown()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Error stopping server", "error", err)
+		}
+	}()
+
+	logger.Info("Serving ports", "address", server.Addr)
+
+	if err = server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("Error serving ports", "error", err)
+
+		return exitFailure
+	}
+
+	return exitSuccess
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cassiuspaim/portimporter/infrastructure/config"
+	"github.com/cassiuspaim/portimporter/infrastructure/jsonstream"
+)
+
+// Runs the validate command, which reads every port of the JSON file and reports the invalid ones.
+func runValidate(args []string) int {
+	flags := newFlagSet("validate")
+	configFlags := registerConfigFlags(flags)
+	configFlags.registerImport(flags)
+
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	cfg, err := configFlags.load(config.Config.ValidateImport)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+	defer configFlags.close()
+
+	file, err := os.Open(cfg.Import.File)
+	if err != nil {
+		logger.Error("Error opening file", "file", cfg.Import.File, "error", err)
+
+		return exitFailure
+	}
+	defer file.Close()
+
+	total, invalid, duplicates := 0, 0, 0
+
+	for entry := range readPorts(file, jsonstream.DuplicatePolicy(cfg.Import.Duplicates)) {
+		total++
+
+		if entry.DuplicateOf != 0 {
+			duplicates++
+
+			// The failed duplicates are printed with their error.
+			if entry.Error == nil {
+				fmt.Printf("%s: duplicate key, first read at offset %d\n", entry.Port.ID, entry.DuplicateOf)
+			}
+		}
+
+		if entry.Ignored {
+			continue
+		}
+
+		if entry.Error != nil {
+			invalid++
+
+			fmt.Printf("%s: %v\n", entry.Port.ID, entry.Error)
+
+			continue
+		}
+
+		if problems := entry.Port.Validate(); len(problems) > 0 {
+			invalid++
+
+			fmt.Printf("%s: %s\n", entry.Port.ID, strings.Join(problems, "; "))
+		}
+	}
+
+	logger.Info("Validation finished", "ports", total, "invalid", invalid, "duplicates", duplicates)
+
+	if invalid > 0 {
+		return exitValidationFailure
+	}
+
+	return exitSuccess
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/cassiuspaim/portimporter/domain/services"
+	"github.com/cassiuspaim/portimporter/infrastructure/config"
+	"github.com/cassiuspaim/portimporter/infrastructure/filewatch"
+)
+
+// Runs the watch command, which imports the JSON file each time it changes until a signal is
+// received, printing the result of each import.
+func runWatch(args []string) int {
+	flags := newFlagSet("watch")
+	configFlags := registerConfigFlags(flags)
+	configFlags.registerDatabase(flags)
+	configFlags.registerImport(flags)
+	configFlags.registerUpsert(flags)
+	configFlags.registerLock(flags)
+	configFlags.registerWatch(flags)
+	configFlags.registerMetrics(flags)
+
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	cfg, err := configFlags.load(config.Config.ValidateDatabase, config.Config.ValidateImport,
+		config.Config.ValidateWatch, config.Config.ValidateLock)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+	defer configFlags.close()
+
+	mergePolicies, err := services.ParseMergePolicies(cfg.Import.Merge)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+
+		return exitUsage
+	}
+
+	// Handle the signals to handle graceful shutdown.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveOperations(ctx, cfg.Metrics.Address, nil)
+
+	db, err := connectToDatabase(cfg.Database)
+	if err != nil {
+		logger.Error("Error connecting to database", "error", err)
+
+		return exitConnectionFailure
+	}
+	defer db.close()
+
+	if err = db.migrate(); err != nil {
+		logger.Error("Error migrating database", "error", err)
+
+		return exitFailure
+	}
+
+	portService := newPortService(db.portRepository, cfg.Import, mergePolicies)
+	recurring := newRecurringImport(portService, newImportLock(db, cfg.Lock), cfg.Import.File,
+		newImportOptions(cfg.Import, configFlags.runID), os.Stdout)
+	if err = filewatch.Watch(ctx, cfg.Import.File, cfg.Watch.Debounce, recurring.run); err != nil {
+		logger.Error("Error watching file", "file", cfg.Import.File, "error", err)
+
+		return exitFailure
+	}
+
+	logger.Info("Watch stopped")
+
+	return exitSuccess
+}